@@ -0,0 +1,561 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// hitungSaldoRekening menghitung saldo awal, mutasi debet/kredit dan saldo akhir setiap rekening
+// untuk rentang tanggal yang diberikan dari tabel jurnal dan detailjurnal Khanza. awalFiskal
+// adalah awal tahun fiskal yang memuat tanggal awal rentang.
+func hitungSaldoRekening(db *gorm.DB, rentang rentangTanggal, awalFiskal time.Time) ([]models.SaldoRekening, error) {
+	query := `
+		SELECT
+			rekening.kd_rek,
+			rekening.nm_rek,
+			rekening.tipe,
+			rekening.balance,
+			rekening.level,
+			COALESCE(subrekening.kd_rek, '') AS induk,
+			COALESCE(rekeningtahun.saldo_awal, 0) AS saldo_tahun,
+			COALESCE(sebelum.debet, 0) AS debet_sebelum,
+			COALESCE(sebelum.kredit, 0) AS kredit_sebelum,
+			COALESCE(sebelum_fiskal.debet, 0) AS debet_sebelum_fiskal,
+			COALESCE(sebelum_fiskal.kredit, 0) AS kredit_sebelum_fiskal,
+			COALESCE(periode.debet, 0) AS debet,
+			COALESCE(periode.kredit, 0) AS kredit
+		FROM
+			rekening
+		LEFT JOIN subrekening ON subrekening.kd_rek2 = rekening.kd_rek
+		LEFT JOIN rekeningtahun ON rekeningtahun.kd_rek = rekening.kd_rek
+			AND rekeningtahun.thn = YEAR(@awal)
+		LEFT JOIN
+		(
+			SELECT detailjurnal.kd_rek, SUM(detailjurnal.debet) AS debet, SUM(detailjurnal.kredit) AS kredit
+			FROM jurnal
+			INNER JOIN detailjurnal ON detailjurnal.no_jurnal = jurnal.no_jurnal
+			WHERE jurnal.tgl_jurnal >= MAKEDATE(YEAR(@awal), 1) AND jurnal.tgl_jurnal < @awal
+			GROUP BY detailjurnal.kd_rek
+		) AS sebelum ON sebelum.kd_rek = rekening.kd_rek
+		LEFT JOIN
+		(
+			SELECT detailjurnal.kd_rek, SUM(detailjurnal.debet) AS debet, SUM(detailjurnal.kredit) AS kredit
+			FROM jurnal
+			INNER JOIN detailjurnal ON detailjurnal.no_jurnal = jurnal.no_jurnal
+			WHERE jurnal.tgl_jurnal >= @awal_fiskal AND jurnal.tgl_jurnal < @awal
+			GROUP BY detailjurnal.kd_rek
+		) AS sebelum_fiskal ON sebelum_fiskal.kd_rek = rekening.kd_rek
+		LEFT JOIN
+		(
+			SELECT detailjurnal.kd_rek, SUM(detailjurnal.debet) AS debet, SUM(detailjurnal.kredit) AS kredit
+			FROM jurnal
+			INNER JOIN detailjurnal ON detailjurnal.no_jurnal = jurnal.no_jurnal
+			WHERE jurnal.tgl_jurnal >= @awal AND jurnal.tgl_jurnal < @akhir
+			GROUP BY detailjurnal.kd_rek
+		) AS periode ON periode.kd_rek = rekening.kd_rek
+		ORDER BY
+			rekening.kd_rek
+	`
+
+	parameter := rentang.Parameter()
+	parameter["awal_fiskal"] = awalFiskal.Format("2006-01-02")
+
+	var rekening []models.SaldoRekening
+	err := db.Raw(query, parameter).Scan(&rekening).Error
+	if err != nil {
+		return nil, err
+	}
+	return susunSaldoRekening(rekening), nil
+}
+
+// susunSaldoRekening menghitung saldo awal dan akhir dari hasil query hitungSaldoRekening.
+//
+// Saldo awal rekening N dan M = rekeningtahun.saldo_awal tahun tanggal awal ditambah mutasi sejak
+// 1 Januari tahun tersebut sampai sehari sebelum tanggal awal. Rekening R dimulai dari nol pada
+// awal tahun fiskal sehingga saldo awalnya hanya mutasi sejak awal tahun fiskal; sisa saldo
+// kalendernya (saldo tahun dan mutasi sebelum awal tahun fiskal) dicatat di SaldoTahunLalu.
+// Nilai rekening induk (subrekening) berisi akumulasi seluruh sub rekeningnya.
+func susunSaldoRekening(rekening []models.SaldoRekening) []models.SaldoRekening {
+	indeks := map[string]int{}
+	for i, rek := range rekening {
+		indeks[rek.KdRek] = i
+	}
+
+	// Nilai dihitung sebagai saldo debet (debet positif) agar bisa dijumlahkan lintas saldo normal
+	netAwalSendiri := make([]float64, len(rekening))
+	netLaluSendiri := make([]float64, len(rekening))
+	for i, rek := range rekening {
+		if _, ok := indeks[rek.Induk]; !ok {
+			rekening[i].Induk = ""
+		}
+
+		saldoTahun := rek.SaldoTahun
+		if rek.Balance == "K" {
+			saldoTahun = -saldoTahun
+		}
+		netKalender := saldoTahun + rek.DebetSebelum - rek.KreditSebelum
+		if rek.Tipe == "R" {
+			netAwalSendiri[i] = rek.DebetSebelumFiskal - rek.KreditSebelumFiskal
+			netLaluSendiri[i] = netKalender - netAwalSendiri[i]
+		} else {
+			netAwalSendiri[i] = netKalender
+		}
+	}
+
+	netAwal := append([]float64{}, netAwalSendiri...)
+	netLalu := append([]float64{}, netLaluSendiri...)
+	debet := make([]float64, len(rekening))
+	kredit := make([]float64, len(rekening))
+	for i, rek := range rekening {
+		debet[i] = rek.Debet
+		kredit[i] = rek.Kredit
+	}
+
+	// Akumulasikan nilai setiap rekening ke seluruh rekening induknya
+	for i, rek := range rekening {
+		induk := rek.Induk
+		for langkah := 0; induk != "" && langkah < len(rekening); langkah++ {
+			j := indeks[induk]
+			rekening[j].AdaSub = true
+			rekening[i].Kedalaman++
+			netAwal[j] += netAwalSendiri[i]
+			netLalu[j] += netLaluSendiri[i]
+			debet[j] += rek.Debet
+			kredit[j] += rek.Kredit
+			induk = rekening[j].Induk
+		}
+	}
+
+	for i, rek := range rekening {
+		netAkhir := netAwal[i] + debet[i] - kredit[i]
+		if rek.Balance == "K" {
+			rekening[i].SaldoAwal = -netAwal[i]
+			rekening[i].SaldoAkhir = -netAkhir
+			rekening[i].SaldoTahunLalu = -netLalu[i]
+		} else {
+			rekening[i].SaldoAwal = netAwal[i]
+			rekening[i].SaldoAkhir = netAkhir
+			rekening[i].SaldoTahunLalu = netLalu[i]
+		}
+		rekening[i].Debet = debet[i]
+		rekening[i].Kredit = kredit[i]
+	}
+
+	return rekening
+}
+
+// awalFiskalRentang mengembalikan awal tahun fiskal yang memuat tanggal awal rentang
+func awalFiskalRentang(rentang rentangTanggal) time.Time {
+	bulanAwal := bulanAwalTahunFiskal()
+	mulai := rentang.Mulai()
+	return awalTahunFiskal(tahunFiskalDari(mulai, bulanAwal), bulanAwal, mulai.Location())
+}
+
+// labaTahunLalu menjumlahkan laba rekening R dari tahun fiskal sebelumnya yang belum ditutup ke
+// modal (SaldoTahunLalu), dihitung dari rekening akar
+func labaTahunLalu(rekening []models.SaldoRekening) float64 {
+	var laba float64
+	for _, rek := range rekeningAkar(rekening) {
+		if rek.Tipe == "R" {
+			laba -= saldoDebet(rek, rek.SaldoTahunLalu)
+		}
+	}
+	return laba
+}
+
+// rekeningAkar mengembalikan rekening yang tidak memiliki induk. Nilai rekening akar sudah
+// mencakup seluruh sub rekeningnya sehingga total cukup dihitung dari rekening akar.
+func rekeningAkar(rekening []models.SaldoRekening) []models.SaldoRekening {
+	result := []models.SaldoRekening{}
+	for _, rek := range rekening {
+		if rek.Induk == "" {
+			result = append(result, rek)
+		}
+	}
+	return result
+}
+
+// rekeningBermutasi membuang rekening yang tidak memiliki saldo maupun mutasi
+func rekeningBermutasi(rekening []models.SaldoRekening) []models.SaldoRekening {
+	result := []models.SaldoRekening{}
+	for _, rek := range rekening {
+		if rek.SaldoAwal != 0 || rek.Debet != 0 || rek.Kredit != 0 || rek.SaldoAkhir != 0 {
+			result = append(result, rek)
+		}
+	}
+	return result
+}
+
+// saldoDebet mengubah saldo sesuai saldo normal menjadi saldo debet (debet positif)
+func saldoDebet(rek models.SaldoRekening, saldo float64) float64 {
+	if rek.Balance == "K" {
+		return -saldo
+	}
+	return saldo
+}
+
+// BukuBesarHandler menangani permintaan buku besar satu rekening beserta saldo berjalannya
+func BukuBesarHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+	kdRek := r.URL.Query().Get("kd_rek")
+	if kdRek == "" {
+		utils.WriteFieldError(w, "kd_rek", "Parameter kd_rek wajib diisi")
+		return
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	rekening, err := hitungSaldoRekening(db, rentang, awalFiskalRentang(rentang))
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menghitung saldo rekening", err)
+		return
+	}
+
+	var akun *models.SaldoRekening
+	for i := range rekening {
+		if rekening[i].KdRek == kdRek {
+			akun = &rekening[i]
+			break
+		}
+	}
+	if akun == nil {
+		utils.WriteError(w, http.StatusNotFound, "Rekening tidak ditemukan")
+		return
+	}
+
+	query := `
+		SELECT
+			jurnal.no_jurnal,
+			jurnal.no_bukti,
+			jurnal.tgl_jurnal,
+			jurnal.jam_jurnal,
+			jurnal.jenis,
+			jurnal.keterangan,
+			detailjurnal.debet,
+			detailjurnal.kredit
+		FROM
+			jurnal
+		INNER JOIN detailjurnal ON detailjurnal.no_jurnal = jurnal.no_jurnal
+		WHERE
+			detailjurnal.kd_rek = ?
+			AND jurnal.tgl_jurnal >= ? AND jurnal.tgl_jurnal < ?
+		ORDER BY
+			jurnal.tgl_jurnal, jurnal.jam_jurnal, jurnal.no_jurnal
+	`
+
+	var result []models.BarisBukuBesar
+	if err := db.Raw(query, kdRek, rentang.MulaiSQL(), rentang.SelesaiSQL()).Scan(&result).Error; err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query", err)
+		return
+	}
+
+	// Jika tidak ada hasil, kembalikan array kosong
+	if result == nil {
+		result = []models.BarisBukuBesar{}
+	}
+
+	// Hitung saldo berjalan sesuai saldo normal rekening. Untuk rekening induk, buku besar hanya
+	// memuat jurnal yang langsung diposting ke rekening tersebut sehingga dimulai dari nol.
+	saldoAwal := akun.SaldoAwal
+	if akun.AdaSub {
+		saldoAwal = 0
+	}
+	saldo := saldoAwal
+	var totalDebet, totalKredit float64
+	for i := range result {
+		if akun.Balance == "K" {
+			saldo += result[i].Kredit - result[i].Debet
+		} else {
+			saldo += result[i].Debet - result[i].Kredit
+		}
+		result[i].Saldo = saldo
+		totalDebet += result[i].Debet
+		totalKredit += result[i].Kredit
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data buku besar berhasil diambil dari database",
+		"filter": map[string]string{
+			"tanggal_awal":  tanggalAwal,
+			"tanggal_akhir": tanggalAkhir,
+			"periode":       rentang.Periode,
+			"kd_rek":        kdRek,
+		},
+		"rekening":     akun,
+		"saldo_awal":   saldoAwal,
+		"total_debet":  totalDebet,
+		"total_kredit": totalKredit,
+		"saldo_akhir":  saldo,
+		"total_data":   len(result),
+		"data":         result,
+	}
+
+	writeJSON(w, response)
+}
+
+// NeracaSaldoHandler menangani permintaan neraca saldo (trial balance) untuk suatu periode
+func NeracaSaldoHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	rekening, err := hitungSaldoRekening(db, rentang, awalFiskalRentang(rentang))
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menghitung saldo rekening", err)
+		return
+	}
+
+	// Total neraca saldo dihitung dari rekening akar agar sub rekening tidak terhitung dua kali
+	var totalMutasiDebet, totalMutasiKredit, totalSaldoDebet, totalSaldoKredit float64
+	for _, rek := range rekeningAkar(rekening) {
+		totalMutasiDebet += rek.Debet
+		totalMutasiKredit += rek.Kredit
+
+		if saldo := saldoDebet(rek, rek.SaldoAkhir); saldo >= 0 {
+			totalSaldoDebet += saldo
+		} else {
+			totalSaldoKredit += -saldo
+		}
+	}
+	// Saldo rekening R sebelum awal tahun fiskal tidak termasuk saldo rekening, sehingga
+	// disajikan terpisah agar neraca saldo tetap seimbang
+	labaLalu := labaTahunLalu(rekening)
+	if labaLalu >= 0 {
+		totalSaldoKredit += labaLalu
+	} else {
+		totalSaldoDebet += -labaLalu
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data neraca saldo berhasil diambil dari database",
+		"filter": map[string]string{
+			"tanggal_awal":  tanggalAwal,
+			"tanggal_akhir": tanggalAkhir,
+			"periode":       rentang.Periode,
+		},
+		"total_mutasi_debet":  totalMutasiDebet,
+		"total_mutasi_kredit": totalMutasiKredit,
+		"total_saldo_debet":   totalSaldoDebet,
+		"total_saldo_kredit":  totalSaldoKredit,
+		"laba_tahun_lalu":     labaLalu,
+		"seimbang":            math.Abs(totalSaldoDebet-totalSaldoKredit) < 0.01,
+		"keterangan":          "Saldo disajikan sesuai saldo normal rekening, nilai rekening induk mencakup seluruh sub rekening",
+		"data":                rekeningBermutasi(rekening),
+	}
+
+	writeJSON(w, response)
+}
+
+// LabaRugiHandler menangani permintaan laporan laba rugi dari rekening bertipe R
+func LabaRugiHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	rekening, err := hitungSaldoRekening(db, rentang, awalFiskalRentang(rentang))
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menghitung saldo rekening", err)
+		return
+	}
+
+	// Laba rugi hanya memakai mutasi periode, saldo awal rekening R diabaikan
+	pendapatan := []models.SaldoRekening{}
+	beban := []models.SaldoRekening{}
+	for _, rek := range rekening {
+		if rek.Tipe != "R" || (rek.Debet == 0 && rek.Kredit == 0) {
+			continue
+		}
+		if rek.Balance == "K" {
+			rek.SaldoAkhir = rek.Kredit - rek.Debet
+			pendapatan = append(pendapatan, rek)
+		} else {
+			rek.SaldoAkhir = rek.Debet - rek.Kredit
+			beban = append(beban, rek)
+		}
+	}
+
+	var totalPendapatan, totalBeban float64
+	for _, rek := range rekeningAkar(rekening) {
+		if rek.Tipe != "R" {
+			continue
+		}
+		if rek.Balance == "K" {
+			totalPendapatan += rek.Kredit - rek.Debet
+		} else {
+			totalBeban += rek.Debet - rek.Kredit
+		}
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data laba rugi berhasil diambil dari database",
+		"filter": map[string]string{
+			"tanggal_awal":  tanggalAwal,
+			"tanggal_akhir": tanggalAkhir,
+			"periode":       rentang.Periode,
+		},
+		"total_pendapatan": totalPendapatan,
+		"total_beban":      totalBeban,
+		"laba_bersih":      totalPendapatan - totalBeban,
+		"pendapatan":       pendapatan,
+		"beban":            beban,
+	}
+
+	writeJSON(w, response)
+}
+
+// NeracaHandler menangani permintaan neraca (posisi keuangan) per tanggal_akhir.
+// Saldo dihitung sejak awal tahun fiskal yang memuat tanggal_akhir. Laba tahun berjalan (rekening
+// R sejak awal tahun fiskal) dan laba tahun lalu yang belum ditutup ke modal ditambahkan ke pasiva.
+func NeracaHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentangPeriode, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	rentang := rentangPeriode.SejakAwalTahunFiskal(bulanAwalTahunFiskal())
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	rekening, err := hitungSaldoRekening(db, rentang, awalFiskalRentang(rentang))
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menghitung saldo rekening", err)
+		return
+	}
+
+	aktiva := []models.SaldoRekening{}
+	kewajiban := []models.SaldoRekening{}
+	modal := []models.SaldoRekening{}
+	for _, rek := range rekeningBermutasi(rekening) {
+		switch {
+		case rek.Tipe == "N" && rek.Balance == "D":
+			aktiva = append(aktiva, rek)
+		case rek.Tipe == "N":
+			kewajiban = append(kewajiban, rek)
+		case rek.Tipe == "M":
+			modal = append(modal, rek)
+		}
+	}
+
+	var totalAktiva, totalKewajiban, totalModal, labaTahunBerjalan float64
+	for _, rek := range rekeningAkar(rekening) {
+		switch {
+		case rek.Tipe == "N" && rek.Balance == "D":
+			totalAktiva += rek.SaldoAkhir
+		case rek.Tipe == "N":
+			totalKewajiban += rek.SaldoAkhir
+		case rek.Tipe == "M":
+			totalModal += rek.SaldoAkhir
+		case rek.Tipe == "R":
+			labaTahunBerjalan -= saldoDebet(rek, rek.SaldoAkhir)
+		}
+	}
+	// Laba rekening R dari tahun fiskal sebelumnya yang belum ditutup ke modal
+	labaLalu := labaTahunLalu(rekening)
+	totalPasiva := totalKewajiban + totalModal + labaLalu + labaTahunBerjalan
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data neraca berhasil diambil dari database",
+		"filter": map[string]string{
+			"tanggal_awal":  tanggalAwal,
+			"tanggal_akhir": tanggalAkhir,
+			"periode":       rentang.Periode,
+		},
+		"total_aktiva":        totalAktiva,
+		"total_kewajiban":     totalKewajiban,
+		"total_modal":         totalModal,
+		"laba_tahun_lalu":     labaLalu,
+		"laba_tahun_berjalan": labaTahunBerjalan,
+		"total_pasiva":        totalPasiva,
+		"seimbang":            math.Abs(totalAktiva-totalPasiva) < 0.01,
+		"aktiva":              aktiva,
+		"kewajiban":           kewajiban,
+		"modal":               modal,
+	}
+
+	writeJSON(w, response)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"testing"
+
+	"siak-rsbw/backend/models"
+)
+
+func TestAwalFiskalRentang(t *testing.T) {
+	t.Setenv("TAHUN_FISKAL_BULAN_AWAL", "7")
+	tests := []struct {
+		nama  string
+		awal  string
+		ingin string
+	}{
+		{"awal tahun fiskal", "2024-07-01", "2024-07-01"},
+		{"sebelum bulan awal", "2025-03-01", "2024-07-01"},
+		{"akhir tahun kalender", "2024-12-31", "2024-07-01"},
+		{"akhir tahun fiskal", "2025-06-30", "2024-07-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rt, err := buatRentangTanggal(tt.awal, tt.awal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := awalFiskalRentang(rt).Format("2006-01-02"); got != tt.ingin {
+				t.Errorf("awalFiskalRentang(%s) = %s, ingin %s", tt.awal, got, tt.ingin)
+			}
+		})
+	}
+}
+
+func TestSusunSaldoRekeningTahunFiskalJuli(t *testing.T) {
+	// Kas, modal dan pendapatan (induk 4 dengan sub 4.1) dengan tahun fiskal mulai Juli. Kolom
+	// sebelum adalah mutasi sejak 1 Januari, kolom sebelum fiskal mutasi sejak awal tahun fiskal.
+	rekening := func(saldoTahunPendapatan, kreditSebelum, kreditSebelumFiskal float64) []models.SaldoRekening {
+		return []models.SaldoRekening{
+			{KdRek: "1", Tipe: "N", Balance: "D", SaldoTahun: 1000 + saldoTahunPendapatan, DebetSebelum: kreditSebelum,
+				DebetSebelumFiskal: kreditSebelumFiskal, Debet: 300},
+			{KdRek: "3", Tipe: "M", Balance: "K", SaldoTahun: 1000},
+			{KdRek: "4", Tipe: "R", Balance: "K"},
+			{KdRek: "4.1", Tipe: "R", Balance: "K", Induk: "4", SaldoTahun: saldoTahunPendapatan,
+				KreditSebelum: kreditSebelum, KreditSebelumFiskal: kreditSebelumFiskal, Kredit: 300},
+		}
+	}
+
+	tests := []struct {
+		nama      string
+		rekening  []models.SaldoRekening
+		saldoAwal float64 // saldo awal pendapatan
+		labaLalu  float64
+	}{
+		// Neraca Agustus 2024: rentang mulai 1 Juli, pendapatan Januari-Juni milik tahun fiskal lalu
+		{"awal tahun fiskal", rekening(0, 500, 0), 0, 500},
+		// Maret 2025: saldo_awal 2025 memuat pendapatan 2024 (Januari-Juni 500, Juli-Desember
+		// 300), mutasi Januari-Februari 2025 100
+		{"melewati tahun kalender", rekening(800, 100, 400), 400, 500},
+		// Tanpa pendapatan sebelum awal tahun fiskal
+		{"tanpa saldo tahun lalu", rekening(0, 200, 200), 200, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			hasil := susunSaldoRekening(tt.rekening)
+			for _, rek := range hasil {
+				if rek.Tipe != "R" {
+					continue
+				}
+				if rek.SaldoAwal != tt.saldoAwal || rek.SaldoAkhir != tt.saldoAwal+300 || rek.SaldoTahunLalu != tt.labaLalu {
+					t.Errorf("rekening %s saldo awal/akhir/tahun lalu = %v/%v/%v, ingin %v/%v/%v", rek.KdRek,
+						rek.SaldoAwal, rek.SaldoAkhir, rek.SaldoTahunLalu, tt.saldoAwal, tt.saldoAwal+300, tt.labaLalu)
+				}
+			}
+			if got := labaTahunLalu(hasil); got != tt.labaLalu {
+				t.Errorf("labaTahunLalu = %v, ingin %v", got, tt.labaLalu)
+			}
+
+			// Neraca seimbang: kas = modal + laba tahun lalu + laba tahun berjalan
+			kas, modal := hasil[0].SaldoAkhir, hasil[1].SaldoAkhir
+			labaBerjalan := hasil[2].SaldoAkhir
+			if selisih := kas - (modal + tt.labaLalu + labaBerjalan); selisih != 0 {
+				t.Errorf("neraca tidak seimbang: kas %v, modal %v, laba lalu %v, laba berjalan %v",
+					kas, modal, tt.labaLalu, labaBerjalan)
+			}
+		})
+	}
+}
@@ -0,0 +1,637 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AturanAlertRequest menyimpan data permintaan membuat atau memperbarui aturan alert
+type AturanAlertRequest struct {
+	Nama        string  `json:"nama"`
+	Metrik      string  `json:"metrik"`
+	Dimensi     string  `json:"dimensi"`
+	Jenis       string  `json:"jenis"`
+	Nilai       float64 `json:"nilai"`
+	MinBaseline float64 `json:"min_baseline"`
+	Keparahan   string  `json:"keparahan"`
+	Kirim       bool    `json:"kirim"`
+	Aktif       *bool   `json:"aktif"`
+}
+
+// TanganiAlertRequest menyimpan catatan saat alert ditandai sudah ditangani
+type TanganiAlertRequest struct {
+	Catatan string `json:"catatan"`
+}
+
+// Pilihan metrik, jenis aturan dan keparahan
+var (
+	metrikAlert = []string{
+		models.MetrikPendapatan, models.MetrikPendapatanUnit, models.MetrikPendapatanPenjab,
+		models.MetrikKunjungan, models.MetrikKunjunganPoli,
+		models.MetrikPiutangBaru, models.MetrikPiutangBaruPenjab,
+	}
+	jenisAturanAlert = []string{
+		models.AturanBatasBawah, models.AturanBatasAtas, models.AturanTurunBasis, models.AturanNaikBasis,
+	}
+	keparahanAlert = []string{models.KeparahanInfo, models.KeparahanPeringatan, models.KeparahanKritis}
+)
+
+// jumlahMingguBaseline adalah jumlah hari yang sama pada minggu-minggu sebelumnya yang dirata-rata
+const jumlahMingguBaseline = 4
+
+// evaluasiAlertMutex mencegah evaluasi terjadwal dan manual berjalan bersamaan
+var evaluasiAlertMutex sync.Mutex
+
+// termasuk memeriksa apakah nilai ada di dalam daftar pilihan
+func termasuk(nilai string, pilihan []string) bool {
+	for _, p := range pilihan {
+		if nilai == p {
+			return true
+		}
+	}
+	return false
+}
+
+// validasi memeriksa isi permintaan aturan alert
+func (req *AturanAlertRequest) validasi() error {
+	req.Nama = strings.TrimSpace(req.Nama)
+	req.Dimensi = strings.TrimSpace(req.Dimensi)
+	if err := validasiWajib("nama", req.Nama, "metrik", req.Metrik, "jenis", req.Jenis); err != nil {
+		return err
+	}
+
+	if !termasuk(req.Metrik, metrikAlert) {
+		return errValidasi("metrik", "Metrik tidak dikenal: %s (pilihan: %s)", req.Metrik, strings.Join(metrikAlert, ", "))
+	}
+	if !termasuk(req.Jenis, jenisAturanAlert) {
+		return errValidasi("jenis", "Jenis aturan tidak dikenal: %s (pilihan: %s)", req.Jenis, strings.Join(jenisAturanAlert, ", "))
+	}
+	if req.Keparahan == "" {
+		req.Keparahan = models.KeparahanPeringatan
+	}
+	if !termasuk(req.Keparahan, keparahanAlert) {
+		return errValidasi("keparahan", "Keparahan tidak dikenal: %s (pilihan: %s)", req.Keparahan, strings.Join(keparahanAlert, ", "))
+	}
+
+	switch req.Metrik {
+	case models.MetrikPendapatan, models.MetrikKunjungan, models.MetrikPiutangBaru:
+		if req.Dimensi != "" {
+			return errValidasi("dimensi", "Metrik %s tidak memiliki dimensi", req.Metrik)
+		}
+	}
+
+	if req.Nilai < 0 {
+		return errValidasi("nilai", "nilai tidak boleh negatif")
+	}
+	if req.Jenis == models.AturanTurunBasis && (req.Nilai <= 0 || req.Nilai > 100) {
+		return errValidasi("nilai", "nilai untuk %s adalah persen penurunan antara 0 dan 100", req.Jenis)
+	}
+	if req.Jenis == models.AturanNaikBasis && req.Nilai <= 0 {
+		return errValidasi("nilai", "nilai untuk %s adalah persen kenaikan lebih dari 0", req.Jenis)
+	}
+	if req.MinBaseline < 0 {
+		return errValidasi("min_baseline", "min_baseline tidak boleh negatif")
+	}
+	return nil
+}
+
+// JalankanEvaluasiAlert memulai evaluasi aturan alert harian. Sesuai jadwal ALERT_CRON (default
+// 0 1 * * *, zona waktu rumah sakit) metrik hari sebelumnya dibandingkan dengan setiap aturan
+// aktif.
+func JalankanEvaluasiAlert() {
+	if utils.GetDB() == nil {
+		log.Println("Database SIAK tidak tersedia, alert harian dinonaktifkan")
+		return
+	}
+
+	ekspresi, ok := jalankanHarian("ALERT_CRON", "0 1 * * *", func(tanggal string) {
+		if _, err := evaluasiAlert(tanggal); err != nil {
+			log.Printf("Evaluasi alert %s gagal: %v", tanggal, err)
+		}
+	})
+	if ok {
+		log.Printf("Evaluasi alert harian aktif (%s)", ekspresi)
+	}
+}
+
+// evaluasiAlert membandingkan metrik satu tanggal dengan seluruh aturan aktif dan mencatat alert
+// yang terpicu. Alert yang sudah tercatat untuk aturan, tanggal dan dimensi yang sama tidak
+// dibuat ulang sehingga evaluasi aman dijalankan berkali-kali. Alert baru dikembalikan.
+func evaluasiAlert(tanggal string) ([]models.Alert, error) {
+	evaluasiAlertMutex.Lock()
+	defer evaluasiAlertMutex.Unlock()
+
+	db := utils.GetDB()
+	var aturan []models.AturanAlert
+	if err := db.Where("aktif = ?", true).Order("id").Find(&aturan).Error; err != nil {
+		return nil, err
+	}
+	if len(aturan) == 0 {
+		return nil, nil
+	}
+
+	khanza := utils.GetMySQLDB()
+	if khanza == nil {
+		return nil, fmt.Errorf("database Khanza tidak tersedia")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), durasiEnv("ALERT_TIMEOUT", 10*time.Minute))
+	defer cancel()
+	khanza = khanza.WithContext(ctx)
+
+	hariIni, err := hitungMetrikHarian(khanza, tanggal)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := hitungBaseline(khanza, tanggal)
+	if err != nil {
+		return nil, err
+	}
+
+	var sudahAda []models.Alert
+	if err := db.Select("aturan_id", "dimensi").Where("tanggal = ?", tanggal).Find(&sudahAda).Error; err != nil {
+		return nil, err
+	}
+	tercatat := map[string]bool{}
+	for _, a := range sudahAda {
+		tercatat[fmt.Sprintf("%d|%s", a.AturanID, a.Dimensi)] = true
+	}
+
+	var hasil []models.Alert
+	for _, a := range aturan {
+		for _, alert := range periksaAturan(a, tanggal, hariIni, baseline) {
+			if tercatat[fmt.Sprintf("%d|%s", a.ID, alert.Dimensi)] {
+				continue
+			}
+			if err := db.Create(&alert).Error; err != nil {
+				log.Printf("Gagal mencatat alert aturan %d (%s): %v", a.ID, alert.Dimensi, err)
+				continue
+			}
+			if a.Kirim {
+				KirimEvent(models.EventAlertHarian, alert)
+			}
+			hasil = append(hasil, alert)
+		}
+	}
+
+	log.Printf("Evaluasi alert %s: %d aturan, %d alert baru", tanggal, len(aturan), len(hasil))
+	return hasil, nil
+}
+
+// hitungBaseline menghitung rata-rata setiap metrik dan dimensi pada hari yang sama selama
+// empat minggu sebelum tanggal. Dimensi yang tidak muncul pada salah satu minggu dihitung nol.
+func hitungBaseline(db *gorm.DB, tanggal string) (metrikHarian, error) {
+	t, err := time.ParseInLocation("2006-01-02", tanggal, utils.ZonaWaktuRS())
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := metrikHarian{}
+	for minggu := 1; minggu <= jumlahMingguBaseline; minggu++ {
+		metrik, err := hitungMetrikHarian(db, t.AddDate(0, 0, -7*minggu).Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		for nama, dimensi := range metrik {
+			for kode, nilai := range dimensi {
+				baseline.tambah(nama, kode, nilai.Nama, nilai.Nilai/jumlahMingguBaseline)
+			}
+		}
+	}
+	return baseline, nil
+}
+
+// periksaAturan mengevaluasi satu aturan terhadap metrik hari ini dan baseline. Aturan tanpa
+// dimensi memeriksa setiap dimensi yang muncul hari ini atau selama periode baseline, sehingga
+// poli yang hari ini sama sekali tidak bertransaksi tetap terdeteksi dengan nilai nol.
+func periksaAturan(aturan models.AturanAlert, tanggal string, hariIni, baseline metrikHarian) []models.Alert {
+	var daftarDimensi []string
+	if aturan.Dimensi != "" {
+		daftarDimensi = []string{aturan.Dimensi}
+	} else {
+		ada := map[string]bool{}
+		for _, sumber := range []metrikHarian{hariIni, baseline} {
+			for kode := range sumber[aturan.Metrik] {
+				if !ada[kode] {
+					ada[kode] = true
+					daftarDimensi = append(daftarDimensi, kode)
+				}
+			}
+		}
+		sort.Strings(daftarDimensi)
+	}
+
+	var hasil []models.Alert
+	for _, kode := range daftarDimensi {
+		nilai := hariIni[aturan.Metrik][kode]
+		basis := baseline[aturan.Metrik][kode]
+		nama := nilai.Nama
+		if nama == "" {
+			nama = basis.Nama
+		}
+		if nama == "" {
+			nama = kode
+		}
+
+		var deviasi *float64
+		if basis.Nilai > 0 {
+			d := math.Round((nilai.Nilai-basis.Nilai)/basis.Nilai*1000) / 10
+			deviasi = &d
+		}
+
+		var pembanding float64
+		var keterangan string
+		switch aturan.Jenis {
+		case models.AturanBatasBawah:
+			if nilai.Nilai >= aturan.Nilai {
+				continue
+			}
+			pembanding = aturan.Nilai
+			keterangan = fmt.Sprintf("di bawah batas %.0f", aturan.Nilai)
+		case models.AturanBatasAtas:
+			if nilai.Nilai <= aturan.Nilai {
+				continue
+			}
+			pembanding = aturan.Nilai
+			keterangan = fmt.Sprintf("di atas batas %.0f", aturan.Nilai)
+		case models.AturanTurunBasis, models.AturanNaikBasis:
+			if deviasi == nil || basis.Nilai < aturan.MinBaseline {
+				continue
+			}
+			if aturan.Jenis == models.AturanTurunBasis && -*deviasi < aturan.Nilai {
+				continue
+			}
+			if aturan.Jenis == models.AturanNaikBasis && *deviasi < aturan.Nilai {
+				continue
+			}
+			pembanding = basis.Nilai
+			keterangan = fmt.Sprintf("%+.1f%% dari rata-rata %d minggu (%.0f)", *deviasi, jumlahMingguBaseline, basis.Nilai)
+		}
+
+		label := labelMetrik[aturan.Metrik]
+		if kode != "" {
+			label += " " + nama
+		}
+		hasil = append(hasil, models.Alert{
+			AturanID:    aturan.ID,
+			Tanggal:     tanggal,
+			Metrik:      aturan.Metrik,
+			Dimensi:     kode,
+			NamaDimensi: nama,
+			Nilai:       nilai.Nilai,
+			Pembanding:  pembanding,
+			Deviasi:     deviasi,
+			Keparahan:   aturan.Keparahan,
+			Pesan:       fmt.Sprintf("%s tanggal %s: %.0f, %s", label, tanggal, nilai.Nilai, keterangan),
+			Status:      models.AlertBaru,
+			DibuatPada:  time.Now(),
+		})
+	}
+	return hasil
+}
+
+// getAturanAlertDariURL mengambil aturan alert berdasarkan ID pada URL
+func getAturanAlertDariURL(w http.ResponseWriter, r *http.Request) (*models.AturanAlert, bool) {
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID aturan tidak valid")
+		return nil, false
+	}
+
+	var aturan models.AturanAlert
+	if err := utils.GetDB().First(&aturan, id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Aturan alert tidak ditemukan")
+		return nil, false
+	}
+	return &aturan, true
+}
+
+// GetAturanAlertHandler menangani permintaan daftar aturan alert beserta pilihan metrik dan jenis
+func GetAturanAlertHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	var aturan []models.AturanAlert
+	if err := utils.GetDB().Order("nama").Find(&aturan).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil aturan alert: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"status":     "success",
+		"metrik":     metrikAlert,
+		"jenis":      jenisAturanAlert,
+		"keparahan":  keparahanAlert,
+		"total_data": len(aturan),
+		"data":       aturan,
+	})
+}
+
+// CreateAturanAlertHandler menangani permintaan membuat aturan alert
+func CreateAturanAlertHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Decode permintaan JSON
+	var req AturanAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	if err := req.validasi(); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	aturan := models.AturanAlert{
+		Nama:        req.Nama,
+		Metrik:      req.Metrik,
+		Dimensi:     req.Dimensi,
+		Jenis:       req.Jenis,
+		Nilai:       req.Nilai,
+		MinBaseline: req.MinBaseline,
+		Keparahan:   req.Keparahan,
+		Kirim:       req.Kirim,
+		Aktif:       req.Aktif == nil || *req.Aktif,
+		DibuatOleh:  username,
+	}
+
+	if err := utils.GetDB().Create(&aturan).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menyimpan aturan alert: "+err.Error())
+		return
+	}
+
+	log.Printf("Aturan alert %d (%s) dibuat oleh %s", aturan.ID, aturan.Nama, username)
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(aturan)
+}
+
+// UpdateAturanAlertHandler menangani permintaan memperbarui aturan alert
+func UpdateAturanAlertHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode PUT
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	aturan, ok := getAturanAlertDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode permintaan JSON
+	var req AturanAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	if err := req.validasi(); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	aturan.Nama = req.Nama
+	aturan.Metrik = req.Metrik
+	aturan.Dimensi = req.Dimensi
+	aturan.Jenis = req.Jenis
+	aturan.Nilai = req.Nilai
+	aturan.MinBaseline = req.MinBaseline
+	aturan.Keparahan = req.Keparahan
+	aturan.Kirim = req.Kirim
+	if req.Aktif != nil {
+		aturan.Aktif = *req.Aktif
+	}
+
+	if err := utils.GetDB().Save(aturan).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memperbarui aturan alert: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aturan)
+}
+
+// DeleteAturanAlertHandler menangani permintaan menghapus aturan alert. Alert yang sudah tercatat
+// tetap disimpan sebagai riwayat.
+func DeleteAturanAlertHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode DELETE
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	aturan, ok := getAturanAlertDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := utils.GetDB().Delete(aturan).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghapus aturan alert: "+err.Error())
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	log.Printf("Aturan alert %d (%s) dihapus oleh %s", aturan.ID, aturan.Nama, username)
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Aturan alert berhasil dihapus",
+	})
+}
+
+// GetAlertHandler menangani permintaan daftar alert, terbaru lebih dulu. Rentang tanggal memakai
+// parameter yang sama dengan laporan (default bulan ini); status, keparahan dan metrik menyaring
+// hasil, dan limit membatasi jumlah data (default 100, maksimal 1000).
+func GetAlertHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	limit, err := getParamInt(r, "limit", 100, 1, 1000)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	status, err := getParamEnum(r, "status", "", models.AlertBaru, models.AlertDitangani)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	keparahan, err := getParamEnum(r, "keparahan", "", keparahanAlert...)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	metrik, err := getParamEnum(r, "metrik", "", metrikAlert...)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	query := utils.GetDB().Model(&models.Alert{}).Where("tanggal BETWEEN ? AND ?", rentang.Awal, rentang.Akhir)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if keparahan != "" {
+		query = query.Where("keparahan = ?", keparahan)
+	}
+	if metrik != "" {
+		query = query.Where("metrik = ?", metrik)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghitung alert: "+err.Error())
+		return
+	}
+	var alert []models.Alert
+	if err := query.Order("tanggal desc, id desc").Limit(limit).Find(&alert).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil alert: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"status": "success",
+		"filter": map[string]string{
+			"tanggal_awal":  rentang.Awal,
+			"tanggal_akhir": rentang.Akhir,
+			"status":        status,
+			"keparahan":     keparahan,
+			"metrik":        metrik,
+		},
+		"total_data": total,
+		"data":       alert,
+	})
+}
+
+// TanganiAlertHandler menangani permintaan menandai alert sudah ditangani beserta catatannya
+func TanganiAlertHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID alert tidak valid")
+		return
+	}
+
+	var req TanganiAlertRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+			return
+		}
+	}
+
+	db := utils.GetDB()
+	var alert models.Alert
+	if err := db.First(&alert, id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Alert tidak ditemukan")
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	sekarang := time.Now()
+	alert.Status = models.AlertDitangani
+	alert.DitanganiOleh = username
+	alert.DitanganiPada = &sekarang
+	alert.Catatan = strings.TrimSpace(req.Catatan)
+
+	err := db.Model(&alert).UpdateColumns(map[string]interface{}{
+		"status":         alert.Status,
+		"ditangani_oleh": alert.DitanganiOleh,
+		"ditangani_pada": alert.DitanganiPada,
+		"catatan":        alert.Catatan,
+	}).Error
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memperbarui alert: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alert)
+}
+
+// EvaluasiAlertHandler menangani permintaan mengevaluasi aturan alert untuk satu tanggal
+// (parameter tanggal, default kemarin), misalnya setelah aturan baru dibuat atau setelah nota
+// yang terlambat ditutup. Response berisi alert yang baru tercatat.
+func EvaluasiAlertHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	sekarang := utils.SekarangRS()
+	tanggal := strings.TrimSpace(r.URL.Query().Get("tanggal"))
+	if tanggal == "" {
+		tanggal = sekarang.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	t, err := time.ParseInLocation("2006-01-02", tanggal, utils.ZonaWaktuRS())
+	if err != nil {
+		utils.WriteFieldError(w, "tanggal", "Format tanggal harus YYYY-MM-DD")
+		return
+	}
+	if t.After(sekarang) {
+		utils.WriteFieldError(w, "tanggal", "tanggal tidak boleh di masa depan")
+		return
+	}
+
+	alert, err := evaluasiAlert(tanggal)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengevaluasi alert: "+err.Error())
+		return
+	}
+	if alert == nil {
+		alert = []models.Alert{}
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"status":     "success",
+		"tanggal":    tanggal,
+		"total_data": len(alert),
+		"data":       alert,
+	})
+}
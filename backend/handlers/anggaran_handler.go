@@ -0,0 +1,620 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnggaranRequest menyimpan data permintaan membuat atau memperbarui anggaran pendapatan
+type AnggaranRequest struct {
+	Periode    string  `json:"periode"`
+	JenisUnit  string  `json:"jenis_unit"`
+	KdUnit     string  `json:"kd_unit"`
+	KdPj       string  `json:"kd_pj"`
+	Jumlah     float64 `json:"jumlah"`
+	Keterangan string  `json:"keterangan"`
+}
+
+// validasi memeriksa isi permintaan anggaran dan mengembalikan error untuk field yang tidak valid
+func (req *AnggaranRequest) validasi() error {
+	req.KdUnit = strings.TrimSpace(req.KdUnit)
+	req.KdPj = strings.TrimSpace(req.KdPj)
+
+	if _, err := time.Parse("2006-01", req.Periode); err != nil {
+		return errValidasi("periode", "Format periode harus YYYY-MM")
+	}
+	switch req.JenisUnit {
+	case models.UnitRawatJalan, models.UnitRawatInap, models.UnitFarmasi:
+	default:
+		return errValidasi("jenis_unit", "jenis_unit harus salah satu dari ralan, ranap, farmasi")
+	}
+	if req.JenisUnit == models.UnitFarmasi && req.KdPj != "" {
+		return errValidasi("kd_pj", "Anggaran farmasi (penjualan bebas) tidak dapat dibedakan per penjab")
+	}
+	if req.Jumlah < 0 {
+		return errValidasi("jumlah", "Jumlah anggaran tidak boleh negatif")
+	}
+	return nil
+}
+
+// hitungRealisasiPendapatan menghitung pendapatan aktual per bulan, jenis unit, unit dan penjab
+// dari query laporan yang sama dengan laporan rawat jalan, rawat inap, piutang pasien dan
+// penjualan bebas obat, sehingga realisasi selalu sama dengan angka laporan tersebut:
+//
+//	ralan    pembayaran nota rawat jalan (basis tanggal bayar) per poli, ditambah piutang rawat
+//	         jalan (tanggal piutang)
+//	ranap    pembayaran nota rawat inap (basis tanggal bayar) per bangsal kamar terakhir, ditambah
+//	         piutang rawat inap (tanggal piutang)
+//	farmasi  penjualan bebas yang sudah dibayar per depo, tanpa penjab; obat resep sudah termasuk
+//	         dalam tagihan rawat jalan/inap sehingga tidak dihitung dua kali
+func hitungRealisasiPendapatan(db *gorm.DB, rentang rentangTanggal) ([]models.RealisasiPendapatan, error) {
+	gabungan := map[string]*models.RealisasiPendapatan{}
+	var urutan []string
+	tambah := func(row models.RealisasiPendapatan) {
+		kunci := row.Bulan + "|" + row.JenisUnit + "|" + row.KdUnit + "|" + row.KdPj
+		if item, ada := gabungan[kunci]; ada {
+			item.Jumlah += row.Jumlah
+			return
+		}
+		baru := row
+		gabungan[kunci] = &baru
+		urutan = append(urutan, kunci)
+	}
+
+	rawatJalan, _, err := ambilRawatJalan(db, rentang, basisTanggal{Nama: "bayar", Kondisi: kondisiBayarJalan}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("query realisasi %s gagal: %w", models.UnitRawatJalan, err)
+	}
+	for _, item := range rawatJalan {
+		tambah(models.RealisasiPendapatan{
+			Bulan: item.TglBayar.Format("2006-01"), JenisUnit: models.UnitRawatJalan,
+			KdUnit: item.KdPoli, NmUnit: item.NmPoli, KdPj: item.KdPj, PngJawab: item.PngJawab,
+			Jumlah: item.BesarBayar,
+		})
+	}
+
+	rawatInap, err := ambilRawatInap(db, rentang, basisTanggal{Nama: "bayar", Kondisi: kondisiBayarInap})
+	if err != nil {
+		return nil, fmt.Errorf("query realisasi %s gagal: %w", models.UnitRawatInap, err)
+	}
+	for _, item := range rawatInap {
+		tambah(models.RealisasiPendapatan{
+			Bulan: item.Tanggal.Format("2006-01"), JenisUnit: models.UnitRawatInap,
+			KdUnit: item.KdBangsal, NmUnit: item.NmBangsal, KdPj: item.KdPj, PngJawab: item.PngJawab,
+			Jumlah: item.BesarBayar,
+		})
+	}
+
+	piutang, _, err := ambilPiutangPasien(db, rentang, basisPiutang, "", 0)
+	if err != nil {
+		return nil, fmt.Errorf("query realisasi piutang gagal: %w", err)
+	}
+	// Bangsal piutang rawat inap diambil dari laporan rawat inap untuk rawat yang berpiutang
+	bangsalPiutang := map[string]models.LaporanRawatInap{}
+	adaPiutangRanap := false
+	for _, item := range piutang {
+		adaPiutangRanap = adaPiutangRanap || item.StatusLanjut == "Ranap"
+	}
+	if adaPiutangRanap {
+		rawatBerpiutang, err := ambilRawatInap(db, rentang, basisTanggal{
+			Nama:    "piutang",
+			Kondisi: "EXISTS (SELECT 1 FROM piutang_pasien WHERE piutang_pasien.no_rawat = reg_periksa.no_rawat AND " + kondisiPiutang + ")",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("query realisasi piutang %s gagal: %w", models.UnitRawatInap, err)
+		}
+		for _, rawat := range rawatBerpiutang {
+			bangsalPiutang[rawat.NoRawat] = rawat
+		}
+	}
+	for _, item := range piutang {
+		row := models.RealisasiPendapatan{
+			Bulan: item.TglPiutang[:min(7, len(item.TglPiutang))], KdPj: item.KdPj, PngJawab: item.PngJawab,
+			Jumlah: item.TotalPiutang,
+		}
+		switch item.StatusLanjut {
+		case "Ralan":
+			row.JenisUnit, row.KdUnit, row.NmUnit = models.UnitRawatJalan, item.KdPoli, item.NmPoli
+		case "Ranap":
+			rawat := bangsalPiutang[item.NoRawat]
+			row.JenisUnit, row.KdUnit, row.NmUnit = models.UnitRawatInap, rawat.KdBangsal, rawat.NmBangsal
+		default:
+			continue
+		}
+		tambah(row)
+	}
+
+	penjualan, err := ambilPenjualanBebas(db, rentang)
+	if err != nil {
+		return nil, fmt.Errorf("query realisasi %s gagal: %w", models.UnitFarmasi, err)
+	}
+	for _, item := range penjualan {
+		tambah(models.RealisasiPendapatan{
+			Bulan: item.TanggalPenjualan.Format("2006-01"), JenisUnit: models.UnitFarmasi,
+			KdUnit: item.KdBangsal, NmUnit: item.NmBangsal, Jumlah: item.Total,
+		})
+	}
+
+	sort.Strings(urutan)
+	hasil := make([]models.RealisasiPendapatan, 0, len(urutan))
+	for _, kunci := range urutan {
+		hasil = append(hasil, *gabungan[kunci])
+	}
+	return hasil, nil
+}
+
+// cariAnggaranBentrok mencari anggaran lain pada periode dan jenis unit yang sama yang cakupannya
+// tumpang tindih dengan permintaan (kd_unit atau kd_pj kosong berarti semua), misalnya anggaran
+// "semua poli" dengan anggaran satu poli, atau anggaran per poli dengan anggaran per penjab.
+// Cakupan yang tumpang tindih membuat satu realisasi terhitung di beberapa baris sehingga total
+// anggaran dan realisasi laporan tidak lagi dapat dibandingkan. Mengembalikan pesan konflik, atau
+// string kosong jika tidak ada yang bentrok.
+func cariAnggaranBentrok(db *gorm.DB, req AnggaranRequest, kecualiID uint) (string, error) {
+	var lain []models.AnggaranPendapatan
+	err := db.Where("periode = ? AND jenis_unit = ? AND id <> ?", req.Periode, req.JenisUnit, kecualiID).
+		Find(&lain).Error
+	if err != nil {
+		return "", err
+	}
+
+	cakupan := func(kode string) string {
+		if kode == "" {
+			return "semua"
+		}
+		return kode
+	}
+	for _, a := range lain {
+		unitBentrok := a.KdUnit == "" || req.KdUnit == "" || a.KdUnit == req.KdUnit
+		penjabBentrok := a.KdPj == "" || req.KdPj == "" || a.KdPj == req.KdPj
+		if !unitBentrok || !penjabBentrok {
+			continue
+		}
+		if a.KdUnit == req.KdUnit && a.KdPj == req.KdPj {
+			return "Anggaran untuk periode, unit dan penjab tersebut sudah ada", nil
+		}
+		return fmt.Sprintf("Cakupan anggaran tumpang tindih dengan anggaran #%d (unit %s, penjab %s) pada periode %s",
+			a.ID, cakupan(a.KdUnit), cakupan(a.KdPj), a.Periode), nil
+	}
+	return "", nil
+}
+
+// cocokAnggaran memeriksa apakah realisasi termasuk dalam cakupan anggaran
+// (kd_unit dan kd_pj kosong pada anggaran berarti semua)
+func cocokAnggaran(jenisUnit, kdUnit, kdPj string, realisasi models.RealisasiPendapatan) bool {
+	return realisasi.JenisUnit == jenisUnit &&
+		(kdUnit == "" || realisasi.KdUnit == kdUnit) &&
+		(kdPj == "" || realisasi.KdPj == kdPj)
+}
+
+// persenCapaian menghitung persentase realisasi terhadap anggaran
+func persenCapaian(realisasi, anggaran float64) float64 {
+	if anggaran == 0 {
+		return 0
+	}
+	return realisasi / anggaran * 100
+}
+
+// getNamaUnitDanPenjab mengambil nama poliklinik, bangsal dan penjab dari Khanza
+func getNamaUnitDanPenjab(db *gorm.DB) (map[string]string, map[string]string, error) {
+	var units []struct {
+		Jenis string
+		Kode  string
+		Nama  string
+	}
+	err := db.Raw(`
+		SELECT 'ralan' AS jenis, kd_poli AS kode, nm_poli AS nama FROM poliklinik
+		UNION ALL
+		SELECT 'ranap' AS jenis, kd_bangsal AS kode, nm_bangsal AS nama FROM bangsal
+		UNION ALL
+		SELECT 'farmasi' AS jenis, kd_bangsal AS kode, nm_bangsal AS nama FROM bangsal
+	`).Scan(&units).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var penjab []struct {
+		KdPj     string
+		PngJawab string
+	}
+	if err := db.Raw("SELECT kd_pj, png_jawab FROM penjab").Scan(&penjab).Error; err != nil {
+		return nil, nil, err
+	}
+
+	namaUnit := map[string]string{}
+	for _, u := range units {
+		namaUnit[u.Jenis+"|"+u.Kode] = u.Nama
+	}
+	namaPenjab := map[string]string{}
+	for _, p := range penjab {
+		namaPenjab[p.KdPj] = p.PngJawab
+	}
+	return namaUnit, namaPenjab, nil
+}
+
+// GetAnggaranHandler menangani permintaan daftar anggaran pendapatan.
+// Filter opsional: tahun (YYYY), periode (YYYY-MM) dan jenis_unit.
+func GetAnggaranHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	periode, err := getParamBulan(r, "periode", "")
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tahun, err := getParamInt(r, "tahun", 0, 1900, 9999)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	jenisUnit, err := getParamEnum(r, "jenis_unit", "", models.UnitRawatJalan, models.UnitRawatInap, models.UnitFarmasi)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	query := utils.GetDB().Order("periode desc, jenis_unit, kd_unit, kd_pj")
+	if periode != "" {
+		query = query.Where("periode = ?", periode)
+	} else if tahun != 0 {
+		query = query.Where("periode LIKE ?", fmt.Sprintf("%04d-%%", tahun))
+	}
+	if jenisUnit != "" {
+		query = query.Where("jenis_unit = ?", jenisUnit)
+	}
+
+	var anggaran []models.AnggaranPendapatan
+	if err := query.Find(&anggaran).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil data anggaran: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(anggaran)
+}
+
+// CreateAnggaranHandler menangani permintaan membuat anggaran pendapatan baru
+func CreateAnggaranHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Decode permintaan JSON
+	var req AnggaranRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	// Validasi input
+	if err := req.validasi(); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	// Cakupan anggaran tidak boleh tumpang tindih dengan anggaran lain pada periode yang sama
+	bentrok, err := cariAnggaranBentrok(utils.GetDB(), req, 0)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memeriksa anggaran: "+err.Error())
+		return
+	}
+	if bentrok != "" {
+		utils.WriteError(w, http.StatusConflict, bentrok)
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	anggaran := models.AnggaranPendapatan{
+		Periode:    req.Periode,
+		JenisUnit:  req.JenisUnit,
+		KdUnit:     req.KdUnit,
+		KdPj:       req.KdPj,
+		Jumlah:     req.Jumlah,
+		Keterangan: req.Keterangan,
+		DibuatOleh: username,
+	}
+
+	// Simpan ke database
+	if err := utils.GetDB().Create(&anggaran).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat anggaran: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(anggaran)
+}
+
+// UpdateAnggaranHandler menangani permintaan memperbarui anggaran pendapatan
+func UpdateAnggaranHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode PUT
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID anggaran tidak valid")
+		return
+	}
+
+	db := utils.GetDB()
+	var anggaran models.AnggaranPendapatan
+	if err := db.First(&anggaran, id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Anggaran tidak ditemukan")
+		return
+	}
+
+	// Decode permintaan JSON
+	var req AnggaranRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	// Validasi input
+	if err := req.validasi(); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	// Cakupan baru tidak boleh tumpang tindih dengan anggaran lain
+	bentrok, err := cariAnggaranBentrok(db, req, anggaran.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memeriksa anggaran: "+err.Error())
+		return
+	}
+	if bentrok != "" {
+		utils.WriteError(w, http.StatusConflict, bentrok)
+		return
+	}
+
+	anggaran.Periode = req.Periode
+	anggaran.JenisUnit = req.JenisUnit
+	anggaran.KdUnit = req.KdUnit
+	anggaran.KdPj = req.KdPj
+	anggaran.Jumlah = req.Jumlah
+	anggaran.Keterangan = req.Keterangan
+
+	// Simpan perubahan
+	if err := db.Save(&anggaran).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memperbarui anggaran: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(anggaran)
+}
+
+// DeleteAnggaranHandler menangani permintaan menghapus anggaran pendapatan
+func DeleteAnggaranHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode DELETE
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID anggaran tidak valid")
+		return
+	}
+
+	result := utils.GetDB().Delete(&models.AnggaranPendapatan{}, id)
+	if result.Error != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghapus anggaran: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, "Anggaran tidak ditemukan")
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Anggaran berhasil dihapus",
+	})
+}
+
+// RealisasiAnggaranHandler menangani permintaan laporan anggaran vs realisasi pendapatan untuk
+// satu bulan (parameter periode YYYY-MM atau last_month, default bulan ini) beserta kumulatif
+// sejak awal tahun fiskal.
+// Setiap baris anggaran dibandingkan dengan realisasi yang masuk dalam cakupannya.
+func RealisasiAnggaranHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Periode diterjemahkan lewat resolvePeriode seperti laporan lain, tetapi harus satu bulan
+	// kalender karena anggaran disimpan per bulan
+	nilaiPeriode := r.URL.Query().Get("periode")
+	if nilaiPeriode == "" {
+		nilaiPeriode = utils.SekarangRS().Format("2006-01")
+	}
+	rentangBulanIni, err := resolvePeriode(nilaiPeriode, utils.SekarangRS(), bulanAwalTahunFiskal())
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	bulan := rentangBulanIni.Mulai()
+	if bulan.Day() != 1 || !rentangBulanIni.Selesai().Equal(bulan.AddDate(0, 1, 0)) {
+		tulisErrorValidasi(w, errValidasi("periode", "periode realisasi anggaran harus satu bulan (YYYY-MM atau last_month)"))
+		return
+	}
+	periode := bulan.Format("2006-01")
+	jenisUnit, err := getParamEnum(r, "jenis_unit", "", models.UnitRawatJalan, models.UnitRawatInap, models.UnitFarmasi)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	rentangYTD := rentangBulanIni.SejakAwalTahunFiskal(bulanAwalTahunFiskal())
+	awalTahun := rentangYTD.Mulai().Format("2006-01")
+	tanggalAkhir := rentangYTD.Akhir
+
+	// Anggaran dari awal tahun fiskal sampai bulan yang diminta
+	query := utils.GetDB().Where("periode BETWEEN ? AND ?", awalTahun, periode)
+	if jenisUnit != "" {
+		query = query.Where("jenis_unit = ?", jenisUnit)
+	}
+	var anggaran []models.AnggaranPendapatan
+	if err := query.Order("jenis_unit, kd_unit, kd_pj, periode").Find(&anggaran).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil data anggaran: "+err.Error())
+		return
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	realisasi, err := hitungRealisasiPendapatan(db, rentangYTD)
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menghitung realisasi", err)
+		return
+	}
+
+	namaUnit, namaPenjab, err := getNamaUnitDanPenjab(db)
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal mengambil nama unit", err)
+		return
+	}
+
+	// Kelompokkan anggaran per kombinasi jenis unit, unit dan penjab
+	perKunci := map[string]*models.RealisasiAnggaran{}
+	var urutan []string
+	for _, a := range anggaran {
+		kunci := a.JenisUnit + "|" + a.KdUnit + "|" + a.KdPj
+		baris, ada := perKunci[kunci]
+		if !ada {
+			baris = &models.RealisasiAnggaran{
+				JenisUnit: a.JenisUnit,
+				KdUnit:    a.KdUnit,
+				NmUnit:    namaUnit[a.JenisUnit+"|"+a.KdUnit],
+				KdPj:      a.KdPj,
+				PngJawab:  namaPenjab[a.KdPj],
+			}
+			if a.KdUnit == "" {
+				baris.NmUnit = "Semua unit"
+			}
+			if a.KdPj == "" {
+				baris.PngJawab = "Semua penjab"
+			}
+			perKunci[kunci] = baris
+			urutan = append(urutan, kunci)
+		}
+
+		baris.AnggaranYTD += a.Jumlah
+		if a.Periode == periode {
+			baris.AnggaranID = a.ID
+			baris.Anggaran = a.Jumlah
+		}
+	}
+
+	// Hitung realisasi yang masuk cakupan setiap baris anggaran. Total realisasi dihitung dari
+	// setiap baris realisasi sekali saja walaupun masuk cakupan lebih dari satu baris anggaran
+	// (anggaran lama yang tersimpan sebelum cakupan tumpang tindih ditolak).
+	result := make([]models.RealisasiAnggaran, 0, len(urutan))
+	var totalAnggaran, totalRealisasi, totalAnggaranYTD, totalRealisasiYTD float64
+	tercakup := make([]bool, len(realisasi))
+	for _, kunci := range urutan {
+		baris := perKunci[kunci]
+		for i, item := range realisasi {
+			if !cocokAnggaran(baris.JenisUnit, baris.KdUnit, baris.KdPj, item) {
+				continue
+			}
+			tercakup[i] = true
+			baris.RealisasiYTD += item.Jumlah
+			if item.Bulan == periode {
+				baris.Realisasi += item.Jumlah
+			}
+		}
+
+		baris.Selisih = baris.Realisasi - baris.Anggaran
+		baris.PersenCapaian = persenCapaian(baris.Realisasi, baris.Anggaran)
+		baris.SelisihYTD = baris.RealisasiYTD - baris.AnggaranYTD
+		baris.PersenCapaianYTD = persenCapaian(baris.RealisasiYTD, baris.AnggaranYTD)
+
+		totalAnggaran += baris.Anggaran
+		totalAnggaranYTD += baris.AnggaranYTD
+		result = append(result, *baris)
+	}
+	for i, item := range realisasi {
+		if !tercakup[i] {
+			continue
+		}
+		totalRealisasiYTD += item.Jumlah
+		if item.Bulan == periode {
+			totalRealisasi += item.Jumlah
+		}
+	}
+
+	// Realisasi keseluruhan per jenis unit, termasuk yang tidak memiliki anggaran
+	realisasiPerJenis := map[string]map[string]float64{}
+	for _, jenis := range []string{models.UnitRawatJalan, models.UnitRawatInap, models.UnitFarmasi} {
+		if jenisUnit != "" && jenis != jenisUnit {
+			continue
+		}
+		realisasiPerJenis[jenis] = map[string]float64{"bulan": 0, "ytd": 0}
+	}
+	for _, item := range realisasi {
+		rekap, ada := realisasiPerJenis[item.JenisUnit]
+		if !ada {
+			continue
+		}
+		rekap["ytd"] += item.Jumlah
+		if item.Bulan == periode {
+			rekap["bulan"] += item.Jumlah
+		}
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data anggaran vs realisasi pendapatan berhasil diambil dari database",
+		"filter": map[string]string{
+			"periode":       periode,
+			"jenis_unit":    jenisUnit,
+			"tanggal_awal":  bulan.Format("2006-01-02"),
+			"tanggal_akhir": tanggalAkhir,
+			"awal_ytd":      rentangYTD.Awal,
+		},
+		"total_data":          len(result),
+		"total_anggaran":      totalAnggaran,
+		"total_realisasi":     totalRealisasi,
+		"total_selisih":       totalRealisasi - totalAnggaran,
+		"persen_capaian":      persenCapaian(totalRealisasi, totalAnggaran),
+		"total_anggaran_ytd":  totalAnggaranYTD,
+		"total_realisasi_ytd": totalRealisasiYTD,
+		"total_selisih_ytd":   totalRealisasiYTD - totalAnggaranYTD,
+		"persen_capaian_ytd":  persenCapaian(totalRealisasiYTD, totalAnggaranYTD),
+		"realisasi_per_jenis": realisasiPerJenis,
+		"data":                result,
+	}
+
+	writeJSON(w, response)
+}
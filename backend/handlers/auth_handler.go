@@ -36,7 +36,7 @@ type AuthResponse struct {
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode POST
 	if r.Method != http.MethodPost {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
@@ -44,14 +44,18 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Format JSON tidak valid", http.StatusBadRequest)
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+	if err := validasiWajib("username", req.Username, "password", req.Password); err != nil {
+		tulisErrorValidasi(w, err)
 		return
 	}
 
 	// Validasi kredensial
 	user, err := models.ValidateCredentials(utils.DB, req.Username, req.Password)
 	if err != nil {
-		http.Error(w, "Username atau password salah", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Username atau password salah")
 		return
 	}
 
@@ -67,7 +71,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(utils.JWTSecret))
 	if err != nil {
-		http.Error(w, "Gagal membuat token", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat token")
 		return
 	}
 
@@ -81,7 +85,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
-		http.Error(w, "Gagal mengenkode respons", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengenkode respons")
 		return
 	}
 }
@@ -93,7 +97,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Hanya menerima metode POST
 	if r.Method != http.MethodPost {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
@@ -101,13 +105,13 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Format JSON tidak valid", http.StatusBadRequest)
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
 		return
 	}
 
 	// Validasi input
-	if req.Username == "" || req.Password == "" || req.Name == "" {
-		http.Error(w, "Username, password, dan nama wajib diisi", http.StatusBadRequest)
+	if err := validasiWajib("username", req.Username, "password", req.Password, "name", req.Name); err != nil {
+		tulisErrorValidasi(w, err)
 		return
 	}
 
@@ -115,7 +119,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var existingUserCount int64
 	utils.DB.Model(&models.User{}).Where("username = ?", req.Username).Count(&existingUserCount)
 	if existingUserCount > 0 {
-		http.Error(w, "Username sudah digunakan", http.StatusConflict)
+		utils.WriteError(w, http.StatusConflict, "Username sudah digunakan")
 		return
 	}
 
@@ -135,14 +139,14 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	// Set dan hash password
 	err = newUser.SetPassword(req.Password)
 	if err != nil {
-		http.Error(w, "Gagal mengatur password: "+err.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengatur password: "+err.Error())
 		return
 	}
 
 	// Simpan ke database
 	result := utils.DB.Create(&newUser)
 	if result.Error != nil {
-		http.Error(w, "Gagal membuat pengguna: "+result.Error.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat pengguna: "+result.Error.Error())
 		return
 	}
 
@@ -158,7 +162,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(utils.JWTSecret))
 	if err != nil {
-		http.Error(w, "Gagal membuat token", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat token")
 		return
 	}
 
@@ -173,7 +177,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
-		http.Error(w, "Gagal mengenkode respons", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengenkode respons")
 		return
 	}
 }
@@ -182,7 +186,7 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode GET
 	if r.Method != http.MethodGet {
-		http.Error(w, "Metode tidak diijinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diijinkan")
 		return
 	}
 
@@ -190,14 +194,14 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	// Context ini diisi oleh middleware auth
 	userID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "Tidak terautentikasi", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Tidak terautentikasi")
 		return
 	}
 
 	// Cari user berdasarkan ID
 	user, err := models.FindUserByID(utils.DB, userID)
 	if err != nil {
-		http.Error(w, "Pengguna tidak ditemukan", http.StatusNotFound)
+		utils.WriteError(w, http.StatusNotFound, "Pengguna tidak ditemukan")
 		return
 	}
 
@@ -207,7 +211,7 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	// Encode response JSON
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(user); err != nil {
-		http.Error(w, "Gagal mengenkode response", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengenkode response")
 		return
 	}
 }
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"siak-rsbw/backend/utils"
+	"strings"
+	"time"
+)
+
+// kunciKonteks adalah tipe kunci nilai context milik paket handlers
+type kunciKonteks string
+
+// kunciNamaLaporan menyimpan nama laporan yang sedang dijalankan di context permintaan
+const kunciNamaLaporan kunciKonteks = "namaLaporan"
+
+// batasWaktuBawaan berisi batas waktu default laporan yang memang menjalankan banyak query,
+// dipakai jika LAPORAN_TIMEOUT_<NAMA> tidak diisi
+var batasWaktuBawaan = map[string]time.Duration{
+	"proyeksi-pendapatan": 5 * time.Minute,
+}
+
+// batasWaktuLaporan mengembalikan batas waktu query satu laporan. Diatur per laporan lewat
+// LAPORAN_TIMEOUT_<NAMA> (contoh: LAPORAN_TIMEOUT_RAWAT_INAP=2m), lalu batasWaktuBawaan, atau
+// untuk semua laporan lewat LAPORAN_TIMEOUT, default 60 detik.
+func batasWaktuLaporan(nama string) time.Duration {
+	kunci := "LAPORAN_TIMEOUT_" + strings.ToUpper(strings.ReplaceAll(nama, "-", "_"))
+	if bawaan, ada := batasWaktuBawaan[nama]; ada {
+		return durasiEnv(kunci, bawaan)
+	}
+	return durasiEnv(kunci, durasiEnv("LAPORAN_TIMEOUT", 60*time.Second))
+}
+
+// namaLaporanDari mengambil nama laporan dari context, atau "laporan" jika tidak ada
+func namaLaporanDari(ctx context.Context) string {
+	if nama, ok := ctx.Value(kunciNamaLaporan).(string); ok && nama != "" {
+		return nama
+	}
+	return "laporan"
+}
+
+// WithBatasWaktu membungkus handler laporan dengan context yang berakhir setelah batas waktu
+// laporan tersebut atau ketika klien memutus koneksi. Query yang memakai koneksi dari
+// getKoneksiLaporan ikut dibatalkan di MySQL.
+func WithBatasWaktu(nama string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), batasWaktuLaporan(nama))
+		defer cancel()
+
+		ctx = context.WithValue(ctx, kunciNamaLaporan, nama)
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// tulisErrorQuery menulis response untuk query laporan yang gagal. Jika batas waktu laporan
+// terlampaui dikirim 504 dengan nama laporan; jika klien sudah memutus koneksi tidak ada yang
+// ditulis; selain itu dikirim 500 dengan pesan yang diberikan.
+func tulisErrorQuery(w http.ResponseWriter, r *http.Request, pesan string, err error) {
+	ctx := r.Context()
+	nama := namaLaporanDari(ctx)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		log.Printf("Laporan %s melebihi batas waktu: %v", nama, err)
+		utils.WriteErrorResponse(w, http.StatusGatewayTimeout, utils.ErrorResponse{
+			Code:    utils.KodeWaktuHabis,
+			Message: fmt.Sprintf("Laporan %s melebihi batas waktu %s, persempit rentang tanggal atau coba lagi", nama, batasWaktuLaporan(nama)),
+		})
+	case errors.Is(ctx.Err(), context.Canceled):
+		log.Printf("Laporan %s dibatalkan karena klien memutus koneksi", nama)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("%s: %v", pesan, err))
+	}
+}
+
+// konteksBerakhir memeriksa apakah context permintaan sudah berakhir (batas waktu atau klien
+// memutus koneksi) dan jika ya menulis error-nya. Dipakai sebelum menulis response pada laporan
+// yang mengabaikan kegagalan query pelengkap, agar hasil parsial tidak dikirim sebagai sukses.
+func konteksBerakhir(w http.ResponseWriter, r *http.Request) bool {
+	if err := r.Context().Err(); err != nil {
+		tulisErrorQuery(w, r, "Permintaan berakhir", err)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	cacheLaporan     utils.Cache
+	cacheLaporanOnce sync.Once
+
+	// laporanTercache berisi nama laporan yang dibungkus WithCacheLaporan
+	laporanTercache   = map[string]bool{}
+	laporanTercacheMu sync.RWMutex
+)
+
+// getCacheLaporan mengembalikan cache laporan. Secara default dipakai CacheLRU di memori dengan
+// kapasitas CACHE_LAPORAN_KAPASITAS entri (default 200); kapasitas 0 menonaktifkan cache.
+func getCacheLaporan() utils.Cache {
+	cacheLaporanOnce.Do(func() {
+		kapasitas, err := strconv.Atoi(getEnv("CACHE_LAPORAN_KAPASITAS", "200"))
+		if err != nil || kapasitas < 0 {
+			log.Printf("CACHE_LAPORAN_KAPASITAS tidak valid, menggunakan 200")
+			kapasitas = 200
+		}
+		if kapasitas == 0 {
+			log.Println("Cache laporan dinonaktifkan (CACHE_LAPORAN_KAPASITAS=0)")
+			return
+		}
+		cacheLaporan = utils.NewCacheLRU(kapasitas)
+	})
+	return cacheLaporan
+}
+
+// SetCacheLaporan mengganti implementasi cache laporan, misalnya dengan cache bersama antar
+// instance. Harus dipanggil saat startup sebelum server menerima permintaan.
+func SetCacheLaporan(cache utils.Cache) {
+	cacheLaporanOnce.Do(func() {})
+	cacheLaporan = cache
+}
+
+// durasiEnv membaca durasi dari variabel lingkungan (contoh: 5m, 24h)
+func durasiEnv(key string, nilaiDefault time.Duration) time.Duration {
+	if d, err := time.ParseDuration(getEnv(key, "")); err == nil && d > 0 {
+		return d
+	}
+	return nilaiDefault
+}
+
+// ttlCacheLaporan menentukan masa berlaku cache untuk suatu rentang. Periode yang seluruhnya
+// sudah lewat jarang berubah sehingga disimpan lama (CACHE_LAPORAN_TTL_LAMPAU, default 24h);
+// periode yang mencakup hari ini masih menerima transaksi sehingga disimpan singkat
+// (CACHE_LAPORAN_TTL_BERJALAN, default 5m).
+func ttlCacheLaporan(rentang rentangTanggal, sekarang time.Time) time.Duration {
+	awalHariIni := time.Date(sekarang.Year(), sekarang.Month(), sekarang.Day(), 0, 0, 0, 0, sekarang.Location())
+	if !rentang.Selesai().After(awalHariIni) {
+		return durasiEnv("CACHE_LAPORAN_TTL_LAMPAU", 24*time.Hour)
+	}
+	return durasiEnv("CACHE_LAPORAN_TTL_BERJALAN", 5*time.Minute)
+}
+
+// kunciCacheLaporan menyusun kunci cache dari nama laporan, rentang tanggal hasil resolusi, label
+// periode hasil resolusi dan parameter lain yang dinormalisasi. Label periode ikut menjadi kunci
+// karena response HIT ditulis apa adanya dan filter.periode di dalamnya harus sesuai permintaan;
+// periode=2024-q2 dan periode=2024-Q2 tetap memakai entri yang sama, sedangkan permintaan dengan
+// tanggal_awal/tanggal_akhir (label kosong) memakai entri tersendiri.
+func kunciCacheLaporan(nama string, rentang rentangTanggal, params url.Values) string {
+	return nama + "|" + rentang.Awal + "|" + rentang.Akhir + "|" + rentang.Periode + "|" + normalisasiParameter(params)
+}
+
+// hapusCacheLaporan menghapus entri cache satu laporan, atau semua laporan jika nama kosong
+func hapusCacheLaporan(nama string) int {
+	cache := getCacheLaporan()
+	if cache == nil {
+		return 0
+	}
+	awalan := ""
+	if nama != "" {
+		awalan = nama + "|"
+	}
+	return cache.HapusAwalan(awalan)
+}
+
+// daftarkanCacheLaporan mencatat nama laporan yang memakai cache agar dapat dikosongkan lewat
+// CacheLaporanHandler
+func daftarkanCacheLaporan(nama string) {
+	laporanTercacheMu.Lock()
+	laporanTercache[nama] = true
+	laporanTercacheMu.Unlock()
+}
+
+// WithCacheLaporan membungkus handler laporan dengan cache hasil. Hanya response 200 yang
+// disimpan. Header X-Cache berisi HIT, MISS atau BYPASS; pada HIT header Age berisi umur entri
+// dalam detik. Parameter live=true melewati cache dan menyimpan ulang hasil terbaru.
+func WithCacheLaporan(nama string, handler http.HandlerFunc) http.HandlerFunc {
+	daftarkanCacheLaporan(nama)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		cache := getCacheLaporan()
+		if r.Method != http.MethodGet || cache == nil {
+			handler(w, r)
+			return
+		}
+
+		// Parameter tanggal yang tidak valid diteruskan ke handler agar error-nya seragam
+		rentang, err := getRentangTanggal(r)
+		if err != nil {
+			w.Header().Set("X-Cache", "BYPASS")
+			handler(w, r)
+			return
+		}
+
+		kunci := kunciCacheLaporan(nama, rentang, r.URL.Query())
+		if r.URL.Query().Get("live") != "true" {
+			if entri, ok := cache.Get(kunci); ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Cache", "HIT")
+				w.Header().Set("Age", strconv.Itoa(int(time.Since(entri.DibuatPada).Seconds())))
+				w.Header().Set("X-Cache-Kedaluwarsa", entri.KedaluwarsaPada.Format(time.RFC3339))
+				w.Write(entri.Data)
+				return
+			}
+		}
+
+		rekaman := &rekamanResponse{}
+		handler(rekaman, r)
+		if rekaman.status == 0 {
+			rekaman.status = http.StatusOK
+		}
+
+		for key, values := range rekaman.header {
+			w.Header()[key] = values
+		}
+		// Response dari permintaan yang sudah dibatalkan atau melewati batas waktu tidak disimpan
+		if rekaman.status == http.StatusOK && r.Context().Err() == nil {
+			ttl := ttlCacheLaporan(rentang, utils.SekarangRS())
+			cache.Set(kunci, rekaman.body.Bytes(), ttl)
+			w.Header().Set("X-Cache", "MISS")
+			w.Header().Set("X-Cache-Kedaluwarsa", time.Now().Add(ttl).Format(time.RFC3339))
+		} else {
+			w.Header().Set("X-Cache", "BYPASS")
+		}
+		w.WriteHeader(rekaman.status)
+		w.Write(rekaman.body.Bytes())
+	}
+}
+
+// CacheLaporanHandler menangani statistik (GET) dan pengosongan (DELETE) cache laporan.
+// Parameter laporan opsional membatasi pengosongan ke satu laporan.
+func CacheLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	cache := getCacheLaporan()
+
+	switch r.Method {
+	case http.MethodGet:
+		laporanTercacheMu.RLock()
+		nama := make([]string, 0, len(laporanTercache))
+		for n := range laporanTercache {
+			nama = append(nama, n)
+		}
+		laporanTercacheMu.RUnlock()
+		sort.Strings(nama)
+
+		response := map[string]interface{}{
+			"status":  "success",
+			"aktif":   cache != nil,
+			"laporan": nama,
+		}
+		if cache != nil {
+			response["statistik"] = cache.Statistik()
+		}
+		writeJSON(w, response)
+
+	case http.MethodDelete:
+		nama := r.URL.Query().Get("laporan")
+		if nama != "" {
+			laporanTercacheMu.RLock()
+			dikenal := laporanTercache[nama]
+			laporanTercacheMu.RUnlock()
+			if !dikenal {
+				utils.WriteFieldError(w, "laporan", "Laporan tidak dikenal: "+nama)
+				return
+			}
+		}
+
+		jumlah := hapusCacheLaporan(nama)
+		log.Printf("Cache laporan dikosongkan (laporan=%q): %d entri", nama, jumlah)
+
+		writeJSON(w, map[string]interface{}{
+			"status":       "success",
+			"message":      "Cache laporan berhasil dikosongkan",
+			"laporan":      nama,
+			"jumlah_entri": jumlah,
+		})
+
+	default:
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+	}
+}
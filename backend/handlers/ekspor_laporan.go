@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"strings"
+)
+
+// contentTypeEkspor adalah content type setiap format berkas ekspor
+var contentTypeEkspor = map[string]string{
+	models.FormatCSV:  "text/csv; charset=utf-8",
+	models.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	models.FormatPDF:  "application/pdf",
+}
+
+// kunciObjek mengembalikan kunci objek JSON sesuai urutan kemunculannya, sehingga kolom ekspor
+// mengikuti urutan field pada struct response
+func kunciObjek(raw json.RawMessage) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("bukan objek JSON")
+	}
+
+	var kunci []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		kunci = append(kunci, token.(string))
+
+		var lewati json.RawMessage
+		if err := decoder.Decode(&lewati); err != nil {
+			return nil, err
+		}
+	}
+	return kunci, nil
+}
+
+// decodeNilai mendekode nilai JSON dengan angka sebagai json.Number agar tidak kehilangan presisi
+func decodeNilai(raw json.RawMessage) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var nilai interface{}
+	err := decoder.Decode(&nilai)
+	return nilai, err
+}
+
+// nilaiSel mengubah nilai JSON menjadi isi sel; objek dan array ditulis sebagai teks JSON
+func nilaiSel(nilai interface{}) interface{} {
+	switch nilai.(type) {
+	case map[string]interface{}, []interface{}:
+		teks, _ := json.Marshal(nilai)
+		return string(teks)
+	}
+	return nilai
+}
+
+// tabelDariArray menyusun tabel ekspor dari array objek JSON. Kolom diambil dari baris pertama;
+// kolom yang baru muncul di baris berikutnya ditambahkan di akhir.
+func tabelDariArray(nama string, raw json.RawMessage) (utils.TabelEkspor, error) {
+	tabel := utils.TabelEkspor{Nama: nama}
+
+	var baris []json.RawMessage
+	if err := json.Unmarshal(raw, &baris); err != nil {
+		return tabel, err
+	}
+
+	indeksKolom := map[string]int{}
+	for _, rawBaris := range baris {
+		kunci, err := kunciObjek(rawBaris)
+		if err != nil {
+			return tabel, fmt.Errorf("baris %s bukan objek", nama)
+		}
+		for _, k := range kunci {
+			if _, ada := indeksKolom[k]; !ada {
+				indeksKolom[k] = len(tabel.Kolom)
+				tabel.Kolom = append(tabel.Kolom, k)
+			}
+		}
+
+		nilai, err := decodeNilai(rawBaris)
+		if err != nil {
+			return tabel, err
+		}
+		objek := nilai.(map[string]interface{})
+		sel := make([]interface{}, len(tabel.Kolom))
+		for k, v := range objek {
+			sel[indeksKolom[k]] = nilaiSel(v)
+		}
+		tabel.Baris = append(tabel.Baris, sel)
+	}
+	return tabel, nil
+}
+
+// susunDokumenEkspor mengubah response JSON laporan menjadi dokumen ekspor. Nilai di level atas
+// (total, filter, keterangan) dikumpulkan pada tabel ringkasan; setiap array objek menjadi satu
+// tabel dengan array data utama laporan di urutan pertama.
+func susunDokumenEkspor(laporan laporanTerdaftar, params url.Values, body []byte) (utils.DokumenEkspor, error) {
+	dokumen := utils.DokumenEkspor{
+		Judul: fmt.Sprintf("Laporan %s %s s.d. %s", laporan.Nama, params.Get("tanggal_awal"), params.Get("tanggal_akhir")),
+	}
+
+	urutan, err := kunciObjek(body)
+	if err != nil {
+		return dokumen, fmt.Errorf("response laporan tidak dapat dibaca: %w", err)
+	}
+	var isi map[string]json.RawMessage
+	if err := json.Unmarshal(body, &isi); err != nil {
+		return dokumen, fmt.Errorf("response laporan tidak dapat dibaca: %w", err)
+	}
+
+	ringkasan := utils.TabelEkspor{Nama: "ringkasan", Kolom: []string{"keterangan", "nilai"}}
+	var tabel []utils.TabelEkspor
+	for _, kunci := range urutan {
+		if kunci == "status" || kunci == "message" {
+			continue
+		}
+		raw := bytes.TrimSpace(isi[kunci])
+
+		// Slice kosong di Go dapat terenkode sebagai null
+		if _, array := laporan.Kunci[kunci]; (array || kunci == laporan.dataUtama()) && string(raw) == "null" {
+			tabel = append(tabel, utils.TabelEkspor{Nama: kunci})
+			continue
+		}
+		if len(raw) > 0 && raw[0] == '[' {
+			t, err := tabelDariArray(kunci, raw)
+			if err == nil {
+				tabel = append(tabel, t)
+				continue
+			}
+		}
+
+		nilai, err := decodeNilai(raw)
+		if err != nil {
+			return dokumen, err
+		}
+		if objek, ok := nilai.(map[string]interface{}); ok {
+			// Objek seperti filter diratakan menjadi filter.tanggal_awal, filter.tanggal_akhir, ...
+			subkunci, _ := kunciObjek(raw)
+			for _, k := range subkunci {
+				ringkasan.Baris = append(ringkasan.Baris, []interface{}{kunci + "." + k, nilaiSel(objek[k])})
+			}
+			continue
+		}
+		ringkasan.Baris = append(ringkasan.Baris, []interface{}{kunci, nilaiSel(nilai)})
+	}
+
+	utama := laporan.dataUtama()
+	sort.SliceStable(tabel, func(i, j int) bool {
+		return tabel[i].Nama == utama && tabel[j].Nama != utama
+	})
+
+	dokumen.Tabel = append([]utils.TabelEkspor{ringkasan}, tabel...)
+	return dokumen, nil
+}
+
+// tulisBerkasEkspor menulis dokumen dalam format yang diminta. CSV hanya memuat satu tabel
+// (bagian); XLSX dan PDF memuat ringkasan beserta seluruh tabel.
+func tulisBerkasEkspor(w io.Writer, format, bagian string, dokumen utils.DokumenEkspor) error {
+	switch format {
+	case models.FormatCSV:
+		for _, tabel := range dokumen.Tabel {
+			if tabel.Nama == bagian {
+				return utils.TulisCSV(w, tabel)
+			}
+		}
+		return fmt.Errorf("bagian %s tidak ada pada response laporan", bagian)
+	case models.FormatXLSX:
+		return utils.TulisXLSX(w, dokumen)
+	case models.FormatPDF:
+		return utils.TulisPDF(w, dokumen)
+	}
+	return fmt.Errorf("format tidak dikenal: %s", format)
+}
+
+// validasiEkspor memeriksa nama laporan, format dan bagian permintaan ekspor (job atau jadwal).
+// Format kosong menjadi xlsx dan bagian kosong untuk CSV menjadi array data utama laporan.
+func validasiEkspor(nama string, format, bagian *string) (laporanTerdaftar, error) {
+	laporan, ok := cariLaporan(nama)
+	if !ok {
+		pilihan := make([]string, len(daftarLaporan))
+		for i, l := range daftarLaporan {
+			pilihan[i] = l.Nama
+		}
+		return laporan, errValidasi("laporan", "Laporan tidak dikenal: %s (pilihan: %s)", nama, strings.Join(pilihan, ", "))
+	}
+
+	if *format == "" {
+		*format = models.FormatXLSX
+	}
+	switch *format {
+	case models.FormatCSV:
+		if *bagian == "" {
+			*bagian = laporan.dataUtama()
+		}
+		if _, ada := laporan.Kunci[*bagian]; !ada && *bagian != laporan.dataUtama() {
+			return laporan, errValidasi("bagian", "Bagian tidak dikenal untuk laporan %s: %s", laporan.Nama, *bagian)
+		}
+	case models.FormatXLSX, models.FormatPDF:
+		if *bagian != "" {
+			return laporan, errValidasi("bagian", "bagian hanya berlaku untuk format csv")
+		}
+	default:
+		return laporan, errValidasi("format", "format tidak dikenal: %s (pilihan: csv, xlsx, pdf)", *format)
+	}
+	return laporan, nil
+}
+
+// parameterLaporan mengubah parameter permintaan ekspor menjadi query laporan. Parameter live
+// dibuang karena ekspor selalu menjalankan handler laporan secara langsung.
+func parameterLaporan(parameter map[string]string) url.Values {
+	params := url.Values{}
+	for key, nilai := range parameter {
+		params.Set(key, nilai)
+	}
+	params.Del("live")
+	return params
+}
+
+// tetapkanRentang menghitung rentang tanggal dari parameter laporan pada saat ini (periode
+// relatif seperti yesterday ikut diselesaikan), lalu mengganti periode dengan tanggal_awal dan
+// tanggal_akhir yang pasti
+func tetapkanRentang(params url.Values) (rentangTanggal, error) {
+	rentang, err := getRentangTanggal(&http.Request{URL: &url.URL{RawQuery: params.Encode()}})
+	if err != nil {
+		return rentang, err
+	}
+	params.Del("periode")
+	params.Set("tanggal_awal", rentang.Awal)
+	params.Set("tanggal_akhir", rentang.Akhir)
+	return rentang, nil
+}
+
+// eksporLaporan menjalankan laporan di dalam proses dan menyusun hasilnya menjadi dokumen ekspor
+func eksporLaporan(ctx context.Context, laporan laporanTerdaftar, params url.Values) (utils.DokumenEkspor, error) {
+	status, body, err := jalankanLaporan(ctx, laporan, params)
+	if err != nil {
+		return utils.DokumenEkspor{}, err
+	}
+	// Handler tidak menulis response apa pun jika context dibatalkan
+	if ctx.Err() != nil {
+		return utils.DokumenEkspor{}, ctx.Err()
+	}
+	if status != http.StatusOK {
+		return utils.DokumenEkspor{}, fmt.Errorf("laporan gagal dengan status %d: %s", status, pesanErrorLaporan(body))
+	}
+	return susunDokumenEkspor(laporan, params, body)
+}
+
+// pesanErrorLaporan mengambil pesan dari body error laporan
+func pesanErrorLaporan(body []byte) string {
+	var errResp utils.ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
+		return errResp.Message
+	}
+	pesan := strings.TrimSpace(string(body))
+	if len(pesan) > 500 {
+		pesan = pesan[:500]
+	}
+	return pesan
+}
@@ -37,6 +37,7 @@ type MySQLStatus struct {
 // HealthResponse adalah struktur respons untuk endpoint health check
 type HealthResponse struct {
 	MySQLDB    DatabaseStatus `json:"mysql_db"`
+	SIAKDB     DatabaseStatus `json:"siak_db"`
 	MainDBType string         `json:"main_db_type"`
 }
 
@@ -50,13 +51,14 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 		MainDBType: utils.GetDatabaseType(),
 	}
 
-	// Periksa koneksi database MySQL
-	response.MySQLDB = checkDatabaseConnection(utils.DB, "mysql")
+	// Periksa koneksi database Khanza (laporan) dan SIAK
+	response.MySQLDB = checkDatabaseConnection(utils.GetMySQLDB(), "mysql")
+	response.SIAKDB = checkDatabaseConnection(utils.GetDB(), "mysql")
 
 	// Encode response JSON
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(response); err != nil {
-		http.Error(w, "Gagal mengenkode response", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengenkode response")
 		return
 	}
 }
@@ -132,16 +134,15 @@ func MySQLCheckHandler(w http.ResponseWriter, r *http.Request) {
 	// Set header Content-Type
 	w.Header().Set("Content-Type", "application/json")
 
-	// Periksa koneksi MySQL
-	mysqlHost := getEnv("MYSQL_HOST", "localhost")
-	mysqlPort := getEnv("MYSQL_PORT", "3306")
-	mysqlUser := getEnv("MYSQL_USER", "root")
-	mysqlPassword := getEnv("MYSQL_PASSWORD", "")
-	mysqlDBName := getEnv("MYSQL_DBNAME", "sik_test")
+	// Periksa koneksi MySQL Khanza (KHANZA_*, dengan MYSQL_* sebagai cadangan)
+	konfigurasi := utils.KonfigurasiKhanza()
+	mysqlHost := konfigurasi.Host
+	mysqlPort := konfigurasi.Port
+	mysqlUser := konfigurasi.User
+	mysqlDBName := konfigurasi.DBName
 
 	// Format DSN untuk MySQL
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		mysqlUser, mysqlPassword, mysqlHost, mysqlPort, mysqlDBName)
+	dsn := konfigurasi.DSN()
 
 	var status MySQLStatus
 	status.TimeChecked = time.Now()
@@ -180,7 +181,7 @@ func MySQLCheckHandler(w http.ResponseWriter, r *http.Request) {
 	// Encode response JSON
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(status); err != nil {
-		http.Error(w, "Gagal mengenkode response", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengenkode response")
 		return
 	}
 }
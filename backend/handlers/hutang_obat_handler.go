@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+)
+
+// HutangObatHandler menangani permintaan laporan hutang obat ke supplier.
+// Sisa hutang per faktur dihitung dari pemesanan.tagihan dikurangi pembayaran di bayar_pemesanan
+// sampai dengan per_tanggal, lalu diringkas menjadi umur hutang per supplier.
+func HutangObatHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	perTanggal, err := getParamTanggal(r, "per_tanggal", utils.SekarangRS().Format("2006-01-02"))
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	// Transaksi sampai akhir hari per_tanggal, yaitu sebelum pukul 00:00 hari berikutnya
+	hariPer, err := buatRentangTanggal(perTanggal, perTanggal)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	kodeSupplier := r.URL.Query().Get("kode_supplier")
+	tampilkanLunas, err := getParamBool(r, "tampilkan_lunas")
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	// Query SQL untuk sisa hutang per faktur
+	query := `
+		SELECT
+			pemesanan.no_faktur,
+			pemesanan.tgl_pesan,
+			pemesanan.tgl_faktur,
+			pemesanan.tgl_tempo,
+			datasuplier.kode_suplier AS kode_supplier,
+			datasuplier.nama_suplier AS nama_supplier,
+			pemesanan.tagihan,
+			COALESCE(bayar.total_bayar, 0) AS total_bayar,
+			pemesanan.tagihan - COALESCE(bayar.total_bayar, 0) AS sisa,
+			COALESCE(GREATEST(DATEDIFF(@per, pemesanan.tgl_tempo), 0), 0) AS hari_terlambat,
+			pemesanan.status
+		FROM
+			pemesanan
+		INNER JOIN
+			datasuplier ON datasuplier.kode_suplier = pemesanan.kode_suplier
+		LEFT JOIN
+		(
+			SELECT no_faktur, SUM(besar_bayar) AS total_bayar
+			FROM bayar_pemesanan
+			WHERE tgl_bayar < @sebelum
+			GROUP BY no_faktur
+		) AS bayar ON bayar.no_faktur = pemesanan.no_faktur
+		WHERE
+			pemesanan.tgl_faktur < @sebelum
+			AND (@supplier = '' OR pemesanan.kode_suplier = @supplier)
+			AND (@lunas OR pemesanan.tagihan - COALESCE(bayar.total_bayar, 0) > 0)
+		ORDER BY
+			datasuplier.nama_suplier, pemesanan.tgl_tempo
+	`
+
+	var result []models.HutangObat
+	err = db.Raw(query, map[string]interface{}{
+		"per":      perTanggal,
+		"sebelum":  hariPer.SelesaiSQL(),
+		"supplier": kodeSupplier,
+		"lunas":    tampilkanLunas,
+	}).Scan(&result).Error
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query", err)
+		return
+	}
+
+	// Ambil riwayat pembayaran untuk faktur yang tampil
+	if len(result) > 0 {
+		noFaktur := make([]string, 0, len(result))
+		for _, item := range result {
+			noFaktur = append(noFaktur, item.NoFaktur)
+		}
+
+		pembayaranQuery := `
+			SELECT
+				no_faktur,
+				tgl_bayar,
+				besar_bayar,
+				nama_bayar,
+				no_bukti,
+				keterangan
+			FROM
+				bayar_pemesanan
+			WHERE
+				no_faktur IN ?
+				AND tgl_bayar < ?
+			ORDER BY
+				tgl_bayar
+		`
+
+		var pembayaran []models.PembayaranHutang
+		if err := db.Raw(pembayaranQuery, noFaktur, hariPer.SelesaiSQL()).Scan(&pembayaran).Error; err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query pembayaran hutang", err)
+			return
+		}
+
+		pembayaranPerFaktur := map[string][]models.PembayaranHutang{}
+		for _, bayar := range pembayaran {
+			pembayaranPerFaktur[bayar.NoFaktur] = append(pembayaranPerFaktur[bayar.NoFaktur], bayar)
+		}
+		for i := range result {
+			result[i].Pembayaran = pembayaranPerFaktur[result[i].NoFaktur]
+			if result[i].Pembayaran == nil {
+				result[i].Pembayaran = []models.PembayaranHutang{}
+			}
+		}
+	}
+
+	// Jika tidak ada hasil, kembalikan array kosong
+	if result == nil {
+		result = []models.HutangObat{}
+	}
+
+	// Susun ringkasan umur hutang per supplier
+	umurPerSupplier := map[string]*models.UmurHutangSupplier{}
+	var totalTagihan, totalBayar, totalSisa float64
+	for _, item := range result {
+		totalTagihan += item.Tagihan
+		totalBayar += item.TotalBayar
+		totalSisa += item.Sisa
+
+		umur, ok := umurPerSupplier[item.KodeSupplier]
+		if !ok {
+			umur = &models.UmurHutangSupplier{KodeSupplier: item.KodeSupplier, NamaSupplier: item.NamaSupplier}
+			umurPerSupplier[item.KodeSupplier] = umur
+		}
+		umur.JumlahFaktur++
+		umur.TotalSisa += item.Sisa
+
+		switch {
+		case item.HariTerlambat <= 0:
+			umur.BelumJatuhTempo += item.Sisa
+		case item.HariTerlambat <= 30:
+			umur.Terlambat1s30 += item.Sisa
+		case item.HariTerlambat <= 60:
+			umur.Terlambat31s60 += item.Sisa
+		case item.HariTerlambat <= 90:
+			umur.Terlambat61s90 += item.Sisa
+		default:
+			umur.TerlambatLebih90 += item.Sisa
+		}
+	}
+
+	umurHutang := []models.UmurHutangSupplier{}
+	for _, umur := range umurPerSupplier {
+		umurHutang = append(umurHutang, *umur)
+	}
+	sort.Slice(umurHutang, func(i, j int) bool {
+		return umurHutang[i].TotalSisa > umurHutang[j].TotalSisa
+	})
+
+	// Query pelengkap yang gagal karena batas waktu tidak boleh menghasilkan laporan parsial
+	if konteksBerakhir(w, r) {
+		return
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data hutang obat berhasil diambil dari database",
+		"filter": map[string]string{
+			"per_tanggal":   perTanggal,
+			"kode_supplier": kodeSupplier,
+		},
+		"total_data":    len(result),
+		"total_tagihan": totalTagihan,
+		"total_bayar":   totalBayar,
+		"total_sisa":    totalSisa,
+		"umur_hutang":   umurHutang,
+		"keterangan":    "Sisa hutang dihitung dari tagihan faktur dikurangi pembayaran sampai per_tanggal",
+		"data":          result,
+	}
+
+	writeJSON(w, response)
+}
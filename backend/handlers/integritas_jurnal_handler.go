@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HasilPemeriksaanJurnal menyimpan hasil satu kali pemeriksaan integritas jurnal
+type HasilPemeriksaanJurnal struct {
+	TanggalAwal  string                `json:"tanggal_awal"`
+	TanggalAkhir string                `json:"tanggal_akhir"`
+	Periode      string                `json:"periode,omitempty"`
+	WaktuPeriksa time.Time             `json:"waktu_periksa"`
+	JumlahTemuan map[string]int        `json:"jumlah_temuan"`
+	Temuan       []models.TemuanJurnal `json:"temuan"`
+}
+
+// Hasil terakhir dari pemeriksaan berkala, disimpan di memori
+var (
+	hasilPemeriksaanTerakhir *HasilPemeriksaanJurnal
+	hasilPemeriksaanMutex    sync.RWMutex
+)
+
+// Jenis temuan pemeriksaan integritas jurnal
+const (
+	TemuanTidakSeimbang    = "jurnal_tidak_seimbang"
+	TemuanTanpaDetail      = "jurnal_tanpa_detail"
+	TemuanRekeningTidakAda = "rekening_tidak_ada"
+	TemuanNotaTanpaJurnal  = "nota_tanpa_jurnal"
+)
+
+// PeriksaIntegritasJurnal memeriksa jurnal dan detailjurnal pada periode yang diberikan untuk
+// jurnal yang debet dan kreditnya tidak seimbang, jurnal tanpa detail, detail yang merujuk
+// rekening yang tidak ada, serta pembayaran nota_jalan/nota_inap yang tidak memiliki jurnal.
+// Jurnal billing Khanza dicatat dengan no_bukti = no_rawat.
+func PeriksaIntegritasJurnal(db *gorm.DB, tanggalAwal, tanggalAkhir string) (*HasilPemeriksaanJurnal, error) {
+	rentang, err := buatRentangTanggal(tanggalAwal, tanggalAkhir)
+	if err != nil {
+		return nil, err
+	}
+
+	pemeriksaan := []struct {
+		jenis string
+		query string
+	}{
+		{
+			jenis: TemuanTidakSeimbang,
+			query: `
+				SELECT
+					jurnal.no_jurnal,
+					jurnal.no_bukti,
+					DATE_FORMAT(jurnal.tgl_jurnal, '%Y-%m-%d') AS tanggal,
+					SUM(detailjurnal.debet) AS debet,
+					SUM(detailjurnal.kredit) AS kredit,
+					jurnal.keterangan
+				FROM jurnal
+				INNER JOIN detailjurnal ON detailjurnal.no_jurnal = jurnal.no_jurnal
+				WHERE jurnal.tgl_jurnal >= @awal AND jurnal.tgl_jurnal < @akhir
+				GROUP BY jurnal.no_jurnal
+				HAVING ABS(SUM(detailjurnal.debet) - SUM(detailjurnal.kredit)) >= 0.01
+			`,
+		},
+		{
+			jenis: TemuanTanpaDetail,
+			query: `
+				SELECT
+					jurnal.no_jurnal,
+					jurnal.no_bukti,
+					DATE_FORMAT(jurnal.tgl_jurnal, '%Y-%m-%d') AS tanggal,
+					jurnal.keterangan
+				FROM jurnal
+				LEFT JOIN detailjurnal ON detailjurnal.no_jurnal = jurnal.no_jurnal
+				WHERE jurnal.tgl_jurnal >= @awal AND jurnal.tgl_jurnal < @akhir
+					AND detailjurnal.no_jurnal IS NULL
+			`,
+		},
+		{
+			jenis: TemuanRekeningTidakAda,
+			query: `
+				SELECT
+					jurnal.no_jurnal,
+					jurnal.no_bukti,
+					detailjurnal.kd_rek,
+					DATE_FORMAT(jurnal.tgl_jurnal, '%Y-%m-%d') AS tanggal,
+					detailjurnal.debet,
+					detailjurnal.kredit,
+					jurnal.keterangan
+				FROM jurnal
+				INNER JOIN detailjurnal ON detailjurnal.no_jurnal = jurnal.no_jurnal
+				LEFT JOIN rekening ON rekening.kd_rek = detailjurnal.kd_rek
+				WHERE jurnal.tgl_jurnal >= @awal AND jurnal.tgl_jurnal < @akhir
+					AND rekening.kd_rek IS NULL
+			`,
+		},
+		{
+			jenis: TemuanNotaTanpaJurnal,
+			query: `
+				SELECT
+					nota.no_rawat,
+					nota.no_nota AS no_bukti,
+					DATE_FORMAT(nota.tanggal, '%Y-%m-%d') AS tanggal,
+					nota.keterangan
+				FROM
+				(
+					SELECT no_rawat, no_nota, tanggal, 'Nota rawat jalan tanpa jurnal' AS keterangan
+					FROM nota_jalan
+					WHERE tanggal >= @awal AND tanggal < @akhir
+					UNION ALL
+					SELECT no_rawat, no_nota, tanggal, 'Nota rawat inap tanpa jurnal' AS keterangan
+					FROM nota_inap
+					WHERE tanggal >= @awal AND tanggal < @akhir
+				) AS nota
+				WHERE NOT EXISTS (
+					SELECT 1 FROM jurnal WHERE jurnal.no_bukti = nota.no_rawat
+				)
+			`,
+		},
+	}
+
+	hasil := &HasilPemeriksaanJurnal{
+		TanggalAwal:  tanggalAwal,
+		TanggalAkhir: tanggalAkhir,
+		WaktuPeriksa: time.Now(),
+		JumlahTemuan: map[string]int{},
+		Temuan:       []models.TemuanJurnal{},
+	}
+
+	for _, p := range pemeriksaan {
+		var temuan []models.TemuanJurnal
+		err := db.Raw(p.query, rentang.Parameter()).Scan(&temuan).Error
+		if err != nil {
+			return nil, fmt.Errorf("pemeriksaan %s gagal: %w", p.jenis, err)
+		}
+
+		for i := range temuan {
+			temuan[i].Jenis = p.jenis
+		}
+		hasil.JumlahTemuan[p.jenis] = len(temuan)
+		hasil.Temuan = append(hasil.Temuan, temuan...)
+	}
+
+	return hasil, nil
+}
+
+// JalankanPemeriksaanJurnalBerkala menjalankan pemeriksaan integritas jurnal bulan berjalan
+// secara berkala di background. Interval diatur lewat JURNAL_CHECK_INTERVAL (contoh: 6h),
+// kosong berarti pemeriksaan berkala tidak dijalankan.
+func JalankanPemeriksaanJurnalBerkala() {
+	intervalStr := getEnv("JURNAL_CHECK_INTERVAL", "")
+	if intervalStr == "" {
+		return
+	}
+
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil || interval <= 0 {
+		log.Printf("JURNAL_CHECK_INTERVAL tidak valid: %s", intervalStr)
+		return
+	}
+
+	go func() {
+		for {
+			// Periksa bulan berjalan sampai hari ini menurut zona waktu rumah sakit
+			tanggalAwal := rentangBulan(utils.SekarangRS()).Awal
+			tanggalAkhir := utils.SekarangRS().Format("2006-01-02")
+
+			if db := utils.GetMySQLDB(); db != nil {
+				hasil, err := PeriksaIntegritasJurnal(db, tanggalAwal, tanggalAkhir)
+				if err != nil {
+					log.Printf("Pemeriksaan integritas jurnal gagal: %v", err)
+				} else {
+					log.Printf("Pemeriksaan integritas jurnal %s s.d. %s: %d temuan",
+						tanggalAwal, tanggalAkhir, len(hasil.Temuan))
+
+					hasilPemeriksaanMutex.Lock()
+					hasilPemeriksaanTerakhir = hasil
+					hasilPemeriksaanMutex.Unlock()
+				}
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+
+	log.Printf("Pemeriksaan integritas jurnal berkala aktif setiap %s", interval)
+}
+
+// IntegritasJurnalHandler menangani permintaan pemeriksaan integritas jurnal.
+// Dengan hasil_terakhir=true, hasil pemeriksaan berkala terakhir dikembalikan tanpa query ulang.
+func IntegritasJurnalHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	hasilTerakhir, err := getParamBool(r, "hasil_terakhir")
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	var hasil *HasilPemeriksaanJurnal
+	if hasilTerakhir {
+		hasilPemeriksaanMutex.RLock()
+		hasil = hasilPemeriksaanTerakhir
+		hasilPemeriksaanMutex.RUnlock()
+
+		if hasil == nil {
+			utils.WriteError(w, http.StatusNotFound, "Belum ada hasil pemeriksaan berkala")
+			return
+		}
+	} else {
+		rentang, errTanggal := getRentangTanggal(r)
+		if errTanggal != nil {
+			tulisErrorValidasi(w, errTanggal)
+			return
+		}
+
+		db, selesai := getKoneksiLaporan(w, r)
+		if db == nil {
+			return
+		}
+		defer selesai()
+
+		hasil, err = PeriksaIntegritasJurnal(db, rentang.Awal, rentang.Akhir)
+		if err != nil {
+			tulisErrorQuery(w, r, "Gagal memeriksa integritas jurnal", err)
+			return
+		}
+		hasil.Periode = rentang.Periode
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Pemeriksaan integritas jurnal selesai",
+		"filter": map[string]string{
+			"tanggal_awal":  hasil.TanggalAwal,
+			"tanggal_akhir": hasil.TanggalAkhir,
+			"periode":       hasil.Periode,
+		},
+		"waktu_periksa": hasil.WaktuPeriksa,
+		"total_temuan":  len(hasil.Temuan),
+		"jumlah_temuan": hasil.JumlahTemuan,
+		"bersih":        len(hasil.Temuan) == 0,
+		"data":          hasil.Temuan,
+	}
+
+	writeJSON(w, response)
+}
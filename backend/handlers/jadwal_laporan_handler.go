@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JadwalLaporanRequest menyimpan data permintaan membuat atau memperbarui jadwal laporan
+type JadwalLaporanRequest struct {
+	Nama    string `json:"nama"`
+	Cron    string `json:"cron"`
+	Laporan string `json:"laporan"`
+	Format  string `json:"format"`
+	Bagian  string `json:"bagian"`
+	// Parameter laporan; gunakan periode relatif (yesterday, last_month, ytd) agar rentang
+	// mengikuti waktu jadwal berjalan
+	Parameter map[string]string `json:"parameter"`
+	Penerima  []string          `json:"penerima"`
+	Aktif     *bool             `json:"aktif"`
+}
+
+// JadwalLaporanResponse adalah jadwal laporan dengan daftar penerima dalam bentuk array
+type JadwalLaporanResponse struct {
+	models.JadwalLaporan
+	Penerima []string `json:"penerima"`
+}
+
+// validasi memeriksa isi permintaan jadwal. Parameter dicoba diselesaikan menjadi rentang
+// tanggal agar kesalahan periode diketahui saat jadwal disimpan, bukan saat jadwal berjalan.
+func (req *JadwalLaporanRequest) validasi() (*utils.JadwalCron, url.Values, error) {
+	req.Nama = strings.TrimSpace(req.Nama)
+	if err := validasiWajib("nama", req.Nama, "cron", req.Cron, "laporan", req.Laporan); err != nil {
+		return nil, nil, err
+	}
+
+	cron, err := utils.ParseCron(req.Cron)
+	if err != nil {
+		return nil, nil, errValidasi("cron", "%s", err.Error())
+	}
+	if cron.Berikutnya(utils.SekarangRS()).IsZero() {
+		return nil, nil, errValidasi("cron", "Ekspresi cron tidak pernah berjalan: %s", req.Cron)
+	}
+
+	if _, err := validasiEkspor(req.Laporan, &req.Format, &req.Bagian); err != nil {
+		return nil, nil, err
+	}
+
+	params := parameterLaporan(req.Parameter)
+	if _, err := tetapkanRentang(parameterLaporan(req.Parameter)); err != nil {
+		return nil, nil, err
+	}
+
+	if len(req.Penerima) == 0 {
+		return nil, nil, errValidasi("penerima", "penerima wajib diisi")
+	}
+	for i, penerima := range req.Penerima {
+		alamat, err := mail.ParseAddress(strings.TrimSpace(penerima))
+		if err != nil {
+			return nil, nil, errValidasi("penerima", "Alamat email tidak valid: %s", penerima)
+		}
+		req.Penerima[i] = alamat.Address
+	}
+
+	return cron, params, nil
+}
+
+// responseJadwal mengubah jadwal menjadi response dengan penerima sebagai array
+func responseJadwal(jadwal models.JadwalLaporan) JadwalLaporanResponse {
+	return JadwalLaporanResponse{JadwalLaporan: jadwal, Penerima: daftarPenerima(jadwal.Penerima)}
+}
+
+// daftarPenerima memecah kolom penerima (dipisah koma) menjadi daftar alamat email
+func daftarPenerima(penerima string) []string {
+	daftar := []string{}
+	for _, alamat := range strings.Split(penerima, ",") {
+		if alamat = strings.TrimSpace(alamat); alamat != "" {
+			daftar = append(daftar, alamat)
+		}
+	}
+	return daftar
+}
+
+// berikutnyaJadwal menghitung waktu jalan berikutnya; nil untuk jadwal nonaktif
+func berikutnyaJadwal(cron *utils.JadwalCron, aktif bool) *time.Time {
+	if !aktif {
+		return nil
+	}
+	berikutnya := cron.Berikutnya(utils.SekarangRS())
+	return &berikutnya
+}
+
+// JalankanPenjadwalLaporan menjalankan penjadwal laporan di background. Setiap awal menit jadwal
+// aktif yang sudah jatuh tempo dijalankan. Jadwal yang terlewat karena server mati dijalankan
+// satu kali saat server kembali hidup.
+func JalankanPenjadwalLaporan() {
+	if utils.GetDB() == nil {
+		log.Println("Database SIAK tidak tersedia, jadwal laporan dinonaktifkan")
+		return
+	}
+
+	go func() {
+		for {
+			sekarang := time.Now()
+			time.Sleep(sekarang.Truncate(time.Minute).Add(time.Minute).Sub(sekarang))
+			jalankanJadwalJatuhTempo()
+		}
+	}()
+
+	log.Println("Penjadwal laporan aktif")
+}
+
+// jalankanJadwalJatuhTempo menjalankan jadwal yang waktunya sudah tiba. Waktu berikutnya
+// dipindahkan lebih dulu dengan syarat nilainya belum berubah, sehingga satu jadwal tidak
+// dijalankan dua kali meskipun ada lebih dari satu instance server.
+func jalankanJadwalJatuhTempo() {
+	db := utils.GetDB()
+	sekarang := utils.SekarangRS()
+
+	var jatuhTempo []models.JadwalLaporan
+	if err := db.Where("aktif = ? AND berikutnya_pada <= ?", true, sekarang).Find(&jatuhTempo).Error; err != nil {
+		log.Printf("Gagal membaca jadwal laporan: %v", err)
+		return
+	}
+
+	for _, jadwal := range jatuhTempo {
+		cron, err := utils.ParseCron(jadwal.Cron)
+		if err != nil {
+			log.Printf("Jadwal laporan %d memiliki cron tidak valid: %v", jadwal.ID, err)
+			continue
+		}
+
+		hasil := db.Model(&models.JadwalLaporan{}).
+			Where("id = ? AND berikutnya_pada = ?", jadwal.ID, jadwal.BerikutnyaPada).
+			UpdateColumn("berikutnya_pada", cron.Berikutnya(sekarang))
+		if hasil.Error != nil || hasil.RowsAffected == 0 {
+			continue
+		}
+
+		go eksekusiJadwal(jadwal, false)
+	}
+}
+
+// eksekusiJadwal menjalankan satu jadwal dan mencatat hasilnya di riwayat
+func eksekusiJadwal(jadwal models.JadwalLaporan, manual bool) models.RiwayatJadwalLaporan {
+	db := utils.GetDB()
+	riwayat := models.RiwayatJadwalLaporan{
+		JadwalID:    jadwal.ID,
+		Penerima:    jadwal.Penerima,
+		Manual:      manual,
+		DimulaiPada: time.Now(),
+	}
+
+	err := kirimJadwalLaporan(jadwal, &riwayat)
+	riwayat.SelesaiPada = time.Now()
+	riwayat.Status = models.JadwalBerhasil
+	if err != nil {
+		riwayat.Status = models.JadwalGagal
+		riwayat.Pesan = err.Error()
+	}
+
+	if err := db.Create(&riwayat).Error; err != nil {
+		log.Printf("Gagal mencatat riwayat jadwal laporan %d: %v", jadwal.ID, err)
+	}
+	db.Model(&models.JadwalLaporan{}).Where("id = ?", jadwal.ID).UpdateColumns(map[string]interface{}{
+		"terakhir_pada":   riwayat.DimulaiPada,
+		"terakhir_status": riwayat.Status,
+	})
+
+	if err != nil {
+		log.Printf("Jadwal laporan %d (%s) gagal: %v", jadwal.ID, jadwal.Nama, err)
+		KirimEvent(models.EventJadwalLaporanGagal, map[string]interface{}{
+			"jadwal_id":     jadwal.ID,
+			"nama":          jadwal.Nama,
+			"laporan":       jadwal.Laporan,
+			"tanggal_awal":  riwayat.TanggalAwal,
+			"tanggal_akhir": riwayat.TanggalAkhir,
+			"penerima":      daftarPenerima(jadwal.Penerima),
+			"manual":        manual,
+			"pesan":         riwayat.Pesan,
+		})
+	} else {
+		log.Printf("Jadwal laporan %d (%s) terkirim ke %s", jadwal.ID, jadwal.Nama, jadwal.Penerima)
+	}
+	return riwayat
+}
+
+// kirimJadwalLaporan menjalankan laporan jadwal untuk rentang saat ini, menyusun berkasnya lalu
+// mengirimnya sebagai lampiran email. Ukuran lampiran dibatasi JADWAL_LAPORAN_MAKS_LAMPIRAN
+// byte (default 10 MB) karena kebanyakan server email menolak lampiran yang lebih besar.
+func kirimJadwalLaporan(jadwal models.JadwalLaporan, riwayat *models.RiwayatJadwalLaporan) error {
+	laporan, ok := cariLaporan(jadwal.Laporan)
+	if !ok {
+		return fmt.Errorf("laporan tidak lagi terdaftar: %s", jadwal.Laporan)
+	}
+	params, err := url.ParseQuery(jadwal.Parameter)
+	if err != nil {
+		return fmt.Errorf("parameter jadwal tidak valid: %w", err)
+	}
+	rentang, err := tetapkanRentang(params)
+	if err != nil {
+		return err
+	}
+	riwayat.TanggalAwal, riwayat.TanggalAkhir = rentang.Awal, rentang.Akhir
+
+	ctx, cancel := context.WithTimeout(context.Background(), durasiEnv("JADWAL_LAPORAN_TIMEOUT", 30*time.Minute))
+	defer cancel()
+
+	dokumen, err := eksporLaporan(ctx, laporan, params)
+	if err != nil {
+		return err
+	}
+
+	var berkas bytes.Buffer
+	if err := tulisBerkasEkspor(&berkas, jadwal.Format, jadwal.Bagian, dokumen); err != nil {
+		return fmt.Errorf("gagal menyusun berkas: %w", err)
+	}
+	riwayat.UkuranBerkas = int64(berkas.Len())
+	if maks := envInt("JADWAL_LAPORAN_MAKS_LAMPIRAN", 10<<20); berkas.Len() > maks {
+		return fmt.Errorf("ukuran lampiran %d byte melebihi batas %d byte, persempit periode atau gunakan job laporan", berkas.Len(), maks)
+	}
+
+	pesan := utils.PesanEmail{
+		Penerima: daftarPenerima(jadwal.Penerima),
+		Subjek:   fmt.Sprintf("%s: %s %s s.d. %s", jadwal.Nama, laporan.Nama, rentang.Awal, rentang.Akhir),
+		Isi:      isiEmailJadwal(jadwal, laporan, rentang, dokumen),
+		Lampiran: []utils.LampiranEmail{{
+			NamaBerkas:  fmt.Sprintf("%s_%s_%s.%s", laporan.Nama, rentang.Awal, rentang.Akhir, jadwal.Format),
+			ContentType: contentTypeEkspor[jadwal.Format],
+			Data:        berkas.Bytes(),
+		}},
+	}
+	return utils.KirimEmail(utils.KonfigurasiSMTPDariEnv(), pesan)
+}
+
+// isiEmailJadwal menyusun isi email: keterangan laporan dan nilai ringkasan (total) laporan
+func isiEmailJadwal(jadwal models.JadwalLaporan, laporan laporanTerdaftar, rentang rentangTanggal, dokumen utils.DokumenEkspor) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Laporan %s periode %s s.d. %s terlampir.\n\n", laporan.Nama, rentang.Awal, rentang.Akhir)
+
+	if len(dokumen.Tabel) > 0 && len(dokumen.Tabel[0].Baris) > 0 {
+		sb.WriteString("Ringkasan:\n")
+		for _, baris := range dokumen.Tabel[0].Baris {
+			if strings.HasPrefix(fmt.Sprint(baris[0]), "filter.") {
+				continue
+			}
+			fmt.Fprintf(&sb, "  %v: %v\n", baris[0], baris[1])
+		}
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "Email ini dikirim otomatis oleh SIAK sesuai jadwal \"%s\" (%s).\n", jadwal.Nama, jadwal.Cron)
+	return sb.String()
+}
+
+// getJadwalDariURL mengambil jadwal dari path /api/jadwal-laporan/{id}. Jika tidak ditemukan,
+// response error sudah ditulis.
+func getJadwalDariURL(w http.ResponseWriter, r *http.Request) (*models.JadwalLaporan, bool) {
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID jadwal tidak valid")
+		return nil, false
+	}
+
+	var jadwal models.JadwalLaporan
+	if err := utils.GetDB().First(&jadwal, id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Jadwal tidak ditemukan")
+		return nil, false
+	}
+	return &jadwal, true
+}
+
+// GetJadwalLaporanHandler menangani permintaan daftar jadwal laporan
+func GetJadwalLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	var jadwal []models.JadwalLaporan
+	if err := utils.GetDB().Order("nama").Find(&jadwal).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil data jadwal laporan: "+err.Error())
+		return
+	}
+
+	data := make([]JadwalLaporanResponse, len(jadwal))
+	for i, j := range jadwal {
+		data[i] = responseJadwal(j)
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+// CreateJadwalLaporanHandler menangani permintaan membuat jadwal laporan
+func CreateJadwalLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Decode permintaan JSON
+	var req JadwalLaporanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	cron, params, err := req.validasi()
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	aktif := req.Aktif == nil || *req.Aktif
+	username, _ := r.Context().Value("username").(string)
+	jadwal := models.JadwalLaporan{
+		Nama:           req.Nama,
+		Cron:           cron.Ekspresi,
+		Laporan:        req.Laporan,
+		Parameter:      params.Encode(),
+		Format:         req.Format,
+		Bagian:         req.Bagian,
+		Penerima:       strings.Join(req.Penerima, ", "),
+		Aktif:          aktif,
+		BerikutnyaPada: berikutnyaJadwal(cron, aktif),
+		DibuatOleh:     username,
+	}
+
+	if err := utils.GetDB().Create(&jadwal).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menyimpan jadwal laporan: "+err.Error())
+		return
+	}
+
+	log.Printf("Jadwal laporan %d (%s, %s) dibuat oleh %s", jadwal.ID, jadwal.Nama, jadwal.Cron, username)
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responseJadwal(jadwal))
+}
+
+// UpdateJadwalLaporanHandler menangani permintaan memperbarui jadwal laporan. Waktu jalan
+// berikutnya dihitung ulang dari ekspresi cron yang baru.
+func UpdateJadwalLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode PUT
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	jadwal, ok := getJadwalDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode permintaan JSON
+	var req JadwalLaporanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	cron, params, err := req.validasi()
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	aktif := jadwal.Aktif
+	if req.Aktif != nil {
+		aktif = *req.Aktif
+	}
+	jadwal.Nama = req.Nama
+	jadwal.Cron = cron.Ekspresi
+	jadwal.Laporan = req.Laporan
+	jadwal.Parameter = params.Encode()
+	jadwal.Format = req.Format
+	jadwal.Bagian = req.Bagian
+	jadwal.Penerima = strings.Join(req.Penerima, ", ")
+	jadwal.Aktif = aktif
+	jadwal.BerikutnyaPada = berikutnyaJadwal(cron, aktif)
+
+	if err := utils.GetDB().Save(jadwal).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memperbarui jadwal laporan: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(responseJadwal(*jadwal))
+}
+
+// DeleteJadwalLaporanHandler menangani permintaan menghapus jadwal laporan beserta riwayatnya
+func DeleteJadwalLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode DELETE
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	jadwal, ok := getJadwalDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("jadwal_id = ?", jadwal.ID).Delete(&models.RiwayatJadwalLaporan{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(jadwal).Error
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghapus jadwal laporan: "+err.Error())
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	log.Printf("Jadwal laporan %d (%s) dihapus oleh %s", jadwal.ID, jadwal.Nama, username)
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Jadwal laporan berhasil dihapus",
+	})
+}
+
+// GetRiwayatJadwalLaporanHandler menangani permintaan riwayat eksekusi satu jadwal, terbaru
+// lebih dulu. Parameter limit membatasi jumlah data (default 50, maksimal 500).
+func GetRiwayatJadwalLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	limit, err := getParamInt(r, "limit", 50, 1, 500)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	jadwal, ok := getJadwalDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	var riwayat []models.RiwayatJadwalLaporan
+	err = utils.GetDB().Where("jadwal_id = ?", jadwal.ID).Order("dimulai_pada desc").Limit(limit).Find(&riwayat).Error
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil riwayat jadwal laporan: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"status":     "success",
+		"jadwal":     responseJadwal(*jadwal),
+		"total_data": len(riwayat),
+		"data":       riwayat,
+	})
+}
+
+// JalankanJadwalLaporanHandler menangani permintaan menjalankan jadwal sekarang juga, misalnya
+// untuk menguji pengaturan SMTP dan penerima. Jadwal berikutnya tidak berubah dan response
+// berisi catatan riwayat eksekusi tersebut.
+func JalankanJadwalLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	jadwal, ok := getJadwalDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	riwayat := eksekusiJadwal(*jadwal, true)
+
+	// Kirim respons; hasil gagal tetap 200 karena kegagalannya sudah tercatat di riwayat
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(riwayat)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"siak-rsbw/backend/models"
+)
+
+// emailDiterima adalah satu email yang diterima server SMTP palsu
+type emailDiterima struct {
+	pengirim string
+	penerima []string
+	data     []byte
+}
+
+// jalankanSMTPPalsu menjalankan server SMTP tanpa enkripsi dan autentikasi yang menerima satu
+// sesi, lalu mengirim email yang diterima ke channel
+func jalankanSMTPPalsu(t *testing.T) (string, string, <-chan emailDiterima) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("gagal membuka listener SMTP: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	hasil := make(chan emailDiterima, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var email emailDiterima
+		tp.PrintfLine("220 smtp-palsu ESMTP")
+		for {
+			baris, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			perintah := strings.ToUpper(baris)
+			switch {
+			case strings.HasPrefix(perintah, "EHLO"), strings.HasPrefix(perintah, "HELO"):
+				tp.PrintfLine("250-smtp-palsu")
+				tp.PrintfLine("250 8BITMIME")
+			case strings.HasPrefix(perintah, "MAIL FROM:"):
+				email.pengirim = alamatSMTP(baris)
+				tp.PrintfLine("250 OK")
+			case strings.HasPrefix(perintah, "RCPT TO:"):
+				email.penerima = append(email.penerima, alamatSMTP(baris))
+				tp.PrintfLine("250 OK")
+			case perintah == "DATA":
+				tp.PrintfLine("354 Akhiri dengan <CRLF>.<CRLF>")
+				if email.data, err = tp.ReadDotBytes(); err != nil {
+					return
+				}
+				tp.PrintfLine("250 OK")
+				hasil <- email
+			case perintah == "QUIT":
+				tp.PrintfLine("221 Bye")
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	return host, port, hasil
+}
+
+// alamatSMTP mengambil alamat di antara < dan > pada perintah MAIL FROM atau RCPT TO
+func alamatSMTP(baris string) string {
+	_, alamat, _ := strings.Cut(baris, "<")
+	alamat, _, _ = strings.Cut(alamat, ">")
+	return alamat
+}
+
+func TestKirimJadwalLaporanEmail(t *testing.T) {
+	host, port, diterima := jalankanSMTPPalsu(t)
+	t.Setenv("SMTP_HOST", host)
+	t.Setenv("SMTP_PORT", port)
+	t.Setenv("SMTP_MODE", "none")
+	t.Setenv("SMTP_USER", "")
+	t.Setenv("SMTP_FROM", "siak@rs.test")
+
+	// Laporan palsu agar tidak membutuhkan database Khanza
+	asli := daftarLaporan
+	t.Cleanup(func() { daftarLaporan = asli })
+	daftarLaporan = append(append([]laporanTerdaftar{}, asli...), laporanTerdaftar{
+		Nama: "uji-email",
+		Path: "/api/laporan/uji-email",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"status":      "success",
+				"total_bayar": 175000,
+				"data": []map[string]interface{}{
+					{"no_rawat": "2024/05/01/000001", "besar_bayar": 100000},
+					{"no_rawat": "2024/05/02/000007", "besar_bayar": 75000},
+				},
+			})
+		},
+		Kunci: map[string][]string{"data": {"no_rawat"}},
+	})
+
+	jadwal := models.JadwalLaporan{
+		Nama:      "Harian keuangan",
+		Cron:      "0 7 * * *",
+		Laporan:   "uji-email",
+		Parameter: "periode=2024-05",
+		Format:    models.FormatCSV,
+		Bagian:    "data",
+		Penerima:  "direktur@rs.test, keuangan@rs.test",
+	}
+	var riwayat models.RiwayatJadwalLaporan
+	if err := kirimJadwalLaporan(jadwal, &riwayat); err != nil {
+		t.Fatalf("kirimJadwalLaporan error: %v", err)
+	}
+
+	email := <-diterima
+	if email.pengirim != "siak@rs.test" {
+		t.Errorf("pengirim = %q, ingin siak@rs.test", email.pengirim)
+	}
+	if strings.Join(email.penerima, ",") != "direktur@rs.test,keuangan@rs.test" {
+		t.Errorf("penerima = %v, ingin [direktur@rs.test keuangan@rs.test]", email.penerima)
+	}
+
+	pesan, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(email.data))))
+	if err != nil {
+		t.Fatalf("email tidak dapat dibaca: %v", err)
+	}
+	subjek, _ := new(mime.WordDecoder).DecodeHeader(pesan.Header.Get("Subject"))
+	if ingin := "Harian keuangan: uji-email 2024-05-01 s.d. 2024-05-31"; subjek != ingin {
+		t.Errorf("subjek = %q, ingin %q", subjek, ingin)
+	}
+
+	_, paramTipe, err := mime.ParseMediaType(pesan.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Content-Type email tidak valid: %v", err)
+	}
+	bagian := multipart.NewReader(pesan.Body, paramTipe["boundary"])
+	var lampiran []byte
+	namaBerkas := ""
+	for {
+		part, err := bagian.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("bagian email tidak dapat dibaca: %v", err)
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		if enc := part.Header.Get("Content-Transfer-Encoding"); enc != "base64" {
+			t.Errorf("Content-Transfer-Encoding lampiran = %q, ingin base64", enc)
+		}
+		namaBerkas = part.FileName()
+		if lampiran, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, part)); err != nil {
+			t.Fatalf("lampiran bukan base64 yang valid: %v", err)
+		}
+	}
+
+	if namaBerkas != "uji-email_2024-05-01_2024-05-31.csv" {
+		t.Errorf("nama lampiran = %q", namaBerkas)
+	}
+	if int64(len(lampiran)) != riwayat.UkuranBerkas {
+		t.Errorf("ukuran lampiran = %d, riwayat mencatat %d", len(lampiran), riwayat.UkuranBerkas)
+	}
+	for _, isi := range []string{"no_rawat", "2024/05/01/000001", "2024/05/02/000007", "75000"} {
+		if !strings.Contains(string(lampiran), isi) {
+			t.Errorf("lampiran CSV tidak memuat %q:\n%s", isi, lampiran)
+		}
+	}
+}
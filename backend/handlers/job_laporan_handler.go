@@ -0,0 +1,574 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobLaporanRequest menyimpan data permintaan job laporan
+type JobLaporanRequest struct {
+	Laporan string `json:"laporan"`
+	Format  string `json:"format"`
+	// Bagian adalah array data yang diekspor ke CSV, default array data utama laporan
+	Bagian string `json:"bagian"`
+	// Parameter laporan, termasuk periode atau tanggal_awal/tanggal_akhir
+	Parameter map[string]string `json:"parameter"`
+}
+
+// JobLaporanResponse adalah job laporan beserta URL unduhan jika hasilnya sudah tersedia
+type JobLaporanResponse struct {
+	models.JobLaporan
+	URLUnduh string `json:"url_unduh,omitempty"`
+}
+
+var (
+	// antrianJob berisi ID job yang menunggu worker; nil berarti worker belum dijalankan
+	antrianJob chan string
+
+	// jobBerjalan berisi fungsi pembatalan job yang sedang dikerjakan worker
+	jobBerjalan   = map[string]context.CancelFunc{}
+	jobBerjalanMu sync.Mutex
+)
+
+// direktoriJobLaporan adalah lokasi berkas hasil job, diatur lewat JOB_LAPORAN_DIR
+func direktoriJobLaporan() string {
+	return getEnv("JOB_LAPORAN_DIR", filepath.Join("data", "job-laporan"))
+}
+
+// pathBerkasJob mengembalikan lokasi berkas hasil satu job
+func pathBerkasJob(job models.JobLaporan) string {
+	return filepath.Join(direktoriJobLaporan(), job.ID+"."+job.Format)
+}
+
+// envInt membaca bilangan bulat positif dari variabel lingkungan
+func envInt(key string, nilaiDefault int) int {
+	if n, err := strconv.Atoi(getEnv(key, "")); err == nil && n > 0 {
+		return n
+	}
+	return nilaiDefault
+}
+
+// JalankanWorkerJobLaporan menjalankan worker job laporan di background. Jumlah worker diatur
+// lewat JOB_LAPORAN_WORKER (default 2) dan kapasitas antrian lewat JOB_LAPORAN_ANTRIAN
+// (default 100). Job yang belum selesai saat server berhenti diantrikan ulang, dan job yang
+// melewati masa retensi dibersihkan setiap JOB_LAPORAN_INTERVAL_PEMBERSIHAN (default 1h).
+func JalankanWorkerJobLaporan() {
+	db := utils.GetDB()
+	if db == nil {
+		log.Println("Database SIAK tidak tersedia, job laporan dinonaktifkan")
+		return
+	}
+
+	if err := os.MkdirAll(direktoriJobLaporan(), 0o750); err != nil {
+		log.Printf("Gagal membuat direktori job laporan %s: %v", direktoriJobLaporan(), err)
+		return
+	}
+
+	// Job yang terputus karena server berhenti dijalankan ulang dari awal
+	db.Model(&models.JobLaporan{}).Where("status = ?", models.JobBerjalan).Updates(map[string]interface{}{
+		"status":  models.JobAntri,
+		"progres": 0,
+		"tahap":   "Diantrikan ulang setelah server dimulai ulang",
+	})
+
+	var tertunda []models.JobLaporan
+	if err := db.Where("status = ?", models.JobAntri).Order("dibuat_pada").Find(&tertunda).Error; err != nil {
+		log.Printf("Gagal membaca job laporan yang tertunda: %v", err)
+	}
+
+	jumlahWorker := envInt("JOB_LAPORAN_WORKER", 2)
+	antrianJob = make(chan string, envInt("JOB_LAPORAN_ANTRIAN", 100))
+	for i := 0; i < jumlahWorker; i++ {
+		go func() {
+			for id := range antrianJob {
+				prosesJobLaporan(id)
+			}
+		}()
+	}
+
+	go func() {
+		for _, job := range tertunda {
+			antrianJob <- job.ID
+		}
+	}()
+
+	interval := durasiEnv("JOB_LAPORAN_INTERVAL_PEMBERSIHAN", time.Hour)
+	go func() {
+		for {
+			bersihkanJobKedaluwarsa()
+			time.Sleep(interval)
+		}
+	}()
+
+	log.Printf("Job laporan aktif: %d worker, %d job tertunda diantrikan ulang", jumlahWorker, len(tertunda))
+}
+
+// prosesJobLaporan menjalankan satu job dan mencatat hasilnya. Status akhir hanya ditulis jika
+// job masih berjalan, sehingga job yang dibatalkan di tengah jalan tetap berstatus dibatalkan.
+func prosesJobLaporan(id string) {
+	db := utils.GetDB()
+
+	var job models.JobLaporan
+	if err := db.First(&job, "id = ?", id).Error; err != nil {
+		log.Printf("Job laporan %s tidak dapat dibaca: %v", id, err)
+		return
+	}
+
+	batasWaktu := durasiEnv("JOB_LAPORAN_TIMEOUT", 30*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), batasWaktu)
+	defer cancel()
+
+	jobBerjalanMu.Lock()
+	jobBerjalan[id] = cancel
+	jobBerjalanMu.Unlock()
+	defer func() {
+		jobBerjalanMu.Lock()
+		delete(jobBerjalan, id)
+		jobBerjalanMu.Unlock()
+	}()
+
+	mulai := time.Now()
+	hasil := db.Model(&models.JobLaporan{}).Where("id = ? AND status = ?", id, models.JobAntri).Updates(map[string]interface{}{
+		"status":       models.JobBerjalan,
+		"progres":      10,
+		"tahap":        "Menjalankan query laporan",
+		"dimulai_pada": mulai,
+	})
+	if hasil.Error != nil || hasil.RowsAffected == 0 {
+		// Job sudah dibatalkan atau dihapus sebelum sempat dijalankan
+		return
+	}
+
+	progres := func(persen int, tahap string) {
+		db.Model(&models.JobLaporan{}).Where("id = ? AND status = ?", id, models.JobBerjalan).
+			Updates(map[string]interface{}{"progres": persen, "tahap": tahap})
+	}
+
+	namaBerkas, ukuran, err := jalankanJobLaporan(ctx, job, progres)
+
+	selesai := time.Now()
+	update := map[string]interface{}{
+		"selesai_pada":     selesai,
+		"kedaluwarsa_pada": selesai.Add(durasiEnv("JOB_LAPORAN_RETENSI", 7*24*time.Hour)),
+	}
+	switch {
+	case err == nil:
+		update["status"] = models.JobSelesai
+		update["progres"] = 100
+		update["tahap"] = "Selesai"
+		update["nama_berkas"] = namaBerkas
+		update["ukuran_berkas"] = ukuran
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		update["status"] = models.JobGagal
+		update["tahap"] = "Gagal"
+		update["pesan"] = fmt.Sprintf("Job melebihi batas waktu %s", batasWaktu)
+	default:
+		update["status"] = models.JobGagal
+		update["tahap"] = "Gagal"
+		update["pesan"] = err.Error()
+	}
+
+	hasil = db.Model(&models.JobLaporan{}).Where("id = ? AND status = ?", id, models.JobBerjalan).Updates(update)
+	if hasil.RowsAffected == 0 {
+		// Job dibatalkan saat berkas sedang ditulis
+		os.Remove(pathBerkasJob(job))
+		return
+	}
+
+	if err != nil {
+		log.Printf("Job laporan %s (%s, %s) gagal setelah %s: %v", id, job.Laporan, job.Username, selesai.Sub(mulai).Round(time.Second), err)
+		return
+	}
+	log.Printf("Job laporan %s (%s, %s) selesai dalam %s", id, job.Laporan, job.Username, selesai.Sub(mulai).Round(time.Second))
+}
+
+// jalankanJobLaporan menjalankan laporan di dalam proses lalu menulis hasilnya ke berkas
+// sementara yang baru dipindahkan ke lokasi akhir setelah lengkap
+func jalankanJobLaporan(ctx context.Context, job models.JobLaporan, progres func(int, string)) (string, int64, error) {
+	laporan, ok := cariLaporan(job.Laporan)
+	if !ok {
+		return "", 0, fmt.Errorf("laporan tidak lagi terdaftar: %s", job.Laporan)
+	}
+	params, err := url.ParseQuery(job.Parameter)
+	if err != nil {
+		return "", 0, fmt.Errorf("parameter job tidak valid: %w", err)
+	}
+
+	dokumen, err := eksporLaporan(ctx, laporan, params)
+	if err != nil {
+		return "", 0, err
+	}
+
+	progres(60, "Menulis berkas "+strings.ToUpper(job.Format))
+	path := pathBerkasJob(job)
+	sementara := path + ".tmp"
+	f, err := os.Create(sementara)
+	if err != nil {
+		return "", 0, fmt.Errorf("gagal membuat berkas: %w", err)
+	}
+	if err := tulisBerkasEkspor(f, job.Format, job.Bagian, dokumen); err != nil {
+		f.Close()
+		os.Remove(sementara)
+		return "", 0, fmt.Errorf("gagal menulis berkas: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(sementara)
+		return "", 0, fmt.Errorf("gagal menulis berkas: %w", err)
+	}
+	if err := os.Rename(sementara, path); err != nil {
+		os.Remove(sementara)
+		return "", 0, fmt.Errorf("gagal menyimpan berkas: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	namaBerkas := fmt.Sprintf("%s_%s_%s.%s", laporan.Nama, params.Get("tanggal_awal"), params.Get("tanggal_akhir"), job.Format)
+	return namaBerkas, info.Size(), nil
+}
+
+// bersihkanJobKedaluwarsa menghapus job yang sudah melewati masa retensi beserta berkasnya
+func bersihkanJobKedaluwarsa() {
+	db := utils.GetDB()
+
+	var kedaluwarsa []models.JobLaporan
+	if err := db.Where("kedaluwarsa_pada < ?", time.Now()).Find(&kedaluwarsa).Error; err != nil {
+		log.Printf("Gagal membaca job laporan kedaluwarsa: %v", err)
+		return
+	}
+
+	for _, job := range kedaluwarsa {
+		if err := os.Remove(pathBerkasJob(job)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Gagal menghapus berkas job laporan %s: %v", job.ID, err)
+			continue
+		}
+		db.Delete(&job)
+	}
+	if len(kedaluwarsa) > 0 {
+		log.Printf("%d job laporan kedaluwarsa dibersihkan", len(kedaluwarsa))
+	}
+}
+
+// validasi memeriksa isi permintaan job dan mengubahnya menjadi parameter laporan. Periode
+// bernama diubah menjadi tanggal_awal dan tanggal_akhir saat job dibuat, sehingga job yang
+// baru dijalankan setelah pergantian bulan tetap memakai rentang yang dimaksud.
+func (req *JobLaporanRequest) validasi() (laporanTerdaftar, url.Values, error) {
+	laporan, err := validasiEkspor(req.Laporan, &req.Format, &req.Bagian)
+	if err != nil {
+		return laporan, nil, err
+	}
+
+	params := parameterLaporan(req.Parameter)
+	if _, err := tetapkanRentang(params); err != nil {
+		return laporan, nil, err
+	}
+	return laporan, params, nil
+}
+
+// idAcak membuat ID acak yang sulit ditebak, dipakai untuk ID job (URL unduhan) dan ID event webhook
+func idAcak() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// responseJob menambahkan URL unduhan pada job yang sudah selesai
+func responseJob(job models.JobLaporan) JobLaporanResponse {
+	response := JobLaporanResponse{JobLaporan: job}
+	if job.Status == models.JobSelesai {
+		response.URLUnduh = "/api/job-laporan/" + job.ID + "/unduh"
+	}
+	return response
+}
+
+// isAdmin memeriksa apakah pengguna pada context permintaan adalah admin
+func isAdmin(r *http.Request) bool {
+	role, _ := r.Context().Value("userRole").(string)
+	return strings.ToLower(role) == "admin"
+}
+
+// getJobDariURL mengambil job dari path /api/job-laporan/{id}[/unduh] yang boleh diakses
+// pengguna (pemilik job atau admin). Jika tidak ditemukan, response error sudah ditulis.
+func getJobDariURL(w http.ResponseWriter, r *http.Request) (*models.JobLaporan, bool) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/job-laporan/"), "/unduh")
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+		utils.WriteFieldError(w, "id", "ID job tidak valid")
+		return nil, false
+	}
+
+	var job models.JobLaporan
+	if err := utils.GetDB().First(&job, "id = ?", id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Job tidak ditemukan")
+		return nil, false
+	}
+
+	// Job milik pengguna lain diperlakukan seperti tidak ada
+	userID, _ := r.Context().Value("userID").(uint)
+	if job.UserID != userID && !isAdmin(r) {
+		utils.WriteError(w, http.StatusNotFound, "Job tidak ditemukan")
+		return nil, false
+	}
+	return &job, true
+}
+
+// CreateJobLaporanHandler menangani permintaan membuat job laporan. Job langsung diantrikan dan
+// response 201 berisi ID job untuk memantau progres. Jumlah job aktif (antri atau berjalan)
+// per pengguna dibatasi lewat JOB_LAPORAN_MAKS_AKTIF, default 3.
+func CreateJobLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	if antrianJob == nil {
+		utils.WriteError(w, http.StatusServiceUnavailable, "Job laporan tidak aktif")
+		return
+	}
+
+	// Decode permintaan JSON
+	var req JobLaporanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	laporan, params, err := req.validasi()
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	userID, _ := r.Context().Value("userID").(uint)
+	username, _ := r.Context().Value("username").(string)
+	db := utils.GetDB()
+
+	var jumlahAktif int64
+	db.Model(&models.JobLaporan{}).
+		Where("user_id = ? AND status IN ?", userID, []string{models.JobAntri, models.JobBerjalan}).
+		Count(&jumlahAktif)
+	if maks := envInt("JOB_LAPORAN_MAKS_AKTIF", 3); jumlahAktif >= int64(maks) {
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Sprintf("Maksimal %d job aktif per pengguna, tunggu job sebelumnya selesai", maks))
+		return
+	}
+
+	id, err := idAcak()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat ID job: "+err.Error())
+		return
+	}
+
+	job := models.JobLaporan{
+		ID:         id,
+		UserID:     userID,
+		Username:   username,
+		Laporan:    laporan.Nama,
+		Parameter:  params.Encode(),
+		Format:     req.Format,
+		Bagian:     req.Bagian,
+		Status:     models.JobAntri,
+		Tahap:      "Menunggu giliran",
+		DibuatPada: time.Now(),
+	}
+	if err := db.Create(&job).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menyimpan job: "+err.Error())
+		return
+	}
+
+	select {
+	case antrianJob <- job.ID:
+	default:
+		db.Delete(&job)
+		utils.WriteError(w, http.StatusServiceUnavailable, "Antrian job laporan penuh, coba lagi nanti")
+		return
+	}
+
+	log.Printf("Job laporan %s (%s %s, %s) dibuat oleh %s", job.ID, job.Laporan, job.Parameter, job.Format, username)
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responseJob(job))
+}
+
+// GetJobLaporanHandler menangani permintaan daftar job laporan milik pengguna, terbaru lebih
+// dulu. Parameter status opsional menyaring job; admin dapat melihat job seluruh pengguna
+// dengan semua=true.
+func GetJobLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	status, err := getParamEnum(r, "status", "", models.JobAntri, models.JobBerjalan, models.JobSelesai, models.JobGagal, models.JobDibatalkan)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	semua, err := getParamBool(r, "semua")
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	if semua && !isAdmin(r) {
+		utils.WriteError(w, http.StatusForbidden, "Akses ditolak: Memerlukan hak admin")
+		return
+	}
+
+	query := utils.GetDB().Order("dibuat_pada desc").Limit(100)
+	if !semua {
+		userID, _ := r.Context().Value("userID").(uint)
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var jobs []models.JobLaporan
+	if err := query.Find(&jobs).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil data job: "+err.Error())
+		return
+	}
+
+	data := make([]JobLaporanResponse, len(jobs))
+	for i, job := range jobs {
+		data[i] = responseJob(job)
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"status":     "success",
+		"total_data": len(data),
+		"data":       data,
+	})
+}
+
+// GetJobLaporanDetailHandler menangani permintaan status dan progres satu job laporan
+func GetJobLaporanDetailHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := getJobDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(responseJob(*job))
+}
+
+// UnduhJobLaporanHandler menangani permintaan mengunduh berkas hasil job yang sudah selesai
+func UnduhJobLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := getJobDariURL(w, r)
+	if !ok {
+		return
+	}
+	if job.Status != models.JobSelesai {
+		utils.WriteError(w, http.StatusConflict, fmt.Sprintf("Job belum selesai (status: %s)", job.Status))
+		return
+	}
+
+	f, err := os.Open(pathBerkasJob(*job))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Berkas hasil job tidak ditemukan")
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", contentTypeEkspor[job.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.NamaBerkas))
+
+	var diubah time.Time
+	if job.SelesaiPada != nil {
+		diubah = *job.SelesaiPada
+	}
+	http.ServeContent(w, r, job.NamaBerkas, diubah, f)
+}
+
+// HapusJobLaporanHandler menangani permintaan membatalkan job yang belum selesai, atau
+// menghapus job yang sudah selesai beserta berkasnya
+func HapusJobLaporanHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode DELETE
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	job, ok := getJobDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	db := utils.GetDB()
+	username, _ := r.Context().Value("username").(string)
+
+	if job.Status == models.JobAntri || job.Status == models.JobBerjalan {
+		sekarang := time.Now()
+		hasil := db.Model(&models.JobLaporan{}).
+			Where("id = ? AND status IN ?", job.ID, []string{models.JobAntri, models.JobBerjalan}).
+			Updates(map[string]interface{}{
+				"status":           models.JobDibatalkan,
+				"tahap":            "Dibatalkan oleh " + username,
+				"selesai_pada":     sekarang,
+				"kedaluwarsa_pada": sekarang.Add(durasiEnv("JOB_LAPORAN_RETENSI", 7*24*time.Hour)),
+			})
+		if hasil.Error != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "Gagal membatalkan job: "+hasil.Error.Error())
+			return
+		}
+
+		if hasil.RowsAffected > 0 {
+			// Hentikan query laporan jika job sedang dikerjakan worker
+			jobBerjalanMu.Lock()
+			if cancel, ada := jobBerjalan[job.ID]; ada {
+				cancel()
+			}
+			jobBerjalanMu.Unlock()
+
+			log.Printf("Job laporan %s dibatalkan oleh %s", job.ID, username)
+
+			// Kirim respons
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": "Job berhasil dibatalkan",
+			})
+			return
+		}
+		// Job baru saja selesai, lanjutkan dengan menghapusnya
+	}
+
+	if err := os.Remove(pathBerkasJob(*job)); err != nil && !os.IsNotExist(err) {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghapus berkas job: "+err.Error())
+		return
+	}
+	if err := db.Delete(&models.JobLaporan{}, "id = ?", job.ID).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghapus job: "+err.Error())
+		return
+	}
+
+	log.Printf("Job laporan %s dihapus oleh %s", job.ID, username)
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Job berhasil dihapus",
+	})
+}
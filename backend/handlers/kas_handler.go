@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ArusKasHandler menangani permintaan laporan arus kas harian.
+//
+// Kas masuk berasal dari pembayaran nota rawat jalan dan rawat inap, deposit (uang muka),
+// penjualan bebas obat dan pemasukan_lain. Kas keluar berasal dari pengeluaran_harian dan
+// pembayaran hutang obat (bayar_pemesanan). Khanza tidak menyimpan saldo kas, sehingga saldo awal
+// diambil dari parameter saldo_awal (misalnya saldo fisik laci kas), atau diturunkan dari saldo
+// akhir snapshot kas periode tutup terakhir sebelum tanggal awal ditambah mutasi di antaranya.
+// Tanpa keduanya saldo tidak diketahui dan seluruh nilai saldo bernilai null.
+func ArusKasHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+	nilaiSaldoAwal, err := getParamFloat(r, "saldo_awal", 0)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	var saldoAwal *float64
+	sumberSaldoAwal := "tidak_tersedia"
+	if r.URL.Query().Get("saldo_awal") != "" {
+		saldoAwal = &nilaiSaldoAwal
+		sumberSaldoAwal = "parameter"
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	dalamPeriode := func(kolom string) string {
+		return kolom + " >= @awal AND " + kolom + " < @akhir"
+	}
+	sejakTutupBuku := func(kolom string) string {
+		return kolom + " >= @tutup_buku AND " + kolom + " < @awal"
+	}
+
+	query := `
+		SELECT tanggal, arus, sumber, akun, petugas, COUNT(DISTINCT kunci) AS jumlah_transaksi, SUM(nominal) AS nominal
+		FROM (` + sumberArusKas(dalamPeriode) + `) AS kas
+		GROUP BY tanggal, arus, sumber, akun, petugas
+		ORDER BY tanggal, arus DESC, sumber, akun
+	`
+
+	var result []models.ArusKas
+	err = db.Raw(query, rentang.Parameter()).Scan(&result).Error
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query", err)
+		return
+	}
+
+	// Jika tidak ada hasil, kembalikan array kosong
+	if result == nil {
+		result = []models.ArusKas{}
+	}
+
+	// Tanpa parameter saldo_awal, saldo awal adalah saldo akhir snapshot kas periode tutup terakhir
+	// ditambah mutasi sejak periode tersebut sampai sebelum tanggal awal
+	tanggalTutupBuku := ""
+	if saldoAwal == nil {
+		saldoTutup, sejak, err := saldoKasTutupBuku(rentang)
+		if err != nil {
+			tulisErrorQuery(w, r, "Gagal membaca saldo kas periode tutup", err)
+			return
+		}
+		if saldoTutup != nil {
+			parameter := rentang.Parameter()
+			parameter["tutup_buku"] = sejak
+			var saldoSebelum struct {
+				Saldo float64
+			}
+			querySaldo := `
+				SELECT COALESCE(SUM(CASE WHEN arus = 'Masuk' THEN nominal ELSE -nominal END), 0) AS saldo
+				FROM (` + sumberArusKas(sejakTutupBuku) + `) AS kas
+			`
+			if err := db.Raw(querySaldo, parameter).Scan(&saldoSebelum).Error; err != nil {
+				tulisErrorQuery(w, r, "Gagal menghitung saldo awal kas", err)
+				return
+			}
+			nilai := *saldoTutup + saldoSebelum.Saldo
+			saldoAwal = &nilai
+			sumberSaldoAwal = "tutup_buku"
+			tanggalTutupBuku = sejak
+		}
+	}
+
+	// Susun saldo harian serta rekap per akun bayar dan per petugas
+	harianPerTanggal := map[string]*models.KasHarian{}
+	rekapAkun := map[string]*models.RekapKas{}
+	rekapPetugas := map[string]*models.RekapKas{}
+	var totalMasuk, totalKeluar float64
+
+	tambahRekap := func(rekap map[string]*models.RekapKas, nama string, item models.ArusKas) {
+		entry, ok := rekap[nama]
+		if !ok {
+			entry = &models.RekapKas{Nama: nama}
+			rekap[nama] = entry
+		}
+		if item.Arus == "Masuk" {
+			entry.Masuk += item.Nominal
+		} else {
+			entry.Keluar += item.Nominal
+		}
+		entry.Netto = entry.Masuk - entry.Keluar
+	}
+
+	for _, item := range result {
+		harian, ok := harianPerTanggal[item.Tanggal]
+		if !ok {
+			harian = &models.KasHarian{Tanggal: item.Tanggal}
+			harianPerTanggal[item.Tanggal] = harian
+		}
+		if item.Arus == "Masuk" {
+			harian.Masuk += item.Nominal
+			totalMasuk += item.Nominal
+		} else {
+			harian.Keluar += item.Nominal
+			totalKeluar += item.Nominal
+		}
+
+		tambahRekap(rekapAkun, item.Akun, item)
+		tambahRekap(rekapPetugas, item.Petugas, item)
+	}
+
+	harian := []models.KasHarian{}
+	for _, item := range harianPerTanggal {
+		harian = append(harian, *item)
+	}
+	sort.Slice(harian, func(i, j int) bool {
+		return harian[i].Tanggal < harian[j].Tanggal
+	})
+
+	var saldoAkhir *float64
+	if saldoAwal != nil {
+		saldo := *saldoAwal
+		for i := range harian {
+			awal := saldo
+			saldo += harian[i].Masuk - harian[i].Keluar
+			akhir := saldo
+			harian[i].SaldoAwal = &awal
+			harian[i].SaldoAkhir = &akhir
+		}
+		saldoAkhir = &saldo
+	}
+
+	filter := map[string]string{
+		"tanggal_awal":  tanggalAwal,
+		"tanggal_akhir": tanggalAkhir,
+		"periode":       rentang.Periode,
+	}
+	if tanggalTutupBuku != "" {
+		filter["saldo_tutup_buku_sejak"] = tanggalTutupBuku
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":            "success",
+		"message":           "Data arus kas berhasil diambil dari database",
+		"filter":            filter,
+		"saldo_awal":        saldoAwal,
+		"sumber_saldo_awal": sumberSaldoAwal,
+		"total_masuk":       totalMasuk,
+		"total_keluar":      totalKeluar,
+		"saldo_akhir":       saldoAkhir,
+		"harian":            harian,
+		"rekap_akun":        urutkanRekapKas(rekapAkun),
+		"rekap_petugas":     urutkanRekapKas(rekapPetugas),
+		"keterangan":        "Saldo awal diambil dari parameter saldo_awal atau saldo akhir snapshot kas periode tutup terakhir ditambah mutasi sesudahnya; tanpa keduanya saldo bernilai null. Saldo akhir = saldo awal + kas masuk - kas keluar; jumlah transaksi dihitung per nota",
+		"data":              result,
+	}
+
+	writeJSON(w, response)
+}
+
+// saldoKasTutupBuku mengambil saldo akhir snapshot kas dari periode tutup terakhir yang berakhir
+// sebelum tanggal awal rentang, beserta tanggal sehari setelah periode tersebut. Saldo nil jika
+// belum ada periode tutup atau snapshot kasnya tidak memiliki saldo.
+func saldoKasTutupBuku(rentang rentangTanggal) (*float64, string, error) {
+	db := utils.GetDB()
+	if db == nil {
+		return nil, "", nil
+	}
+
+	var periode models.PeriodeTutup
+	err := db.Where("tanggal_akhir < ?", rentang.Awal).Order("tanggal_akhir desc").First(&periode).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var snapshot models.SnapshotLaporan
+	parameter := normalisasiParameter(url.Values{
+		"tanggal_awal":  {periode.TanggalAwal},
+		"tanggal_akhir": {periode.TanggalAkhir},
+	})
+	err = db.Where("periode_id = ? AND laporan = ? AND parameter = ?", periode.ID, "kas", parameter).First(&snapshot).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var isi struct {
+		SaldoAkhir *float64 `json:"saldo_akhir"`
+	}
+	if err := json.Unmarshal([]byte(snapshot.Data), &isi); err != nil {
+		return nil, "", fmt.Errorf("snapshot kas periode %s s.d. %s tidak dapat dibaca: %w",
+			periode.TanggalAwal, periode.TanggalAkhir, err)
+	}
+	if isi.SaldoAkhir == nil {
+		return nil, "", nil
+	}
+
+	akhir, err := time.Parse("2006-01-02", periode.TanggalAkhir)
+	if err != nil {
+		return nil, "", err
+	}
+	return isi.SaldoAkhir, akhir.AddDate(0, 0, 1).Format("2006-01-02"), nil
+}
+
+// urutkanRekapKas mengubah map rekap kas menjadi slice yang diurutkan berdasarkan nama
+func urutkanRekapKas(rekap map[string]*models.RekapKas) []models.RekapKas {
+	result := []models.RekapKas{}
+	for _, item := range rekap {
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Nama < result[j].Nama
+	})
+	return result
+}
+
+// sumberArusKas menyusun union seluruh transaksi kas, satu baris per detail pembayaran dengan
+// kunci nota/transaksinya. Kondisi tanggal diberikan pemanggil sehingga union yang sama dipakai
+// untuk mutasi periode maupun saldo sebelum periode. Petugas kasir untuk nota pasien diambil dari
+// tagihan_sadewa yang dicatat saat billing disimpan.
+func sumberArusKas(kondisi func(kolom string) string) string {
+	return `
+			SELECT
+				DATE_FORMAT(nota_jalan.tanggal, '%Y-%m-%d') AS tanggal,
+				nota_jalan.no_nota AS kunci,
+				'Masuk' AS arus,
+				'Rawat Jalan' AS sumber,
+				detail_nota_jalan.nama_bayar AS akun,
+				COALESCE(petugas.nama, tagihan_sadewa.petugas, '-') AS petugas,
+				detail_nota_jalan.besar_bayar AS nominal
+			FROM nota_jalan
+			INNER JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = nota_jalan.no_rawat
+			LEFT JOIN tagihan_sadewa ON tagihan_sadewa.no_nota = nota_jalan.no_nota
+			LEFT JOIN petugas ON petugas.nip = tagihan_sadewa.petugas
+			WHERE ` + kondisi("nota_jalan.tanggal") + `
+
+			UNION ALL
+
+			SELECT
+				DATE_FORMAT(nota_inap.tanggal, '%Y-%m-%d'),
+				nota_inap.no_nota,
+				'Masuk',
+				'Rawat Inap',
+				detail_nota_inap.nama_bayar,
+				COALESCE(petugas.nama, tagihan_sadewa.petugas, '-'),
+				detail_nota_inap.besar_bayar
+			FROM nota_inap
+			INNER JOIN detail_nota_inap ON detail_nota_inap.no_rawat = nota_inap.no_rawat
+			LEFT JOIN tagihan_sadewa ON tagihan_sadewa.no_nota = nota_inap.no_nota
+			LEFT JOIN petugas ON petugas.nip = tagihan_sadewa.petugas
+			WHERE ` + kondisi("nota_inap.tanggal") + `
+
+			UNION ALL
+
+			SELECT
+				DATE_FORMAT(deposit.tgl_deposit, '%Y-%m-%d'),
+				CONCAT(deposit.no_rawat, ' ', deposit.tgl_deposit),
+				'Masuk',
+				'Deposit',
+				deposit.nama_bayar,
+				COALESCE(petugas.nama, deposit.nip, '-'),
+				deposit.besar_deposit
+			FROM deposit
+			LEFT JOIN petugas ON petugas.nip = deposit.nip
+			WHERE ` + kondisi("deposit.tgl_deposit") + `
+
+			UNION ALL
+
+			SELECT
+				DATE_FORMAT(penjualan.tgl_jual, '%Y-%m-%d'),
+				penjualan.nota_jual,
+				'Masuk',
+				'Penjualan Obat',
+				penjualan.nama_bayar,
+				COALESCE(petugas.nama, penjualan.nip, '-'),
+				detailjual.total
+			FROM penjualan
+			INNER JOIN detailjual ON detailjual.nota_jual = penjualan.nota_jual
+			LEFT JOIN petugas ON petugas.nip = penjualan.nip
+			WHERE penjualan.status = 'Sudah Dibayar'
+				AND ` + kondisi("penjualan.tgl_jual") + `
+
+			UNION ALL
+
+			SELECT
+				DATE_FORMAT(pemasukan_lain.tanggal, '%Y-%m-%d'),
+				pemasukan_lain.no_masuk,
+				'Masuk',
+				'Pemasukan Lain',
+				COALESCE(kategori_pemasukan_lain.nama_kategori, pemasukan_lain.kode_kategori),
+				COALESCE(petugas.nama, pemasukan_lain.nip, '-'),
+				pemasukan_lain.biaya
+			FROM pemasukan_lain
+			LEFT JOIN kategori_pemasukan_lain ON kategori_pemasukan_lain.kode_kategori = pemasukan_lain.kode_kategori
+			LEFT JOIN petugas ON petugas.nip = pemasukan_lain.nip
+			WHERE ` + kondisi("pemasukan_lain.tanggal") + `
+
+			UNION ALL
+
+			SELECT
+				DATE_FORMAT(pengeluaran_harian.tanggal, '%Y-%m-%d'),
+				pengeluaran_harian.no_keluar,
+				'Keluar',
+				'Pengeluaran Harian',
+				COALESCE(kategori_pengeluaran_harian.nama_kategori, pengeluaran_harian.kode_kategori),
+				COALESCE(petugas.nama, pengeluaran_harian.nip, '-'),
+				pengeluaran_harian.biaya
+			FROM pengeluaran_harian
+			LEFT JOIN kategori_pengeluaran_harian ON kategori_pengeluaran_harian.kode_kategori = pengeluaran_harian.kode_kategori
+			LEFT JOIN petugas ON petugas.nip = pengeluaran_harian.nip
+			WHERE ` + kondisi("pengeluaran_harian.tanggal") + `
+
+			UNION ALL
+
+			SELECT
+				DATE_FORMAT(bayar_pemesanan.tgl_bayar, '%Y-%m-%d'),
+				CONCAT(bayar_pemesanan.no_faktur, ' ', bayar_pemesanan.tgl_bayar),
+				'Keluar',
+				'Bayar Hutang Obat',
+				bayar_pemesanan.nama_bayar,
+				COALESCE(petugas.nama, bayar_pemesanan.nip, '-'),
+				bayar_pemesanan.besar_bayar
+			FROM bayar_pemesanan
+			LEFT JOIN petugas ON petugas.nip = bayar_pemesanan.nip
+			WHERE ` + kondisi("bayar_pemesanan.tgl_bayar") + `
+	`
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// kelompokUmur adalah pembagian kelompok umur pasien pada tanggal registrasi. Batas adalah umur
+// (tahun) paling tinggi yang belum masuk kelompok, 0 untuk kelompok terakhir.
+var kelompokUmur = []struct {
+	Kode  string
+	Nama  string
+	Batas int
+}{
+	{"<1", "Di bawah 1 tahun", 1},
+	{"1-4", "1-4 tahun", 5},
+	{"5-14", "5-14 tahun", 15},
+	{"15-24", "15-24 tahun", 25},
+	{"25-44", "25-44 tahun", 45},
+	{"45-64", "45-64 tahun", 65},
+	{"65+", "65 tahun ke atas", 0},
+}
+
+// kunjunganTidakDiketahui adalah kode kelompok untuk data pasien yang kosong (tanggal lahir,
+// jenis kelamin atau wilayah)
+const kunjunganTidakDiketahui = "-"
+
+// LaporanKunjunganHandler menangani permintaan laporan kunjungan pasien dari reg_periksa (registrasi
+// yang tidak batal, rawat jalan maupun rawat inap) berdasarkan tanggal registrasi. Kunjungan
+// direkap per status daftar (pasien baru/lama), poli, dokter, penjab, kabupaten, kecamatan,
+// kelompok umur dan jenis kelamin. Parameter bandingkan (periode_sebelumnya atau tahun_lalu)
+// menambahkan jumlah kunjungan periode pembanding pada setiap rekap.
+func LaporanKunjunganHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	pembanding, err := getRentangPembanding(r, rentang)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	result, err := hitungKunjungan(db, rentang)
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query kunjungan", err)
+		return
+	}
+
+	var resultPembanding []models.KunjunganPasien
+	if pembanding != nil {
+		resultPembanding, err = hitungKunjungan(db, *pembanding)
+		if err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query kunjungan periode pembanding", err)
+			return
+		}
+	}
+
+	filter := map[string]string{
+		"tanggal_awal":  rentang.Awal,
+		"tanggal_akhir": rentang.Akhir,
+		"periode":       rentang.Periode,
+	}
+	totalKunjungan, pasienBaru, pasienLama := totalKunjunganPasien(result)
+
+	response := map[string]interface{}{
+		"status":          "success",
+		"message":         "Data kunjungan pasien berhasil diambil dari database",
+		"filter":          filter,
+		"total_kunjungan": totalKunjungan,
+		"pasien_baru":     pasienBaru,
+		"pasien_lama":     pasienLama,
+	}
+	filterPembanding(filter, r, pembanding)
+	if pembanding != nil {
+		totalPembanding, baruPembanding, lamaPembanding := totalKunjunganPasien(resultPembanding)
+		response["total_kunjungan_pembanding"] = totalPembanding
+		response["pasien_baru_pembanding"] = baruPembanding
+		response["pasien_lama_pembanding"] = lamaPembanding
+	}
+
+	rekap := func(kunci func(models.KunjunganPasien) (string, string), urutan []string) []models.RekapKunjungan {
+		return rekapKunjunganPer(result, resultPembanding, pembanding != nil, kunci, urutan)
+	}
+
+	urutanUmur := make([]string, 0, len(kelompokUmur)+1)
+	namaUmur := map[string]string{kunjunganTidakDiketahui: "Tidak diketahui"}
+	for _, k := range kelompokUmur {
+		urutanUmur = append(urutanUmur, k.Kode)
+		namaUmur[k.Kode] = k.Nama
+	}
+	urutanUmur = append(urutanUmur, kunjunganTidakDiketahui)
+
+	namaStatusDaftar := map[string]string{"Baru": "Pasien baru", "Lama": "Pasien lama"}
+	namaJk := map[string]string{"L": "Laki-laki", "P": "Perempuan"}
+
+	response["rekap_status_daftar"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		return item.StatusDaftar, namaAtauKode(namaStatusDaftar, item.StatusDaftar)
+	}, []string{"Baru", "Lama", kunjunganTidakDiketahui})
+	response["rekap_poli"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		return item.KdPoli, item.NmPoli
+	}, nil)
+	response["rekap_dokter"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		return item.KdDokter, item.NmDokter
+	}, nil)
+	response["rekap_penjab"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		return item.KdPj, item.PngJawab
+	}, nil)
+	response["rekap_kabupaten"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		return item.KdKab, item.NmKab
+	}, nil)
+	// Nama kecamatan bisa sama di kabupaten berbeda sehingga nama kabupaten ikut ditampilkan
+	response["rekap_kecamatan"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		if item.KdKec == kunjunganTidakDiketahui {
+			return item.KdKec, item.NmKec
+		}
+		return item.KdKec, fmt.Sprintf("%s, %s", item.NmKec, item.NmKab)
+	}, nil)
+	response["rekap_kelompok_umur"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		return item.KelompokUmur, namaUmur[item.KelompokUmur]
+	}, urutanUmur)
+	response["rekap_jenis_kelamin"] = rekap(func(item models.KunjunganPasien) (string, string) {
+		return item.JenisKelamin, namaAtauKode(namaJk, item.JenisKelamin)
+	}, []string{"L", "P", kunjunganTidakDiketahui})
+	response["keterangan"] = "Kunjungan dari reg_periksa yang tidak batal berdasarkan tanggal registrasi; umur dihitung pada tanggal registrasi"
+
+	writeJSON(w, response)
+}
+
+// hitungKunjungan menghitung jumlah kunjungan reg_periksa yang tidak batal dalam rentang per
+// kombinasi status daftar, poli, dokter, penjab, wilayah, kelompok umur dan jenis kelamin
+func hitungKunjungan(db *gorm.DB, rentang rentangTanggal) ([]models.KunjunganPasien, error) {
+	var kasusUmur strings.Builder
+	kasusUmur.WriteString("CASE WHEN kunjungan.umur IS NULL OR kunjungan.umur < 0 THEN '" + kunjunganTidakDiketahui + "'")
+	for _, k := range kelompokUmur {
+		if k.Batas > 0 {
+			fmt.Fprintf(&kasusUmur, " WHEN kunjungan.umur < %d THEN '%s'", k.Batas, k.Kode)
+		} else {
+			fmt.Fprintf(&kasusUmur, " ELSE '%s'", k.Kode)
+		}
+	}
+	kasusUmur.WriteString(" END")
+
+	query := `
+		SELECT
+			kunjungan.stts_daftar AS status_daftar,
+			kunjungan.kd_poli,
+			poliklinik.nm_poli,
+			kunjungan.kd_dokter,
+			dokter.nm_dokter,
+			kunjungan.kd_pj,
+			penjab.png_jawab,
+			COALESCE(kabupaten.kd_kab, '-') AS kd_kab,
+			COALESCE(kabupaten.nm_kab, 'Tidak diketahui') AS nm_kab,
+			COALESCE(kecamatan.kd_kec, '-') AS kd_kec,
+			COALESCE(kecamatan.nm_kec, 'Tidak diketahui') AS nm_kec,
+			` + kasusUmur.String() + ` AS kelompok_umur,
+			CASE WHEN kunjungan.jk IN ('L', 'P') THEN kunjungan.jk ELSE '-' END AS jenis_kelamin,
+			COUNT(*) AS jumlah
+		FROM
+		(
+			SELECT
+				reg_periksa.stts_daftar,
+				reg_periksa.kd_poli,
+				reg_periksa.kd_dokter,
+				reg_periksa.kd_pj,
+				pasien.kd_kab,
+				pasien.kd_kec,
+				pasien.jk,
+				TIMESTAMPDIFF(YEAR, pasien.tgl_lahir, reg_periksa.tgl_registrasi) AS umur
+			FROM reg_periksa
+			INNER JOIN pasien ON pasien.no_rkm_medis = reg_periksa.no_rkm_medis
+			WHERE reg_periksa.tgl_registrasi >= @awal AND reg_periksa.tgl_registrasi < @akhir
+				AND reg_periksa.stts <> 'Batal'
+		) AS kunjungan
+		INNER JOIN poliklinik ON poliklinik.kd_poli = kunjungan.kd_poli
+		INNER JOIN dokter ON dokter.kd_dokter = kunjungan.kd_dokter
+		INNER JOIN penjab ON penjab.kd_pj = kunjungan.kd_pj
+		LEFT JOIN kabupaten ON kabupaten.kd_kab = kunjungan.kd_kab
+		LEFT JOIN kecamatan ON kecamatan.kd_kec = kunjungan.kd_kec
+		GROUP BY
+			status_daftar, kunjungan.kd_poli, poliklinik.nm_poli, kunjungan.kd_dokter, dokter.nm_dokter,
+			kunjungan.kd_pj, penjab.png_jawab, kabupaten.kd_kab, kabupaten.nm_kab,
+			kecamatan.kd_kec, kecamatan.nm_kec, kelompok_umur, jenis_kelamin
+	`
+
+	var result []models.KunjunganPasien
+	if err := db.Raw(query, rentang.Parameter()).Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// totalKunjunganPasien menjumlahkan seluruh kunjungan beserta kunjungan pasien baru dan lama
+func totalKunjunganPasien(data []models.KunjunganPasien) (total, baru, lama int) {
+	for _, item := range data {
+		total += item.Jumlah
+		switch item.StatusDaftar {
+		case "Baru":
+			baru += item.Jumlah
+		case "Lama":
+			lama += item.Jumlah
+		}
+	}
+	return total, baru, lama
+}
+
+// rekapKunjunganPer mengelompokkan kunjungan berdasarkan kunci yang dipilih. Kelompok yang hanya
+// ada di periode pembanding tetap ditampilkan dengan jumlah nol. Urutan berisi kode kelompok
+// dengan urutan tetap (misalnya kelompok umur); jika kosong, rekap diurutkan dari jumlah terbesar.
+func rekapKunjunganPer(data, dataPembanding []models.KunjunganPasien, bandingkan bool, kunci func(models.KunjunganPasien) (string, string), urutan []string) []models.RekapKunjungan {
+	rekapPerKode := map[string]*models.RekapKunjungan{}
+	pembandingPerKode := map[string]int{}
+	ambil := func(item models.KunjunganPasien) *models.RekapKunjungan {
+		kode, nama := kunci(item)
+		rekap, ok := rekapPerKode[kode]
+		if !ok {
+			rekap = &models.RekapKunjungan{Kode: kode, Nama: nama}
+			rekapPerKode[kode] = rekap
+		}
+		return rekap
+	}
+
+	total := 0
+	for _, item := range data {
+		rekap := ambil(item)
+		rekap.Jumlah += item.Jumlah
+		switch item.StatusDaftar {
+		case "Baru":
+			rekap.PasienBaru += item.Jumlah
+		case "Lama":
+			rekap.PasienLama += item.Jumlah
+		}
+		total += item.Jumlah
+	}
+	for _, item := range dataPembanding {
+		rekap := ambil(item)
+		pembandingPerKode[rekap.Kode] += item.Jumlah
+	}
+
+	result := []models.RekapKunjungan{}
+	for kode, rekap := range rekapPerKode {
+		if total > 0 {
+			rekap.Persen = math.Round(float64(rekap.Jumlah)/float64(total)*10000) / 100
+		}
+		if bandingkan {
+			jumlahPembanding := pembandingPerKode[kode]
+			selisih := rekap.Jumlah - jumlahPembanding
+			rekap.Pembanding = &jumlahPembanding
+			rekap.Selisih = &selisih
+			if jumlahPembanding > 0 {
+				persen := math.Round(float64(selisih)/float64(jumlahPembanding)*10000) / 100
+				rekap.PersenPerubahan = &persen
+			}
+		}
+		result = append(result, *rekap)
+	}
+
+	// Kode di luar urutan tetap diletakkan setelah kode yang ada di urutan
+	posisi := func(kode string) int {
+		for i, k := range urutan {
+			if k == kode {
+				return i
+			}
+		}
+		return len(urutan)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if pi, pj := posisi(result[i].Kode), posisi(result[j].Kode); pi != pj {
+			return pi < pj
+		}
+		if result[i].Jumlah != result[j].Jumlah {
+			return result[i].Jumlah > result[j].Jumlah
+		}
+		return result[i].Kode < result[j].Kode
+	})
+	return result
+}
+
+// namaAtauKode mengembalikan nama untuk kode dari daftar nama, atau kode itu sendiri jika tidak ada
+func namaAtauKode(nama map[string]string, kode string) string {
+	if n, ok := nama[kode]; ok {
+		return n
+	}
+	if kode == kunjunganTidakDiketahui {
+		return "Tidak diketahui"
+	}
+	return kode
+}
@@ -1,15 +1,213 @@
 package handlers
 
 import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"siak-rsbw/backend/utils"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// parseDate adalah helper untuk mengubah string tanggal menjadi time.Time
-func parseDate(dateStr string) time.Time {
-	t, err := time.Parse("2006-01-02", dateStr)
+// getRentangTanggal mengambil dan memvalidasi rentang tanggal laporan dari query URL.
+// Rentang dapat diberikan sebagai periode bernama (lihat resolvePeriode) atau sebagai
+// tanggal_awal dan tanggal_akhir, tetapi tidak keduanya. Jika tidak ada yang disediakan,
+// digunakan rentang bulan ini; jika hanya salah satu tanggal yang diisi, nilai lainnya diambil
+// dari bulan ini. Bulan berjalan ditentukan menurut zona waktu rumah sakit.
+func getRentangTanggal(r *http.Request) (rentangTanggal, error) {
+	periode := strings.TrimSpace(r.URL.Query().Get("periode"))
+	tanggalAwal := strings.TrimSpace(r.URL.Query().Get("tanggal_awal"))
+	tanggalAkhir := strings.TrimSpace(r.URL.Query().Get("tanggal_akhir"))
+
+	if periode != "" {
+		if tanggalAwal != "" || tanggalAkhir != "" {
+			return rentangTanggal{}, errValidasi("periode", "periode tidak dapat digabung dengan tanggal_awal atau tanggal_akhir")
+		}
+		return resolvePeriode(periode, utils.SekarangRS(), bulanAwalTahunFiskal())
+	}
+
+	bulanIni := rentangBulan(utils.SekarangRS())
+	if tanggalAwal == "" && tanggalAkhir == "" {
+		return bulanIni, nil
+	}
+
+	if tanggalAwal == "" {
+		tanggalAwal = bulanIni.Awal
+	}
+
+	if tanggalAkhir == "" {
+		tanggalAkhir = bulanIni.Akhir
+	}
+
+	return buatRentangTanggal(tanggalAwal, tanggalAkhir)
+}
+
+// Nilai parameter bandingkan
+const (
+	bandingkanPeriodeSebelumnya = "periode_sebelumnya"
+	bandingkanTahunLalu         = "tahun_lalu"
+)
+
+// getRentangPembanding membaca parameter bandingkan dan mengembalikan rentang pembanding untuk
+// rentang laporan, atau nil jika parameter tidak diisi:
+//
+//	periode_sebelumnya  rentang dengan panjang yang sama tepat sebelum rentang laporan; rentang
+//	                    bulan penuh (bulan, kuartal, tahun) digeser per bulan, selain itu per hari
+//	tahun_lalu          rentang yang sama satu tahun sebelumnya (29 Februari menjadi 28 Februari)
+func getRentangPembanding(r *http.Request, rentang rentangTanggal) (*rentangTanggal, error) {
+	bandingkan, err := getParamEnum(r, "bandingkan", "", bandingkanPeriodeSebelumnya, bandingkanTahunLalu)
+	if err != nil || bandingkan == "" {
+		return nil, err
+	}
+
+	mulai, selesai := rentang.Mulai(), rentang.Selesai()
+	var awal, akhir time.Time
+	switch bandingkan {
+	case bandingkanPeriodeSebelumnya:
+		if mulai.Day() == 1 && selesai.Day() == 1 {
+			bulan := (selesai.Year()-mulai.Year())*12 + int(selesai.Month()-mulai.Month())
+			awal = mulai.AddDate(0, -bulan, 0)
+		} else {
+			hari := int(selesai.Sub(mulai).Hours()/24 + 0.5)
+			awal = mulai.AddDate(0, 0, -hari)
+		}
+		akhir = mulai.AddDate(0, 0, -1)
+
+	case bandingkanTahunLalu:
+		awal = mulai.AddDate(-1, 0, 0)
+		if awal.Day() != mulai.Day() {
+			awal = awal.AddDate(0, 0, -awal.Day())
+		}
+		akhir = selesai.AddDate(-1, 0, -1)
+	}
+
+	pembanding, err := buatRentangTanggal(awal.Format("2006-01-02"), akhir.Format("2006-01-02"))
 	if err != nil {
-		// Jika gagal parse, kembalikan waktu sekarang sebagai fallback
-		return time.Now()
+		return nil, errValidasi("bandingkan", "%s", err.Error())
+	}
+	return &pembanding, nil
+}
+
+// filterPembanding mencatat parameter bandingkan dan rentang pembanding pada filter response
+func filterPembanding(filter map[string]string, r *http.Request, pembanding *rentangTanggal) {
+	if pembanding == nil {
+		return
+	}
+	filter["bandingkan"] = r.URL.Query().Get("bandingkan")
+	filter["pembanding_awal"] = pembanding.Awal
+	filter["pembanding_akhir"] = pembanding.Akhir
+}
+
+// tambahPembanding menambahkan nilai periode pembanding, selisih dan persen perubahan untuk setiap
+// total pada response, misalnya total_pendapatan_pembanding, total_pendapatan_selisih dan
+// total_pendapatan_persen_perubahan. Persen perubahan null jika nilai pembanding nol.
+func tambahPembanding(response map[string]interface{}, nilaiPembanding map[string]float64) {
+	for kunci, pembanding := range nilaiPembanding {
+		nilai, _ := response[kunci].(float64)
+		selisih := nilai - pembanding
+		var persen *float64
+		if pembanding != 0 {
+			p := math.Round(selisih/pembanding*10000) / 100
+			persen = &p
+		}
+		response[kunci+"_pembanding"] = pembanding
+		response[kunci+"_selisih"] = selisih
+		response[kunci+"_persen_perubahan"] = persen
+	}
+}
+
+// getKoneksiLaporan mengambil koneksi Khanza untuk satu permintaan laporan. Satu koneksi dari
+// pool dipesan dan diikat ke context permintaan, sehingga ketika batas waktu laporan habis atau
+// klien memutus koneksi, query yang sedang berjalan dihentikan di MySQL dengan KILL QUERY.
+// Pemanggil wajib memanggil fungsi selesai yang dikembalikan. Jika gagal, response error sudah
+// ditulis dan nilai nil dikembalikan.
+func getKoneksiLaporan(w http.ResponseWriter, r *http.Request) (*gorm.DB, func()) {
+	db := utils.GetMySQLDB()
+	if db == nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Koneksi ke database MySQL tidak tersedia")
+		return nil, nil
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		errMessage := fmt.Sprintf("Gagal mendapatkan instance SQL DB: %v", err)
+		fmt.Println(errMessage)
+		utils.WriteError(w, http.StatusInternalServerError, errMessage)
+		return nil, nil
+	}
+
+	ctx := r.Context()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal mendapatkan koneksi database", err)
+		return nil, nil
+	}
+
+	// ID koneksi MySQL dipakai untuk menghentikan query; sekaligus menggantikan ping
+	var idKoneksi uint64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&idKoneksi); err != nil {
+		conn.Close()
+		tulisErrorQuery(w, r, "Ping database gagal", err)
+		return nil, nil
+	}
+
+	berhenti := make(chan struct{})
+	go func() {
+		select {
+		case <-berhenti:
+		case <-ctx.Done():
+			select {
+			case <-berhenti:
+				return
+			default:
+			}
+			hentikanQuery(idKoneksi, namaLaporanDari(ctx), ctx.Err())
+		}
+	}()
+
+	tx := db.WithContext(ctx)
+	tx.Statement.ConnPool = conn
+
+	selesai := func() {
+		close(berhenti)
+		if ctx.Err() != nil {
+			// Koneksi mungkin baru saja menerima KILL QUERY, jangan kembalikan ke pool
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return tx, selesai
+}
+
+// hentikanQuery menghentikan query yang sedang berjalan pada koneksi MySQL tertentu. KILL QUERY
+// dikirim lewat pool kontrol Khanza, bukan pool laporan, karena pool laporan bisa sedang penuh
+// oleh query yang justru ingin dihentikan.
+func hentikanQuery(idKoneksi uint64, nama string, alasan error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	kontrol, err := utils.GetKontrolKhanza()
+	if err != nil {
+		log.Printf("Gagal menghentikan query laporan %s (koneksi %d): pool kontrol tidak tersedia: %v", nama, idKoneksi, err)
+		return
+	}
+	if _, err := kontrol.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", idKoneksi)); err != nil {
+		log.Printf("Gagal menghentikan query laporan %s (koneksi %d): %v", nama, idKoneksi, err)
+		return
+	}
+	log.Printf("Query laporan %s (koneksi %d) dihentikan: %v", nama, idKoneksi, alasan)
+}
+
+// writeJSON mengenkode response ke JSON
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(response); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("Gagal mengenkode response: %v", err))
 	}
-	return t
 }
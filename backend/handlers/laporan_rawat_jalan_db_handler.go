@@ -6,252 +6,78 @@ import (
 	"net/http"
 	"siak-rsbw/backend/models"
 	"siak-rsbw/backend/utils"
-	"time"
+
+	"gorm.io/gorm"
 )
 
-// RawatJalanHandler menangani permintaan untuk mendapatkan laporan rawat jalan dari database MySQL
+// RawatJalanHandler menangani permintaan untuk mendapatkan laporan rawat jalan dari database MySQL.
+// Parameter bandingkan menambahkan total bayar, piutang dan pendapatan periode pembanding.
 func RawatJalanHandler(w http.ResponseWriter, r *http.Request) {
 	// Set header Content-Type
 	w.Header().Set("Content-Type", "application/json")
 
 	// Hanya menerima metode GET
 	if r.Method != http.MethodGet {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
-	// Ambil parameter tanggal dari query URL
-	tanggalAwal := r.URL.Query().Get("tanggal_awal")
-	tanggalAkhir := r.URL.Query().Get("tanggal_akhir")
-	filterBy := r.URL.Query().Get("filter_by")
-	includePiutang := r.URL.Query().Get("include_piutang") // Parameter baru untuk mengontrol penggabungan piutang
-
-	// Default includePiutang ke false jika tidak ada
-	if includePiutang == "" {
-		includePiutang = "false"
-	}
-
-	// Log parameter untuk debugging
-	fmt.Printf("Parameter filter rawat jalan: tanggal_awal=%s, tanggal_akhir=%s, filter_by=%s, include_piutang=%s\n",
-		tanggalAwal, tanggalAkhir, filterBy, includePiutang)
-
-	// Jika tanggal tidak disediakan, gunakan rentang bulan ini
-	if tanggalAwal == "" || tanggalAkhir == "" {
-		now := time.Now()
-		firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		lastOfMonth := time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 0, now.Location())
-
-		if tanggalAwal == "" {
-			tanggalAwal = firstOfMonth.Format("2006-01-02")
-		}
-
-		if tanggalAkhir == "" {
-			tanggalAkhir = lastOfMonth.Format("2006-01-02")
-		}
+	// Ambil dan validasi parameter tanggal dari query URL (default rentang bulan ini)
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
 	}
-
-	// Ambil instance MySQL DB dari utils
-	db := utils.GetMySQLDB()
-	if db == nil {
-		http.Error(w, "Koneksi ke database MySQL tidak tersedia", http.StatusInternalServerError)
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+	// Parameter untuk mengontrol penggabungan piutang, default false
+	includePiutang, err := getParamEnum(r, "include_piutang", "false", "true", "false")
+	if err != nil {
+		tulisErrorValidasi(w, err)
 		return
 	}
 
-	// Cek apakah koneksi database berfungsi
-	sqlDB, err := db.DB()
+	// Basis tanggal filter (tanggal_basis, atau filter_by untuk kompatibilitas)
+	basis, err := getBasisTanggal(r, "rawat-jalan")
 	if err != nil {
-		errMessage := fmt.Sprintf("Gagal mendapatkan instance SQL DB: %v", err)
-		fmt.Println(errMessage)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+		tulisErrorValidasi(w, err)
 		return
 	}
-
-	// Test ping
-	err = sqlDB.Ping()
+	pembanding, err := getRentangPembanding(r, rentang)
 	if err != nil {
-		errMessage := fmt.Sprintf("Ping database gagal: %v", err)
-		fmt.Println(errMessage)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+		tulisErrorValidasi(w, err)
 		return
 	}
 
-	fmt.Println("Koneksi ke database MySQL berhasil!")
-
-	// Query SQL
-	var query string
+	// Log parameter untuk debugging
+	fmt.Printf("Parameter filter rawat jalan: tanggal_awal=%s, tanggal_akhir=%s, tanggal_basis=%s, include_piutang=%s\n",
+		tanggalAwal, tanggalAkhir, basis.Nama, includePiutang)
 
-	// Buat query berdasarkan filter dengan optimasi untuk kecepatan
-	if filterBy == "tgl_bayar" {
-		query = `
-			SELECT 
-				reg_periksa.no_rawat,
-				pasien.no_rkm_medis,
-				pasien.nm_pasien,
-				reg_periksa.tgl_registrasi,
-				poliklinik.nm_poli,
-				nota_jalan.no_nota,
-				nota_jalan.tanggal as tgl_bayar,
-				SUM(detail_nota_jalan.besar_bayar) as besar_bayar,
-				penjab.png_jawab,
-				reg_periksa.kd_pj
-			FROM
-				reg_periksa
-			INNER JOIN pasien ON reg_periksa.no_rkm_medis = pasien.no_rkm_medis
-			INNER JOIN poliklinik ON reg_periksa.kd_poli = poliklinik.kd_poli
-			INNER JOIN penjab ON reg_periksa.kd_pj = penjab.kd_pj
-			LEFT JOIN nota_jalan ON nota_jalan.no_rawat = reg_periksa.no_rawat
-			LEFT JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
-			WHERE
-				nota_jalan.tanggal BETWEEN ? AND ?
-				AND reg_periksa.status_lanjut = 'Ralan'
-			GROUP BY
-				reg_periksa.no_rawat
-			ORDER BY SUM(detail_nota_jalan.besar_bayar) DESC
-			LIMIT 300
-		`
-	} else if filterBy == "tgl_registrasi" {
-		query = `
-			SELECT 
-				reg_periksa.no_rawat,
-				pasien.no_rkm_medis,
-				pasien.nm_pasien,
-				reg_periksa.tgl_registrasi,
-				poliklinik.nm_poli,
-				nota_jalan.no_nota,
-				nota_jalan.tanggal as tgl_bayar,
-				SUM(detail_nota_jalan.besar_bayar) as besar_bayar,
-				penjab.png_jawab,
-				reg_periksa.kd_pj
-			FROM
-				reg_periksa
-			INNER JOIN pasien ON reg_periksa.no_rkm_medis = pasien.no_rkm_medis
-			INNER JOIN poliklinik ON reg_periksa.kd_poli = poliklinik.kd_poli
-			INNER JOIN penjab ON reg_periksa.kd_pj = penjab.kd_pj
-			LEFT JOIN nota_jalan ON nota_jalan.no_rawat = reg_periksa.no_rawat
-			LEFT JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
-			WHERE
-				reg_periksa.tgl_registrasi BETWEEN ? AND ?
-				AND reg_periksa.status_lanjut = 'Ralan'
-			GROUP BY
-				reg_periksa.no_rawat
-			ORDER BY penjab.png_jawab LIKE '%BPJS%' DESC, SUM(detail_nota_jalan.besar_bayar) DESC
-			LIMIT 300
-		`
-	} else {
-		// Query default atau filterBy=both (filter by tgl_registrasi OR tgl_bayar)
-		query = `
-			SELECT 
-				reg_periksa.no_rawat,
-				pasien.no_rkm_medis,
-				pasien.nm_pasien,
-				reg_periksa.tgl_registrasi,
-				poliklinik.nm_poli,
-				nota_jalan.no_nota,
-				nota_jalan.tanggal as tgl_bayar,
-				SUM(detail_nota_jalan.besar_bayar) as besar_bayar,
-				penjab.png_jawab,
-				reg_periksa.kd_pj
-			FROM
-				reg_periksa
-			INNER JOIN pasien ON reg_periksa.no_rkm_medis = pasien.no_rkm_medis
-			INNER JOIN poliklinik ON reg_periksa.kd_poli = poliklinik.kd_poli
-			INNER JOIN penjab ON reg_periksa.kd_pj = penjab.kd_pj
-			LEFT JOIN nota_jalan ON nota_jalan.no_rawat = reg_periksa.no_rawat
-			LEFT JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
-			WHERE
-				reg_periksa.tgl_registrasi BETWEEN ? AND ?
-				AND reg_periksa.status_lanjut = 'Ralan'
-			GROUP BY
-				reg_periksa.no_rawat
-			ORDER BY penjab.png_jawab LIKE '%BPJS%' DESC, SUM(detail_nota_jalan.besar_bayar) DESC
-			LIMIT 300
-		`
+	// Ambil koneksi Khanza yang terikat context permintaan
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
 	}
+	defer selesai()
 
-	// Eksekusi query
-	var result []models.LaporanRawatJalan
+	fmt.Println("Koneksi ke database MySQL berhasil!")
 
 	// Log detail query untuk debugging
-	fmt.Printf("Filter By: %s\n", filterBy)
-	fmt.Printf("Query yang dijalankan: %s\n", query)
+	fmt.Printf("Tanggal basis: %s\n", basis.Nama)
 	fmt.Printf("Parameter: tanggal_awal=%s, tanggal_akhir=%s\n", tanggalAwal, tanggalAkhir)
 
-	var queryErr error
-	if filterBy == "tgl_bayar" {
-		queryErr = db.Raw(query,
-			tanggalAwal, tanggalAkhir, // Parameter untuk nota_jalan.tanggal
-		).Scan(&result).Error
-	} else if filterBy == "tgl_registrasi" {
-		queryErr = db.Raw(query,
-			tanggalAwal, tanggalAkhir, // Parameter untuk reg_periksa.tgl_registrasi
-		).Scan(&result).Error
-	} else {
-		// Untuk filter "both", gunakan 2 parameter untuk satu query
-		queryErr = db.Raw(query,
-			tanggalAwal, tanggalAkhir, // Parameter untuk reg_periksa.tgl_registrasi
-		).Scan(&result).Error
-	}
-
+	// Eksekusi query; tampilan dibatasi 300 baris, job dan ekspor tanpa batas
+	result, terpotong, queryErr := ambilRawatJalan(db, rentang, basis, batasBarisLaporan(r, 300))
 	if queryErr != nil {
-		http.Error(w, fmt.Sprintf("Gagal menjalankan query: %v", queryErr), http.StatusInternalServerError)
+		tulisErrorQuery(w, r, "Gagal menjalankan query", queryErr)
 		return
 	}
 
 	// Log jumlah hasil untuk debugging
 	fmt.Printf("Jumlah data yang ditemukan: %d\n", len(result))
 
-	// Jika tidak ada hasil, kembalikan array kosong
-	if result == nil {
-		result = []models.LaporanRawatJalan{}
-	}
-
 	// Query untuk total pembayaran rawat jalan (dipisahkan untuk performa lebih baik)
-	var totalBayarRawatJalan float64
-	var totalQuery string
-
-	if filterBy == "tgl_bayar" {
-		totalQuery = `
-			SELECT COALESCE(SUM(detail_nota_jalan.besar_bayar), 0) as total
-			FROM reg_periksa
-			INNER JOIN nota_jalan ON nota_jalan.no_rawat = reg_periksa.no_rawat
-			LEFT JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
-			WHERE nota_jalan.tanggal BETWEEN ? AND ?
-			AND reg_periksa.status_lanjut = 'Ralan'
-		`
-	} else if filterBy == "tgl_registrasi" {
-		totalQuery = `
-			SELECT COALESCE(SUM(detail_nota_jalan.besar_bayar), 0) as total
-			FROM reg_periksa
-			LEFT JOIN nota_jalan ON nota_jalan.no_rawat = reg_periksa.no_rawat
-			LEFT JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
-			WHERE reg_periksa.tgl_registrasi BETWEEN ? AND ?
-			AND reg_periksa.status_lanjut = 'Ralan'
-		`
-	} else {
-		totalQuery = `
-			SELECT COALESCE(SUM(detail_nota_jalan.besar_bayar), 0) as total
-			FROM reg_periksa
-			LEFT JOIN nota_jalan ON nota_jalan.no_rawat = reg_periksa.no_rawat
-			LEFT JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
-			WHERE reg_periksa.tgl_registrasi BETWEEN ? AND ?
-			AND reg_periksa.status_lanjut = 'Ralan'
-		`
-	}
-
-	var totalResult struct {
-		Total float64
-	}
-
-	var totalQueryErr error
-	if filterBy == "tgl_bayar" || filterBy == "tgl_registrasi" {
-		totalQueryErr = db.Raw(totalQuery, tanggalAwal, tanggalAkhir).Scan(&totalResult).Error
-	} else {
-		totalQueryErr = db.Raw(totalQuery, tanggalAwal, tanggalAkhir).Scan(&totalResult).Error
-	}
-
-	if totalQueryErr == nil {
-		totalBayarRawatJalan = totalResult.Total
-	} else {
+	totalBayarRawatJalan, totalQueryErr := hitungBayarRawatJalan(db, rentang, basis)
+	if totalQueryErr != nil {
 		fmt.Printf("Gagal menjalankan query total: %v\n", totalQueryErr)
 		// Hitung dari hasil yang ada jika query gagal
 		for _, item := range result {
@@ -262,111 +88,69 @@ func RawatJalanHandler(w http.ResponseWriter, r *http.Request) {
 	// Tambahkan data piutang pasien - optimasi query piutang
 	var piutangResults []models.LaporanPiutangPasien
 	var totalPiutang float64
+	piutangTerpotong := false
 
 	// Hanya jalankan query piutang jika includePiutang = true
 	if includePiutang == "true" {
-		// Query SQL untuk total piutang (tanpa detail untuk kecepatan)
-		piutangTotalQuery := `
-			SELECT COALESCE(SUM(CAST(detail_piutang_pasien.totalpiutang AS DECIMAL(15,2))), 0) as total
-			FROM reg_periksa
-			INNER JOIN piutang_pasien ON reg_periksa.no_rawat = piutang_pasien.no_rawat
-			INNER JOIN detail_piutang_pasien ON reg_periksa.no_rawat = detail_piutang_pasien.no_rawat
-			WHERE piutang_pasien.tgl_piutang BETWEEN ? AND ?
-			AND reg_periksa.status_lanjut = 'Ralan'
-		`
-
-		var piutangTotalResult struct {
-			Total float64
-		}
-
-		piutangTotalErr := db.Raw(piutangTotalQuery, tanggalAwal, tanggalAkhir).Scan(&piutangTotalResult).Error
-		if piutangTotalErr == nil {
-			totalPiutang = piutangTotalResult.Total
-		} else {
+		// Total piutang rawat jalan (tanpa detail untuk kecepatan)
+		var piutangTotalErr error
+		totalPiutang, piutangTotalErr = hitungPiutangPasien(db, rentang, "Ralan")
+		if piutangTotalErr != nil {
 			fmt.Printf("Gagal menjalankan query total piutang: %v\n", piutangTotalErr)
 		}
 
-		// Query SQL untuk piutang pasien dengan alias yang jelas untuk setiap kolom
-		piutangQuery := `
-			SELECT
-				reg_periksa.no_rawat AS no_rawat, 
-				penjab.png_jawab AS png_jawab, 
-				detail_piutang_pasien.nama_bayar AS nama_bayar, 
-				CAST(detail_piutang_pasien.totalpiutang AS DECIMAL(15,2)) AS totalpiutang
-			FROM
-				reg_periksa
-				INNER JOIN piutang_pasien ON reg_periksa.no_rawat = piutang_pasien.no_rawat
-				INNER JOIN detail_piutang_pasien ON reg_periksa.no_rawat = detail_piutang_pasien.no_rawat
-				INNER JOIN penjab ON detail_piutang_pasien.kd_pj = penjab.kd_pj
-			WHERE
-				piutang_pasien.tgl_piutang BETWEEN ? AND ?
-				AND reg_periksa.status_lanjut = 'Ralan'
-			LIMIT 500
-		`
-
-		// Eksekusi query menggunakan map untuk melihat data mentah dari database
-		var rawPiutangResults []map[string]interface{}
-		piutangQueryErr := db.Raw(piutangQuery, tanggalAwal, tanggalAkhir).Scan(&rawPiutangResults).Error
+		// Detail piutang pasien rawat jalan; tampilan dibatasi 500 baris, job dan ekspor tanpa batas
+		var piutangQueryErr error
+		piutangResults, piutangTerpotong, piutangQueryErr = ambilPiutangPasien(db, rentang, basisPiutang, "Ralan", batasBarisLaporan(r, 500))
 		if piutangQueryErr != nil {
 			fmt.Printf("Gagal menjalankan query piutang: %v\n", piutangQueryErr)
 			// Lanjutkan dengan data rawat jalan saja jika query piutang gagal
-		} else {
-			// Konversi hasil mentah ke struct LaporanPiutangPasien
-			for _, raw := range rawPiutangResults {
-				var piutang float64
-
-				// Coba ekstrak nilai totalpiutang dengan berbagai cara
-				if val, ok := raw["totalpiutang"]; ok {
-					switch v := val.(type) {
-					case float64:
-						piutang = v
-					case float32:
-						piutang = float64(v)
-					case int64:
-						piutang = float64(v)
-					case int:
-						piutang = float64(v)
-					case string:
-						fmt.Sscanf(v, "%f", &piutang)
-					case []uint8:
-						// Konversi []uint8 ke string lalu ke float64
-						str := string(v)
-						fmt.Sscanf(str, "%f", &piutang)
-					case map[string]interface{}:
-						// Jika nilai adalah map, coba ambil sebagai float
-						if floatVal, ok := v["value"].(float64); ok {
-							piutang = floatVal
-						}
-					case nil:
-						// Abaikan nilai nil
-						piutang = 0
-					default:
-						fmt.Printf("Tipe data tidak dikenali untuk totalpiutang: %T dengan nilai %v\n", v, v)
-					}
-				}
-
-				item := models.LaporanPiutangPasien{
-					NoRawat:      fmt.Sprintf("%v", raw["no_rawat"]),
-					PngJawab:     fmt.Sprintf("%v", raw["png_jawab"]),
-					NamaBayar:    fmt.Sprintf("%v", raw["nama_bayar"]),
-					TotalPiutang: piutang,
-				}
-				piutangResults = append(piutangResults, item)
-			}
 		}
 
 		fmt.Printf("Jumlah data piutang yang ditemukan: %d\n", len(piutangResults))
 		fmt.Printf("Total piutang: %.2f\n", totalPiutang)
 	}
 
+	// Query pelengkap yang gagal karena batas waktu tidak boleh menghasilkan laporan parsial
+	if konteksBerakhir(w, r) {
+		return
+	}
+
+	// Total periode pembanding dihitung dengan query dan basis tanggal yang sama; piutang hanya
+	// dibandingkan jika ikut dihitung
+	var nilaiPembanding map[string]float64
+	if pembanding != nil {
+		bayarPembanding, err := hitungBayarRawatJalan(db, *pembanding, basis)
+		if err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query rawat jalan periode pembanding", err)
+			return
+		}
+		var piutangPembanding float64
+		if includePiutang == "true" {
+			if piutangPembanding, err = hitungPiutangPasien(db, *pembanding, "Ralan"); err != nil {
+				tulisErrorQuery(w, r, "Gagal menjalankan query piutang periode pembanding", err)
+				return
+			}
+		}
+		nilaiPembanding = map[string]float64{
+			"total_bayar_rawat_jalan": bayarPembanding,
+			"total_piutang":           piutangPembanding,
+			"total_pendapatan":        bayarPembanding + piutangPembanding,
+		}
+	}
+
 	// Siapkan response
+	filter := map[string]string{
+		"tanggal_awal":  tanggalAwal,
+		"tanggal_akhir": tanggalAkhir,
+		"periode":       rentang.Periode,
+		"tanggal_basis": basis.Nama,
+	}
+	filterPembanding(filter, r, pembanding)
 	response := map[string]interface{}{
-		"status":  "success",
-		"message": "Data laporan rawat jalan dan piutang pasien berhasil diambil dari database",
-		"filter": map[string]string{
-			"tanggal_awal":  tanggalAwal,
-			"tanggal_akhir": tanggalAkhir,
-		},
+		"status":                  "success",
+		"message":                 "Data laporan rawat jalan dan piutang pasien berhasil diambil dari database",
+		"filter":                  filter,
 		"total_data_rawat_jalan":  len(result),
 		"total_bayar_rawat_jalan": totalBayarRawatJalan,
 		"data_rawat_jalan":        result,
@@ -374,7 +158,12 @@ func RawatJalanHandler(w http.ResponseWriter, r *http.Request) {
 		"total_piutang":           totalPiutang,
 		"data_piutang":            piutangResults,
 		"total_pendapatan":        totalBayarRawatJalan + totalPiutang,
+		// truncated menandakan data_rawat_jalan terpotong batas tampilan; total tetap dihitung
+		// dari seluruh data
+		"truncated":         terpotong,
+		"truncated_piutang": piutangTerpotong,
 	}
+	tambahPembanding(response, nilaiPembanding)
 
 	// Jika parameter includePiutang = false, hapus data piutang detail
 	if includePiutang == "false" {
@@ -384,7 +173,85 @@ func RawatJalanHandler(w http.ResponseWriter, r *http.Request) {
 	// Encode respons ke JSON
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("Gagal mengenkode response: %v", err), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("Gagal mengenkode response: %v", err))
 		return
 	}
 }
+
+// hitungBayarRawatJalan menjumlahkan pembayaran nota rawat jalan yang lolos filter basis tanggal
+func hitungBayarRawatJalan(db *gorm.DB, rentang rentangTanggal, basis basisTanggal) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(detail_nota_jalan.besar_bayar), 0) as total
+		FROM reg_periksa
+		INNER JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
+		WHERE ` + basis.Kondisi + `
+		AND reg_periksa.status_lanjut = 'Ralan'
+	`
+
+	var hasil struct {
+		Total float64
+	}
+	err := db.Raw(query, rentang.Parameter()).Scan(&hasil).Error
+	return hasil.Total, err
+}
+
+// ambilRawatJalan menjalankan query laporan rawat jalan: satu baris per no_rawat rawat jalan yang
+// lolos filter basis tanggal, dengan total pembayaran nota. Query yang sama dipakai laporan
+// rawat jalan dan perhitungan realisasi pendapatan agar angkanya selalu sama. Batas 0 berarti
+// tanpa batas; nilai kembali kedua menandakan hasil terpotong oleh batas.
+func ambilRawatJalan(db *gorm.DB, rentang rentangTanggal, basis basisTanggal, batas int) ([]models.LaporanRawatJalan, bool, error) {
+	// Urutan hasil: berdasarkan nominal untuk basis bayar, BPJS lebih dulu untuk basis lainnya
+	urutan := "penjab.png_jawab LIKE '%BPJS%' DESC, SUM(detail_nota_jalan.besar_bayar) DESC"
+	if basis.Nama == "bayar" {
+		urutan = "SUM(detail_nota_jalan.besar_bayar) DESC"
+	}
+	limit := ""
+	if batas > 0 {
+		limit = fmt.Sprintf("LIMIT %d", batas+1)
+	}
+
+	// Filter tanggal dinilai per no_rawat sehingga setiap rawat muncul sekali
+	query := `
+		SELECT 
+			reg_periksa.no_rawat,
+			pasien.no_rkm_medis,
+			pasien.nm_pasien,
+			reg_periksa.tgl_registrasi,
+			reg_periksa.kd_poli,
+			poliklinik.nm_poli,
+			nota_jalan.no_nota,
+			nota_jalan.tanggal as tgl_bayar,
+			SUM(detail_nota_jalan.besar_bayar) as besar_bayar,
+			penjab.png_jawab,
+			reg_periksa.kd_pj
+		FROM
+			reg_periksa
+		INNER JOIN pasien ON reg_periksa.no_rkm_medis = pasien.no_rkm_medis
+		INNER JOIN poliklinik ON reg_periksa.kd_poli = poliklinik.kd_poli
+		INNER JOIN penjab ON reg_periksa.kd_pj = penjab.kd_pj
+		LEFT JOIN nota_jalan ON nota_jalan.no_rawat = reg_periksa.no_rawat
+		LEFT JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = reg_periksa.no_rawat
+		WHERE
+			` + basis.Kondisi + `
+			AND reg_periksa.status_lanjut = 'Ralan'
+		GROUP BY
+			reg_periksa.no_rawat
+		ORDER BY ` + urutan + `
+		` + limit + `
+	`
+
+	var result []models.LaporanRawatJalan
+	if err := db.Raw(query, rentang.Parameter()).Scan(&result).Error; err != nil {
+		return nil, false, err
+	}
+
+	terpotong := batas > 0 && len(result) > batas
+	if terpotong {
+		result = result[:batas]
+	}
+	// Jika tidak ada hasil, kembalikan array kosong
+	if result == nil {
+		result = []models.LaporanRawatJalan{}
+	}
+	return result, terpotong, nil
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// laporanTerdaftar mendeskripsikan laporan yang bisa dijalankan di dalam proses (tanpa HTTP),
+// misalnya untuk snapshot tutup buku.
+type laporanTerdaftar struct {
+	Nama    string
+	Path    string
+	Handler http.HandlerFunc
+	// Varian adalah kombinasi parameter tambahan (selain tanggal) yang ikut di-snapshot
+	Varian []url.Values
+	// Kunci berisi kolom pembeda baris untuk setiap array data pada response
+	Kunci map[string][]string
+	// DataUtama adalah array data yang diekspor secara default (misalnya ke CSV), kosong berarti "data"
+	DataUtama string
+}
+
+// daftarLaporan berisi laporan periodik yang ikut ditutup saat tutup buku
+var daftarLaporan = []laporanTerdaftar{
+	{
+		Nama:    "rawat-inap",
+		Path:    "/api/laporan/rawat-inap",
+		Handler: LaporanRawatInapHandler,
+		Varian: []url.Values{
+			{},
+			{"tanggal_basis": {"masuk"}},
+			{"tanggal_basis": {"pulang"}},
+		},
+		Kunci: map[string][]string{
+			"data_rawat_inap": {"no_rawat"},
+			"data_piutang":    {"no_rawat", "nama_bayar"},
+		},
+		DataUtama: "data_rawat_inap",
+	},
+	{
+		Nama:    "rawat-jalan",
+		Path:    "/api/laporan/rawat-jalan",
+		Handler: RawatJalanHandler,
+		Varian: []url.Values{
+			{},
+			{"tanggal_basis": {"bayar"}},
+			{"tanggal_basis": {"keduanya"}},
+		},
+		Kunci: map[string][]string{
+			"data_rawat_jalan": {"no_rawat"},
+			"data_piutang":     {"no_rawat", "nama_bayar"},
+		},
+		DataUtama: "data_rawat_jalan",
+	},
+	{
+		Nama:    "piutang-pasien",
+		Path:    "/api/laporan/piutang-pasien",
+		Handler: LaporanPiutangPasienHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"data": {"no_rawat", "nama_bayar"},
+		},
+	},
+	{
+		Nama:    "penjualan-obat",
+		Path:    "/api/laporan/penjualan-obat",
+		Handler: PenjualanBebasObatHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"data":             {"no_penjualan"},
+			"rekap_barang":     {"kode_brng"},
+			"rekap_petugas":    {"kode"},
+			"rekap_cara_bayar": {"kode"},
+		},
+	},
+	{
+		Nama:    "penerimaan-obat",
+		Path:    "/api/laporan/penerimaan-obat",
+		Handler: PenerimaanObatHandler,
+		Varian: []url.Values{
+			{},
+			{"detail": {"true"}},
+		},
+		Kunci: map[string][]string{
+			"data": {"no_penerimaan"},
+		},
+	},
+	{
+		Nama:    "pendapatan-resep",
+		Path:    "/api/laporan/pendapatan-resep",
+		Handler: PendapatanResepHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"data": {"kd_bangsal", "kd_pj", "status_lanjut", "jenis"},
+		},
+	},
+	{
+		Nama:    "kunjungan",
+		Path:    "/api/laporan/kunjungan",
+		Handler: LaporanKunjunganHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"rekap_status_daftar": {"kode"},
+			"rekap_poli":          {"kode"},
+			"rekap_dokter":        {"kode"},
+			"rekap_penjab":        {"kode"},
+			"rekap_kabupaten":     {"kode"},
+			"rekap_kecamatan":     {"kode"},
+			"rekap_kelompok_umur": {"kode"},
+			"rekap_jenis_kelamin": {"kode"},
+		},
+		DataUtama: "rekap_poli",
+	},
+	{
+		Nama:    "stok-obat",
+		Path:    "/api/laporan/stok-obat",
+		Handler: StokObatHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"data":       {"kode_brng", "kd_bangsal"},
+			"rekap_depo": {"kd_bangsal"},
+		},
+	},
+	{
+		Nama:    "kas",
+		Path:    "/api/laporan/kas",
+		Handler: ArusKasHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"data":   {"tanggal", "arus", "sumber", "akun", "petugas"},
+			"harian": {"tanggal"},
+		},
+	},
+	{
+		Nama:    "neraca-saldo",
+		Path:    "/api/akuntansi/neraca-saldo",
+		Handler: NeracaSaldoHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"data": {"kd_rek"},
+		},
+	},
+	{
+		Nama:    "laba-rugi",
+		Path:    "/api/akuntansi/laba-rugi",
+		Handler: LabaRugiHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"pendapatan": {"kd_rek"},
+			"beban":      {"kd_rek"},
+		},
+		DataUtama: "pendapatan",
+	},
+	{
+		Nama:    "neraca",
+		Path:    "/api/akuntansi/neraca",
+		Handler: NeracaHandler,
+		Varian:  []url.Values{{}},
+		Kunci: map[string][]string{
+			"aktiva":    {"kd_rek"},
+			"kewajiban": {"kd_rek"},
+			"modal":     {"kd_rek"},
+		},
+		DataUtama: "aktiva",
+	},
+}
+
+// dataUtama mengembalikan nama array data utama laporan
+func (l laporanTerdaftar) dataUtama() string {
+	if l.DataUtama != "" {
+		return l.DataUtama
+	}
+	return "data"
+}
+
+// cariLaporan mencari laporan terdaftar berdasarkan nama
+func cariLaporan(nama string) (laporanTerdaftar, bool) {
+	for _, laporan := range daftarLaporan {
+		if laporan.Nama == nama {
+			return laporan, true
+		}
+	}
+	return laporanTerdaftar{}, false
+}
+
+// normalisasiParameter menyusun parameter laporan selain rentang tanggal (tanggal_awal,
+// tanggal_akhir dan periode) dalam bentuk yang konsisten (parameter kosong dibuang, kunci
+// diurutkan) untuk dipakai sebagai kunci snapshot
+func normalisasiParameter(params url.Values) string {
+	normal := url.Values{}
+	for key, values := range params {
+		if key == "tanggal_awal" || key == "tanggal_akhir" || key == "periode" || key == "live" {
+			continue
+		}
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+		normal.Set(key, values[0])
+	}
+	return normal.Encode()
+}
+
+// rekamanResponse adalah http.ResponseWriter yang menyimpan response di memori
+type rekamanResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *rekamanResponse) Header() http.Header {
+	if rr.header == nil {
+		rr.header = http.Header{}
+	}
+	return rr.header
+}
+
+func (rr *rekamanResponse) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	return rr.body.Write(b)
+}
+
+func (rr *rekamanResponse) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+}
+
+// kunciTanpaBatasBaris menandai context laporan yang dijalankan di dalam proses, yang hasilnya
+// tidak boleh dipotong oleh batas baris tampilan
+const kunciTanpaBatasBaris kunciKonteks = "tanpaBatasBaris"
+
+// batasBarisLaporan mengembalikan batas jumlah baris detail yang ditampilkan laporan. Laporan yang
+// dijalankan lewat jalankanLaporan (job, ekspor, snapshot tutup buku) harus lengkap sehingga
+// batasnya 0 (tanpa batas).
+func batasBarisLaporan(r *http.Request, batas int) int {
+	if tanpaBatas, _ := r.Context().Value(kunciTanpaBatasBaris).(bool); tanpaBatas {
+		return 0
+	}
+	return batas
+}
+
+// jalankanLaporan menjalankan handler laporan di dalam proses dengan parameter yang diberikan
+// dan mengembalikan status HTTP serta body response-nya. Batas baris tampilan tidak berlaku.
+func jalankanLaporan(ctx context.Context, laporan laporanTerdaftar, params url.Values) (int, []byte, error) {
+	ctx = context.WithValue(ctx, kunciNamaLaporan, laporan.Nama)
+	ctx = context.WithValue(ctx, kunciTanpaBatasBaris, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, laporan.Path+"?"+params.Encode(), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rekaman := &rekamanResponse{}
+	laporan.Handler(rekaman, req)
+	if rekaman.status == 0 {
+		rekaman.status = http.StatusOK
+	}
+	return rekaman.status, rekaman.body.Bytes(), nil
+}
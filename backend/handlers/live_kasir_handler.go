@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"siak-rsbw/backend/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TransaksiKasir adalah satu pembayaran baru yang dikirim ke dashboard kasir
+type TransaksiKasir struct {
+	Sumber     string  `json:"sumber"` // ralan, ranap atau farmasi
+	NoNota     string  `json:"no_nota"`
+	NoRawat    string  `json:"no_rawat,omitempty"`
+	NoRkmMedis string  `json:"no_rkm_medis"`
+	NmPasien   string  `json:"nm_pasien"`
+	Tanggal    string  `json:"tanggal"`
+	Jam        string  `json:"jam,omitempty"` // penjualan bebas tidak memiliki jam
+	CaraBayar  string  `json:"cara_bayar"`
+	Jumlah     float64 `json:"jumlah"`
+}
+
+// RingkasanSumberKasir adalah total pembayaran hari ini dari satu sumber
+type RingkasanSumberKasir struct {
+	Sumber          string  `json:"sumber"`
+	JumlahTransaksi int     `json:"jumlah_transaksi"`
+	Jumlah          float64 `json:"jumlah"`
+}
+
+// RingkasanKasir adalah total berjalan pembayaran hari ini
+type RingkasanKasir struct {
+	Tanggal         string                 `json:"tanggal"`
+	Sumber          []RingkasanSumberKasir `json:"sumber"`
+	JumlahTransaksi int                    `json:"jumlah_transaksi"`
+	Total           float64                `json:"total"`
+}
+
+// watermarkKasir mencatat pembayaran hari ini yang sudah dikirim, dengan kunci sumber dan nomor
+// nota. Setiap pemeriksaan membaca ulang jendela waktu terakhir lalu membuang nota yang sudah
+// tercatat, sehingga nota yang rinciannya baru tersimpan setelah pemeriksaan sebelumnya tetap
+// terkirim. Nilai map adalah jam nota (kosong untuk penjualan bebas) untuk membuang catatan yang
+// sudah keluar dari jendela.
+type watermarkKasir struct {
+	Tanggal  string
+	Terkirim map[string]string
+}
+
+// hubKasir memantau Khanza sekali untuk seluruh klien dashboard kasir lalu menyebarkan hasilnya.
+// Pemantauan hanya berjalan selama ada klien yang terhubung.
+type hubKasir struct {
+	mu        sync.Mutex
+	klien     map[chan []byte]bool
+	berjalan  bool
+	ringkasan []byte // frame ringkasan terakhir untuk klien yang baru terhubung
+	idEvent   int64
+}
+
+var liveKasir = &hubKasir{klien: map[chan []byte]bool{}}
+
+// notaKasir adalah tabel nota dan detail pembayaran rawat jalan dan rawat inap
+var notaKasir = []struct {
+	sumber string
+	nota   string
+	detail string
+}{
+	{sumber: "ralan", nota: "nota_jalan", detail: "detail_nota_jalan"},
+	{sumber: "ranap", nota: "nota_inap", detail: "detail_nota_inap"},
+}
+
+// daftar menambahkan klien baru dan mengembalikan frame ringkasan terakhir. Pemantauan dimulai
+// jika belum berjalan. False jika jumlah klien sudah mencapai KASIR_LIVE_MAKS_KLIEN (default 100).
+func (h *hubKasir) daftar() (chan []byte, []byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.klien) >= envInt("KASIR_LIVE_MAKS_KLIEN", 100) {
+		return nil, nil, false
+	}
+	ch := make(chan []byte, 16)
+	h.klien[ch] = true
+	if !h.berjalan {
+		h.berjalan = true
+		go h.pantau()
+	}
+	return ch, h.ringkasan, true
+}
+
+// lepas menghapus klien yang terputus
+func (h *hubKasir) lepas(ch chan []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.klien[ch] {
+		delete(h.klien, ch)
+		close(ch)
+	}
+}
+
+// siarkan mengirim satu event ke seluruh klien. Klien yang antriannya penuh diputus agar satu
+// klien lambat tidak menahan klien lain; browser akan menyambung ulang secara otomatis.
+func (h *hubKasir) siarkan(event string, data interface{}) []byte {
+	isi, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Gagal mengenkode event kasir %s: %v", event, err)
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.idEvent++
+	frame := []byte(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", h.idEvent, event, isi))
+	for ch := range h.klien {
+		select {
+		case ch <- frame:
+		default:
+			delete(h.klien, ch)
+			close(ch)
+		}
+	}
+	return frame
+}
+
+// pantau memeriksa pembayaran baru setiap KASIR_LIVE_INTERVAL (default 5s) dan berhenti ketika
+// tidak ada lagi klien yang terhubung
+func (h *hubKasir) pantau() {
+	interval := durasiEnv("KASIR_LIVE_INTERVAL", 5*time.Second)
+	var watermark watermarkKasir
+	var ringkasanTerakhir []byte
+
+	for {
+		h.mu.Lock()
+		if len(h.klien) == 0 {
+			h.berjalan = false
+			h.ringkasan = nil
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+
+		if err := h.periksa(&watermark, &ringkasanTerakhir); err != nil {
+			log.Printf("Pemantauan kasir gagal: %v", err)
+			h.siarkan("gangguan", map[string]string{"pesan": err.Error()})
+		}
+		time.Sleep(interval)
+	}
+}
+
+// periksa mengambil pembayaran yang belum dikirim dan total hari ini. Pada pemeriksaan
+// pertama watermark hanya diisi tanpa mengirim transaksi, karena riwayat hari ini dapat
+// diambil dari laporan; setelah pergantian hari seluruh pembayaran hari baru dikirim.
+func (h *hubKasir) periksa(watermark *watermarkKasir, ringkasanTerakhir *[]byte) error {
+	khanza := utils.GetMySQLDB()
+	if khanza == nil {
+		return fmt.Errorf("database Khanza tidak tersedia")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), durasiEnv("KASIR_LIVE_TIMEOUT", 30*time.Second))
+	defer cancel()
+	db := khanza.WithContext(ctx)
+
+	sekarang := utils.SekarangRS()
+	hari := sekarang.Format("2006-01-02")
+	awal := watermark.Tanggal == ""
+	if watermark.Tanggal != hari {
+		*watermark = watermarkKasir{Tanggal: hari, Terkirim: map[string]string{}}
+	}
+
+	// Jendela dibaca ulang setiap pemeriksaan, tidak melewati awal hari
+	batasJam := "00:00:00"
+	if mulai := sekarang.Add(-durasiEnv("KASIR_LIVE_JENDELA", 10*time.Minute)); mulai.Format("2006-01-02") == hari {
+		batasJam = mulai.Format("15:04:05")
+	}
+
+	transaksi, err := transaksiKasirBaru(db, watermark, batasJam)
+	if err != nil {
+		if awal {
+			// Watermark awal diisi ulang pada pemeriksaan berikutnya
+			watermark.Tanggal = ""
+		}
+		return err
+	}
+	if !awal && len(transaksi) > 0 {
+		h.siarkan("transaksi", transaksi)
+	}
+
+	ringkasan, err := ringkasanKasir(db, hari)
+	if err != nil {
+		return err
+	}
+	isi, _ := json.Marshal(ringkasan)
+	if !bytes.Equal(isi, *ringkasanTerakhir) {
+		*ringkasanTerakhir = isi
+		frame := h.siarkan("ringkasan", ringkasan)
+		h.mu.Lock()
+		h.ringkasan = frame
+		h.mu.Unlock()
+	}
+	return nil
+}
+
+// transaksiKasirBaru mengambil pembayaran rawat jalan dan rawat inap dengan jam nota sejak
+// batasJam serta seluruh penjualan bebas yang sudah dibayar hari ini, lalu mengembalikan yang
+// belum pernah dikirim. Penjualan bebas dibaca sehari penuh karena tidak memiliki jam dan nota
+// lama dapat baru dibayar belakangan. Watermark hanya berubah jika seluruh query berhasil agar
+// tidak ada pembayaran yang terlewat.
+func transaksiKasirBaru(db *gorm.DB, wm *watermarkKasir, batasJam string) ([]TransaksiKasir, error) {
+	var kandidat []TransaksiKasir
+
+	for _, n := range notaKasir {
+		var rows []TransaksiKasir
+		err := db.Raw(fmt.Sprintf(`
+			SELECT
+				'%[1]s' AS sumber,
+				%[2]s.no_nota,
+				%[2]s.no_rawat,
+				reg_periksa.no_rkm_medis,
+				pasien.nm_pasien,
+				DATE_FORMAT(%[2]s.tanggal, '%%Y-%%m-%%d') AS tanggal,
+				TIME_FORMAT(%[2]s.jam, '%%H:%%i:%%s') AS jam,
+				GROUP_CONCAT(DISTINCT %[3]s.nama_bayar SEPARATOR ', ') AS cara_bayar,
+				SUM(%[3]s.besar_bayar) AS jumlah
+			FROM %[2]s
+			INNER JOIN %[3]s ON %[3]s.no_rawat = %[2]s.no_rawat
+			INNER JOIN reg_periksa ON reg_periksa.no_rawat = %[2]s.no_rawat
+			INNER JOIN pasien ON pasien.no_rkm_medis = reg_periksa.no_rkm_medis
+			WHERE %[2]s.tanggal = @hari AND %[2]s.jam >= @jam
+			GROUP BY %[2]s.no_nota, %[2]s.no_rawat, reg_periksa.no_rkm_medis, pasien.nm_pasien,
+				%[2]s.tanggal, %[2]s.jam
+			ORDER BY %[2]s.jam, %[2]s.no_nota
+		`, n.sumber, n.nota, n.detail), map[string]interface{}{
+			"hari": wm.Tanggal,
+			"jam":  batasJam,
+		}).Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("query %s gagal: %w", n.nota, err)
+		}
+		kandidat = append(kandidat, rows...)
+	}
+
+	var penjualan []TransaksiKasir
+	err := db.Raw(`
+		SELECT
+			'farmasi' AS sumber,
+			penjualan.nota_jual AS no_nota,
+			penjualan.no_rkm_medis,
+			penjualan.nm_pasien,
+			DATE_FORMAT(penjualan.tgl_jual, '%Y-%m-%d') AS tanggal,
+			penjualan.nama_bayar AS cara_bayar,
+			SUM(detailjual.total) AS jumlah
+		FROM penjualan
+		INNER JOIN detailjual ON detailjual.nota_jual = penjualan.nota_jual
+		WHERE penjualan.status = 'Sudah Dibayar' AND penjualan.tgl_jual = @hari
+		GROUP BY penjualan.nota_jual, penjualan.no_rkm_medis, penjualan.nm_pasien,
+			penjualan.tgl_jual, penjualan.nama_bayar
+		ORDER BY penjualan.nota_jual
+	`, map[string]interface{}{"hari": wm.Tanggal}).Scan(&penjualan).Error
+	if err != nil {
+		return nil, fmt.Errorf("query penjualan gagal: %w", err)
+	}
+	kandidat = append(kandidat, penjualan...)
+
+	return saringTransaksiKasir(wm, kandidat, batasJam), nil
+}
+
+// saringTransaksiKasir mengembalikan transaksi yang belum tercatat di watermark lalu mencatatnya.
+// Catatan nota dengan jam sebelum batasJam dibuang karena nota tersebut tidak akan terbaca lagi.
+func saringTransaksiKasir(wm *watermarkKasir, kandidat []TransaksiKasir, batasJam string) []TransaksiKasir {
+	for kunci, jam := range wm.Terkirim {
+		if jam != "" && jam < batasJam {
+			delete(wm.Terkirim, kunci)
+		}
+	}
+
+	var hasil []TransaksiKasir
+	for _, row := range kandidat {
+		kunci := row.Sumber + "|" + row.NoNota
+		if _, ada := wm.Terkirim[kunci]; ada {
+			continue
+		}
+		wm.Terkirim[kunci] = row.Jam
+		hasil = append(hasil, row)
+	}
+	return hasil
+}
+
+// ringkasanKasir menghitung total pembayaran hari ini per sumber. Total dihitung ulang penuh
+// setiap kali sehingga tetap benar walaupun ada nota yang rinciannya baru tersimpan setelah
+// keluar dari jendela pemeriksaan.
+func ringkasanKasir(db *gorm.DB, hari string) (RingkasanKasir, error) {
+	ringkasan := RingkasanKasir{Tanggal: hari}
+	err := db.Raw(`
+		SELECT 'ralan' AS sumber, COUNT(DISTINCT nota_jalan.no_rawat) AS jumlah_transaksi,
+			COALESCE(SUM(detail_nota_jalan.besar_bayar), 0) AS jumlah
+		FROM nota_jalan
+		INNER JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = nota_jalan.no_rawat
+		WHERE nota_jalan.tanggal = @hari
+		UNION ALL
+		SELECT 'ranap', COUNT(DISTINCT nota_inap.no_rawat), COALESCE(SUM(detail_nota_inap.besar_bayar), 0)
+		FROM nota_inap
+		INNER JOIN detail_nota_inap ON detail_nota_inap.no_rawat = nota_inap.no_rawat
+		WHERE nota_inap.tanggal = @hari
+		UNION ALL
+		SELECT 'farmasi', COUNT(DISTINCT penjualan.nota_jual), COALESCE(SUM(detailjual.total), 0)
+		FROM penjualan
+		INNER JOIN detailjual ON detailjual.nota_jual = penjualan.nota_jual
+		WHERE penjualan.status = 'Sudah Dibayar' AND penjualan.tgl_jual = @hari
+	`, map[string]interface{}{"hari": hari}).Scan(&ringkasan.Sumber).Error
+	if err != nil {
+		return ringkasan, fmt.Errorf("query ringkasan kasir gagal: %w", err)
+	}
+
+	for _, s := range ringkasan.Sumber {
+		ringkasan.JumlahTransaksi += s.JumlahTransaksi
+		ringkasan.Total += s.Jumlah
+	}
+	return ringkasan, nil
+}
+
+// LiveKasirHandler menangani koneksi server-sent events untuk dashboard kasir. Event yang
+// dikirim: ringkasan (total hari ini, dikirim saat terhubung dan setiap kali berubah),
+// transaksi (array pembayaran baru) dan gangguan (pemeriksaan Khanza gagal). Komentar ping
+// dikirim setiap KASIR_LIVE_HEARTBEAT (default 15s) agar koneksi tidak diputus proxy.
+func LiveKasirHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Streaming tidak didukung")
+		return
+	}
+
+	ch, ringkasan, ok := liveKasir.daftar()
+	if !ok {
+		utils.WriteError(w, http.StatusServiceUnavailable, "Jumlah koneksi dashboard kasir sudah maksimal")
+		return
+	}
+	defer liveKasir.lepas(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if ringkasan != nil {
+		w.Write(ringkasan)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(durasiEnv("KASIR_LIVE_HEARTBEAT", 15*time.Second))
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case frame, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(frame); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
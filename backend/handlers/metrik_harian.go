@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"siak-rsbw/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// nilaiMetrik adalah nilai satu metrik untuk satu dimensi (unit, poli atau penjab)
+type nilaiMetrik struct {
+	Nama  string
+	Nilai float64
+}
+
+// metrikHarian memetakan metrik -> dimensi -> nilai untuk satu tanggal. Metrik total memakai
+// dimensi kosong.
+type metrikHarian map[string]map[string]nilaiMetrik
+
+// labelMetrik adalah nama metrik yang dipakai pada pesan alert
+var labelMetrik = map[string]string{
+	models.MetrikPendapatan:        "Pendapatan",
+	models.MetrikPendapatanUnit:    "Pendapatan unit",
+	models.MetrikPendapatanPenjab:  "Pendapatan penjab",
+	models.MetrikKunjungan:         "Kunjungan",
+	models.MetrikKunjunganPoli:     "Kunjungan poli",
+	models.MetrikPiutangBaru:       "Piutang baru",
+	models.MetrikPiutangBaruPenjab: "Piutang baru penjab",
+}
+
+// tambah menjumlahkan nilai ke satu metrik dan dimensi
+func (m metrikHarian) tambah(metrik, dimensi, nama string, nilai float64) {
+	if m[metrik] == nil {
+		m[metrik] = map[string]nilaiMetrik{}
+	}
+	item := m[metrik][dimensi]
+	if item.Nama == "" {
+		item.Nama = nama
+	}
+	item.Nilai += nilai
+	m[metrik][dimensi] = item
+}
+
+// hitungMetrikHarian menghitung seluruh metrik alert untuk satu tanggal. Pendapatan memakai
+// perhitungan realisasi anggaran; kunjungan dihitung dari reg_periksa yang tidak batal; piutang
+// baru dari piutang pasien dengan tanggal piutang pada hari tersebut.
+func hitungMetrikHarian(db *gorm.DB, tanggal string) (metrikHarian, error) {
+	rentang, err := buatRentangTanggal(tanggal, tanggal)
+	if err != nil {
+		return nil, err
+	}
+
+	// Metrik total selalu ada walaupun tidak ada transaksi sama sekali
+	metrik := metrikHarian{}
+	metrik.tambah(models.MetrikPendapatan, "", "Total", 0)
+	metrik.tambah(models.MetrikKunjungan, "", "Total", 0)
+	metrik.tambah(models.MetrikPiutangBaru, "", "Total", 0)
+
+	realisasi, err := hitungRealisasiPendapatan(db, rentang)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range realisasi {
+		metrik.tambah(models.MetrikPendapatan, "", "Total", row.Jumlah)
+		metrik.tambah(models.MetrikPendapatanUnit, row.JenisUnit+":"+row.KdUnit, row.NmUnit, row.Jumlah)
+		if row.KdPj != "" {
+			metrik.tambah(models.MetrikPendapatanPenjab, row.KdPj, row.PngJawab, row.Jumlah)
+		}
+	}
+
+	var kunjungan []struct {
+		Kode  string
+		Nama  string
+		Nilai float64
+	}
+	err = db.Raw(`
+		SELECT
+			reg_periksa.kd_poli AS kode,
+			poliklinik.nm_poli AS nama,
+			COUNT(*) AS nilai
+		FROM reg_periksa
+		INNER JOIN poliklinik ON poliklinik.kd_poli = reg_periksa.kd_poli
+		WHERE reg_periksa.tgl_registrasi >= @awal AND reg_periksa.tgl_registrasi < @akhir
+			AND reg_periksa.stts <> 'Batal'
+		GROUP BY reg_periksa.kd_poli, poliklinik.nm_poli
+	`, rentang.Parameter()).Scan(&kunjungan).Error
+	if err != nil {
+		return nil, fmt.Errorf("query kunjungan gagal: %w", err)
+	}
+	for _, row := range kunjungan {
+		metrik.tambah(models.MetrikKunjungan, "", "Total", row.Nilai)
+		metrik.tambah(models.MetrikKunjunganPoli, row.Kode, row.Nama, row.Nilai)
+	}
+
+	var piutang []struct {
+		Kode  string
+		Nama  string
+		Nilai float64
+	}
+	err = db.Raw(`
+		SELECT
+			detail_piutang_pasien.kd_pj AS kode,
+			penjab.png_jawab AS nama,
+			SUM(detail_piutang_pasien.totalpiutang) AS nilai
+		FROM piutang_pasien
+		INNER JOIN detail_piutang_pasien ON detail_piutang_pasien.no_rawat = piutang_pasien.no_rawat
+		INNER JOIN penjab ON penjab.kd_pj = detail_piutang_pasien.kd_pj
+		WHERE piutang_pasien.tgl_piutang >= @awal AND piutang_pasien.tgl_piutang < @akhir
+		GROUP BY detail_piutang_pasien.kd_pj, penjab.png_jawab
+	`, rentang.Parameter()).Scan(&piutang).Error
+	if err != nil {
+		return nil, fmt.Errorf("query piutang gagal: %w", err)
+	}
+	for _, row := range piutang {
+		metrik.tambah(models.MetrikPiutangBaru, "", "Total", row.Nilai)
+		metrik.tambah(models.MetrikPiutangBaruPenjab, row.Kode, row.Nama, row.Nilai)
+	}
+
+	return metrik, nil
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PendapatanHarianPenjab adalah pendapatan rawat jalan dan rawat inap satu penjab dalam sehari
+type PendapatanHarianPenjab struct {
+	KdPj     string  `json:"kd_pj"`
+	PngJawab string  `json:"png_jawab"`
+	Jumlah   float64 `json:"jumlah"`
+}
+
+// PiutangMelebihiBatas adalah piutang satu perawatan dan penjab yang melebihi batas notifikasi
+type PiutangMelebihiBatas struct {
+	NoRawat      string  `json:"no_rawat"`
+	NoRkmMedis   string  `json:"no_rkm_medis"`
+	StatusLanjut string  `json:"status_lanjut"`
+	KdPj         string  `json:"kd_pj"`
+	PngJawab     string  `json:"png_jawab"`
+	TotalPiutang float64 `json:"total_piutang"`
+}
+
+// JalankanNotifikasiKeuangan memulai pengirim event keuangan harian. Sesuai jadwal
+// NOTIFIKASI_KEUANGAN_CRON (default 30 0 * * *, zona waktu rumah sakit) pendapatan hari
+// sebelumnya dikirim sebagai event pendapatan.harian, dan piutang hari sebelumnya yang melebihi
+// PIUTANG_BATAS_NOTIFIKASI (default 10000000) dikirim sebagai event piutang.melebihi_batas.
+func JalankanNotifikasiKeuangan() {
+	if utils.GetDB() == nil {
+		log.Println("Database SIAK tidak tersedia, notifikasi keuangan dinonaktifkan")
+		return
+	}
+
+	if ekspresi, ok := jalankanHarian("NOTIFIKASI_KEUANGAN_CRON", "30 0 * * *", kirimNotifikasiKeuangan); ok {
+		log.Printf("Notifikasi keuangan harian aktif (%s)", ekspresi)
+	}
+}
+
+// jalankanHarian menjalankan fungsi di latar belakang sesuai ekspresi cron pada variabel
+// lingkungan key (zona waktu rumah sakit). Fungsi menerima tanggal sehari sebelum waktu jadwal,
+// yaitu hari terakhir yang transaksinya sudah lengkap.
+func jalankanHarian(key, nilaiDefault string, fungsi func(tanggal string)) (string, bool) {
+	ekspresi := getEnv(key, nilaiDefault)
+	cron, err := utils.ParseCron(ekspresi)
+	if err != nil {
+		log.Printf("%s tidak valid: %v", key, err)
+		return ekspresi, false
+	}
+
+	go func() {
+		for {
+			berikutnya := cron.Berikutnya(utils.SekarangRS())
+			if berikutnya.IsZero() {
+				log.Printf("%s tidak pernah berjalan: %s", key, ekspresi)
+				return
+			}
+			time.Sleep(time.Until(berikutnya))
+
+			fungsi(berikutnya.AddDate(0, 0, -1).Format("2006-01-02"))
+		}
+	}()
+	return ekspresi, true
+}
+
+// kirimNotifikasiKeuangan menghitung dan mengirim event keuangan untuk satu tanggal. Query
+// Khanza hanya dijalankan jika ada langganan yang menerima eventnya.
+func kirimNotifikasiKeuangan(tanggal string) {
+	kirimPendapatan := adaPelangganEvent(models.EventPendapatanHarian)
+	kirimPiutang := adaPelangganEvent(models.EventPiutangMelebihiBatas)
+	if !kirimPendapatan && !kirimPiutang {
+		return
+	}
+
+	khanza := utils.GetMySQLDB()
+	if khanza == nil {
+		log.Println("Database Khanza tidak tersedia, notifikasi keuangan dilewati")
+		return
+	}
+	rentang, err := buatRentangTanggal(tanggal, tanggal)
+	if err != nil {
+		log.Printf("Tanggal notifikasi keuangan tidak valid: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), durasiEnv("NOTIFIKASI_KEUANGAN_TIMEOUT", 10*time.Minute))
+	defer cancel()
+	db := khanza.WithContext(ctx)
+
+	if kirimPendapatan {
+		data, err := pendapatanHarian(db, rentang)
+		if err != nil {
+			log.Printf("Gagal menghitung pendapatan harian %s: %v", tanggal, err)
+		} else {
+			KirimEvent(models.EventPendapatanHarian, data)
+		}
+	}
+
+	if kirimPiutang {
+		batas := 10000000.0
+		if nilai, err := strconv.ParseFloat(getEnv("PIUTANG_BATAS_NOTIFIKASI", ""), 64); err == nil && nilai > 0 {
+			batas = nilai
+		}
+		piutang, err := piutangMelebihiBatas(db, rentang, batas)
+		if err != nil {
+			log.Printf("Gagal memeriksa piutang %s: %v", tanggal, err)
+		} else if len(piutang) > 0 {
+			KirimEvent(models.EventPiutangMelebihiBatas, map[string]interface{}{
+				"tanggal":    tanggal,
+				"batas":      batas,
+				"total_data": len(piutang),
+				"data":       piutang,
+			})
+		}
+	}
+}
+
+// pendapatanHarian menyusun isi event pendapatan.harian dari realisasi pendapatan satu hari:
+// total, total per jenis unit, total per penjab (rawat jalan dan rawat inap; penjualan bebas
+// farmasi tidak memiliki penjab) dan rincian per unit dan penjab
+func pendapatanHarian(db *gorm.DB, rentang rentangTanggal) (map[string]interface{}, error) {
+	rincian, err := hitungRealisasiPendapatan(db, rentang)
+	if err != nil {
+		return nil, err
+	}
+
+	var total float64
+	perJenisUnit := map[string]float64{
+		models.UnitRawatJalan: 0,
+		models.UnitRawatInap:  0,
+		models.UnitFarmasi:    0,
+	}
+	perPenjab := map[string]*PendapatanHarianPenjab{}
+	for _, row := range rincian {
+		total += row.Jumlah
+		perJenisUnit[row.JenisUnit] += row.Jumlah
+		if row.KdPj == "" {
+			continue
+		}
+		if _, ada := perPenjab[row.KdPj]; !ada {
+			perPenjab[row.KdPj] = &PendapatanHarianPenjab{KdPj: row.KdPj, PngJawab: row.PngJawab}
+		}
+		perPenjab[row.KdPj].Jumlah += row.Jumlah
+	}
+
+	daftarPenjab := make([]PendapatanHarianPenjab, 0, len(perPenjab))
+	for _, p := range perPenjab {
+		daftarPenjab = append(daftarPenjab, *p)
+	}
+	sort.Slice(daftarPenjab, func(i, j int) bool {
+		return daftarPenjab[i].Jumlah > daftarPenjab[j].Jumlah
+	})
+
+	return map[string]interface{}{
+		"tanggal":        rentang.Awal,
+		"total":          total,
+		"per_jenis_unit": perJenisUnit,
+		"per_penjab":     daftarPenjab,
+		"rincian":        rincian,
+	}, nil
+}
+
+// piutangMelebihiBatas mengambil piutang pasien per perawatan dan penjab dalam rentang yang
+// totalnya melebihi batas, terbesar lebih dulu
+func piutangMelebihiBatas(db *gorm.DB, rentang rentangTanggal, batas float64) ([]PiutangMelebihiBatas, error) {
+	parameter := rentang.Parameter()
+	parameter["batas"] = batas
+
+	var hasil []PiutangMelebihiBatas
+	err := db.Raw(`
+		SELECT
+			piutang_pasien.no_rawat,
+			piutang_pasien.no_rkm_medis,
+			reg_periksa.status_lanjut,
+			detail_piutang_pasien.kd_pj,
+			penjab.png_jawab,
+			SUM(detail_piutang_pasien.totalpiutang) AS total_piutang
+		FROM piutang_pasien
+		INNER JOIN detail_piutang_pasien ON detail_piutang_pasien.no_rawat = piutang_pasien.no_rawat
+		INNER JOIN reg_periksa ON reg_periksa.no_rawat = piutang_pasien.no_rawat
+		INNER JOIN penjab ON penjab.kd_pj = detail_piutang_pasien.kd_pj
+		WHERE piutang_pasien.tgl_piutang >= @awal AND piutang_pasien.tgl_piutang < @akhir
+		GROUP BY piutang_pasien.no_rawat, piutang_pasien.no_rkm_medis, reg_periksa.status_lanjut,
+			detail_piutang_pasien.kd_pj, penjab.png_jawab
+		HAVING total_piutang > @batas
+		ORDER BY total_piutang DESC
+	`, parameter).Scan(&hasil).Error
+	return hasil, err
+}
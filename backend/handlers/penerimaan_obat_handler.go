@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"siak-rsbw/backend/models"
 	"siak-rsbw/backend/utils"
-	"time"
 )
 
 // PenerimaanObatHandler menangani permintaan untuk mendapatkan laporan penerimaan obat dari database MySQL
@@ -16,55 +15,28 @@ func PenerimaanObatHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Hanya menerima metode GET
 	if r.Method != http.MethodGet {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
-	// Ambil parameter tanggal dari query URL
-	tanggalAwal := r.URL.Query().Get("tanggal_awal")
-	tanggalAkhir := r.URL.Query().Get("tanggal_akhir")
-
-	// Jika tanggal tidak disediakan, gunakan rentang bulan ini
-	if tanggalAwal == "" || tanggalAkhir == "" {
-		now := time.Now()
-		firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		lastOfMonth := time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 0, now.Location())
-
-		if tanggalAwal == "" {
-			tanggalAwal = firstOfMonth.Format("2006-01-02")
-		}
-
-		if tanggalAkhir == "" {
-			tanggalAkhir = lastOfMonth.Format("2006-01-02")
-		}
-	}
-
-	// Ambil instance MySQL DB dari utils
-	db := utils.GetMySQLDB()
-	if db == nil {
-		http.Error(w, "Koneksi ke database MySQL tidak tersedia", http.StatusInternalServerError)
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
 		return
 	}
-
-	// Cek apakah koneksi database berfungsi
-	sqlDB, err := db.DB()
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+	modeDetail, err := getParamBool(r, "detail")
 	if err != nil {
-		errMessage := fmt.Sprintf("Gagal mendapatkan instance SQL DB: %v", err)
-		fmt.Println(errMessage)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+		tulisErrorValidasi(w, err)
 		return
 	}
 
-	// Test ping
-	err = sqlDB.Ping()
-	if err != nil {
-		errMessage := fmt.Sprintf("Ping database gagal: %v", err)
-		fmt.Println(errMessage)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
 		return
 	}
-
-	fmt.Println("Koneksi ke database MySQL berhasil!")
+	defer selesai()
 
 	// Query SQL untuk penerimaan obat
 	query := `
@@ -81,7 +53,7 @@ func PenerimaanObatHandler(w http.ResponseWriter, r *http.Request) {
 		INNER JOIN
 			datasuplier ON datasuplier.kode_suplier = pemesanan.kode_suplier
 		WHERE
-			pemesanan.tgl_pesan BETWEEN ? AND ?
+			pemesanan.tgl_pesan >= ? AND pemesanan.tgl_pesan < ?
 		GROUP BY
 			pemesanan.no_faktur
 		ORDER BY
@@ -91,12 +63,12 @@ func PenerimaanObatHandler(w http.ResponseWriter, r *http.Request) {
 	// Eksekusi query
 	var result []models.PenerimaanObat
 	err = db.Raw(query,
-		tanggalAwal,
-		tanggalAkhir,
+		rentang.MulaiSQL(),
+		rentang.SelesaiSQL(),
 	).Scan(&result).Error
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Gagal menjalankan query: %v", err), http.StatusInternalServerError)
+		tulisErrorQuery(w, r, "Gagal menjalankan query", err)
 		return
 	}
 
@@ -105,6 +77,87 @@ func PenerimaanObatHandler(w http.ResponseWriter, r *http.Request) {
 		result = []models.PenerimaanObat{}
 	}
 
+	// Mode detail: lengkapi faktur dengan petugas, potongan, PPN dan baris detailpesan
+	var totalPotongan, totalPPN, totalTagihan float64
+	if modeDetail && len(result) > 0 {
+		noFaktur := make([]string, 0, len(result))
+		for _, item := range result {
+			noFaktur = append(noFaktur, item.NoPenerimaan)
+		}
+
+		fakturQuery := `
+			SELECT
+				pemesanan.no_faktur AS no_penerimaan,
+				COALESCE(petugas.nama, pemesanan.nip) AS petugas,
+				pemesanan.potongan,
+				pemesanan.ppn,
+				pemesanan.meterai,
+				pemesanan.tagihan
+			FROM
+				pemesanan
+			LEFT JOIN
+				petugas ON petugas.nip = pemesanan.nip
+			WHERE
+				pemesanan.no_faktur IN ?
+		`
+
+		var fakturRows []models.PenerimaanObat
+		if err := db.Raw(fakturQuery, noFaktur).Scan(&fakturRows).Error; err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query faktur", err)
+			return
+		}
+
+		detailQuery := `
+			SELECT
+				detailpesan.no_faktur AS no_penerimaan,
+				detailpesan.kode_brng,
+				databarang.nama_brng AS nama_obat,
+				detailpesan.jumlah,
+				detailpesan.kode_sat AS satuan,
+				detailpesan.h_pesan AS harga_satuan,
+				detailpesan.subtotal,
+				detailpesan.besardis AS diskon,
+				detailpesan.total
+			FROM
+				detailpesan
+			INNER JOIN
+				databarang ON databarang.kode_brng = detailpesan.kode_brng
+			WHERE
+				detailpesan.no_faktur IN ?
+			ORDER BY
+				detailpesan.no_faktur, databarang.nama_brng
+		`
+
+		var detailRows []models.DetailPenerimaanObat
+		if err := db.Raw(detailQuery, noFaktur).Scan(&detailRows).Error; err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query detail", err)
+			return
+		}
+
+		fakturPerNo := map[string]models.PenerimaanObat{}
+		for _, faktur := range fakturRows {
+			fakturPerNo[faktur.NoPenerimaan] = faktur
+		}
+		detailPerFaktur := map[string][]models.DetailPenerimaanObat{}
+		for _, detail := range detailRows {
+			detailPerFaktur[detail.NoPenerimaan] = append(detailPerFaktur[detail.NoPenerimaan], detail)
+		}
+
+		for i := range result {
+			faktur := fakturPerNo[result[i].NoPenerimaan]
+			result[i].Petugas = faktur.Petugas
+			result[i].Potongan = faktur.Potongan
+			result[i].PPN = faktur.PPN
+			result[i].Meterai = faktur.Meterai
+			result[i].Tagihan = faktur.Tagihan
+			result[i].Detail = detailPerFaktur[result[i].NoPenerimaan]
+
+			totalPotongan += faktur.Potongan
+			totalPPN += faktur.PPN
+			totalTagihan += faktur.Tagihan
+		}
+	}
+
 	// Hitung total penerimaan
 	var totalPenerimaan float64
 	for _, item := range result {
@@ -118,6 +171,7 @@ func PenerimaanObatHandler(w http.ResponseWriter, r *http.Request) {
 		"filter": map[string]string{
 			"tanggal_awal":  tanggalAwal,
 			"tanggal_akhir": tanggalAkhir,
+			"periode":       rentang.Periode,
 		},
 		"total_data":       len(result),
 		"total_penerimaan": totalPenerimaan,
@@ -125,10 +179,16 @@ func PenerimaanObatHandler(w http.ResponseWriter, r *http.Request) {
 		"data":             result,
 	}
 
+	if modeDetail {
+		response["total_potongan"] = totalPotongan
+		response["total_ppn"] = totalPPN
+		response["total_tagihan"] = totalTagihan
+	}
+
 	// Encode respons ke JSON
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("Gagal mengenkode response: %v", err), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("Gagal mengenkode response: %v", err))
 		return
 	}
 }
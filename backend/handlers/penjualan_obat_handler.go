@@ -1,131 +1,383 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"siak-rsbw/backend/models"
 	"siak-rsbw/backend/utils"
-	"time"
+	"sort"
+
+	"gorm.io/gorm"
 )
 
-// PenjualanBebasObatHandler menangani permintaan untuk mendapatkan laporan penjualan bebas obat dari database MySQL
+// PenjualanBebasObatHandler menangani permintaan untuk mendapatkan laporan penjualan bebas obat dari database MySQL.
+// Parameter bandingkan menambahkan total penjualan, retur dan penjualan bersih periode pembanding.
 func PenjualanBebasObatHandler(w http.ResponseWriter, r *http.Request) {
 	// Set header Content-Type
 	w.Header().Set("Content-Type", "application/json")
 
 	// Hanya menerima metode GET
 	if r.Method != http.MethodGet {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+	modeDetail, err := getParamBool(r, "detail")
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	// Jumlah barang terlaris yang ditampilkan (default 10)
+	top, err := getParamInt(r, "top", 10, 1, 100)
+	if err != nil {
+		tulisErrorValidasi(w, err)
 		return
 	}
+	pembanding, err := getRentangPembanding(r, rentang)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
 
-	// Ambil parameter tanggal dari query URL
-	tanggalAwal := r.URL.Query().Get("tanggal_awal")
-	tanggalAkhir := r.URL.Query().Get("tanggal_akhir")
+	// Eksekusi query penjualan bebas obat
+	result, err := ambilPenjualanBebas(db, rentang)
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query", err)
+		return
+	}
 
-	// Jika tanggal tidak disediakan, gunakan rentang bulan ini
-	if tanggalAwal == "" || tanggalAkhir == "" {
-		now := time.Now()
-		firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		lastOfMonth := time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 0, now.Location())
+	// Mode detail: lengkapi setiap nota dengan baris detailjual
+	if modeDetail && len(result) > 0 {
+		detailQuery := `
+			SELECT
+				detailjual.nota_jual AS no_penjualan,
+				detailjual.kode_brng,
+				databarang.nama_brng AS nama_obat,
+				detailjual.kode_sat AS satuan,
+				detailjual.jumlah,
+				detailjual.h_jual AS harga_jual,
+				detailjual.subtotal,
+				detailjual.bsr_dis AS diskon,
+				detailjual.tambahan,
+				detailjual.embalase,
+				detailjual.tuslah,
+				detailjual.total
+			FROM
+				penjualan
+			INNER JOIN
+				detailjual ON detailjual.nota_jual = penjualan.nota_jual
+			INNER JOIN
+				databarang ON databarang.kode_brng = detailjual.kode_brng
+			WHERE
+				penjualan.status = 'Sudah Dibayar' AND
+				penjualan.tgl_jual >= ? AND penjualan.tgl_jual < ?
+			ORDER BY
+				detailjual.nota_jual, databarang.nama_brng
+		`
 
-		if tanggalAwal == "" {
-			tanggalAwal = firstOfMonth.Format("2006-01-02")
+		var detailRows []models.DetailPenjualanObat
+		if err := db.Raw(detailQuery, rentang.MulaiSQL(), rentang.SelesaiSQL()).Scan(&detailRows).Error; err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query detail", err)
+			return
 		}
 
-		if tanggalAkhir == "" {
-			tanggalAkhir = lastOfMonth.Format("2006-01-02")
+		detailPerNota := map[string][]models.DetailPenjualanObat{}
+		for _, detail := range detailRows {
+			detailPerNota[detail.NoPenjualan] = append(detailPerNota[detail.NoPenjualan], detail)
+		}
+		for i := range result {
+			result[i].Detail = detailPerNota[result[i].NoPenjualan]
 		}
 	}
 
-	// Ambil instance MySQL DB dari utils
-	db := utils.GetMySQLDB()
-	if db == nil {
-		http.Error(w, "Koneksi ke database MySQL tidak tersedia", http.StatusInternalServerError)
+	// Rekap penjualan per barang
+	rekapBarangQuery := `
+		SELECT
+			detailjual.kode_brng,
+			databarang.nama_brng AS nama_obat,
+			detailjual.kode_sat AS satuan,
+			SUM(detailjual.jumlah) AS jumlah,
+			SUM(detailjual.total) AS total
+		FROM
+			penjualan
+		INNER JOIN
+			detailjual ON detailjual.nota_jual = penjualan.nota_jual
+		INNER JOIN
+			databarang ON databarang.kode_brng = detailjual.kode_brng
+		WHERE
+			penjualan.status = 'Sudah Dibayar' AND
+			penjualan.tgl_jual >= ? AND penjualan.tgl_jual < ?
+		GROUP BY
+			detailjual.kode_brng
+	`
+
+	var rekapBarang []models.RekapPenjualanBarang
+	if err := db.Raw(rekapBarangQuery, rentang.MulaiSQL(), rentang.SelesaiSQL()).Scan(&rekapBarang).Error; err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query rekap barang", err)
 		return
 	}
 
-	// Cek apakah koneksi database berfungsi
-	sqlDB, err := db.DB()
-	if err != nil {
-		errMessage := fmt.Sprintf("Gagal mendapatkan instance SQL DB: %v", err)
-		fmt.Println(errMessage)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+	// Retur penjualan per barang pada periode yang sama
+	returBarangQuery := `
+		SELECT
+			detreturjual.kode_brng,
+			databarang.nama_brng AS nama_obat,
+			detreturjual.kode_sat AS satuan,
+			SUM(detreturjual.jml_retur) AS jumlah_retur,
+			SUM(detreturjual.subtotal) AS total_retur
+		FROM
+			returjual
+		INNER JOIN
+			detreturjual ON detreturjual.no_retur_jual = returjual.no_retur_jual
+		INNER JOIN
+			databarang ON databarang.kode_brng = detreturjual.kode_brng
+		WHERE
+			returjual.tgl_retur >= ? AND returjual.tgl_retur < ?
+		GROUP BY
+			detreturjual.kode_brng
+	`
+
+	var returBarang []models.RekapPenjualanBarang
+	if err := db.Raw(returBarangQuery, rentang.MulaiSQL(), rentang.SelesaiSQL()).Scan(&returBarang).Error; err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query retur barang", err)
 		return
 	}
 
-	// Test ping
-	err = sqlDB.Ping()
-	if err != nil {
-		errMessage := fmt.Sprintf("Ping database gagal: %v", err)
-		fmt.Println(errMessage)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+	// Retur penjualan per nota asal, untuk dikurangkan dari rekap petugas dan cara bayar
+	returNotaQuery := `
+		SELECT
+			detreturjual.nota_jual AS no_penjualan,
+			COALESCE(penjualan.nip, '-') AS nip,
+			COALESCE(petugas.nama, penjualan.nip, '-') AS petugas,
+			COALESCE(penjualan.nama_bayar, '-') AS nama_bayar,
+			SUM(detreturjual.subtotal) AS total
+		FROM
+			returjual
+		INNER JOIN
+			detreturjual ON detreturjual.no_retur_jual = returjual.no_retur_jual
+		LEFT JOIN
+			penjualan ON penjualan.nota_jual = detreturjual.nota_jual
+		LEFT JOIN
+			petugas ON petugas.nip = penjualan.nip
+		WHERE
+			returjual.tgl_retur >= ? AND returjual.tgl_retur < ?
+		GROUP BY
+			detreturjual.nota_jual
+	`
+
+	var returNota []models.PenjualanBebasObat
+	if err := db.Raw(returNotaQuery, rentang.MulaiSQL(), rentang.SelesaiSQL()).Scan(&returNota).Error; err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query retur nota", err)
 		return
 	}
 
-	fmt.Println("Koneksi ke database MySQL berhasil!")
+	// Gabungkan penjualan dan retur per barang
+	rekapPerBarang := map[string]*models.RekapPenjualanBarang{}
+	for i := range rekapBarang {
+		rekapPerBarang[rekapBarang[i].KodeBrng] = &rekapBarang[i]
+	}
+	var hanyaRetur []models.RekapPenjualanBarang
+	for _, retur := range returBarang {
+		if rekap, ok := rekapPerBarang[retur.KodeBrng]; ok {
+			rekap.JumlahRetur += retur.JumlahRetur
+			rekap.TotalRetur += retur.TotalRetur
+			continue
+		}
+		// Barang yang hanya diretur pada periode ini (dijual pada periode sebelumnya)
+		hanyaRetur = append(hanyaRetur, retur)
+	}
+	rekapBarang = append(rekapBarang, hanyaRetur...)
+	for i := range rekapBarang {
+		rekapBarang[i].JumlahBersih = rekapBarang[i].Jumlah - rekapBarang[i].JumlahRetur
+		rekapBarang[i].TotalBersih = rekapBarang[i].Total - rekapBarang[i].TotalRetur
+	}
+	if rekapBarang == nil {
+		rekapBarang = []models.RekapPenjualanBarang{}
+	}
+
+	// Barang terlaris berdasarkan nilai dan jumlah
+	sort.Slice(rekapBarang, func(i, j int) bool {
+		return rekapBarang[i].TotalBersih > rekapBarang[j].TotalBersih
+	})
+	terlarisNilai := append([]models.RekapPenjualanBarang{}, rekapBarang[:min(top, len(rekapBarang))]...)
+
+	terlarisJumlah := append([]models.RekapPenjualanBarang{}, rekapBarang...)
+	sort.Slice(terlarisJumlah, func(i, j int) bool {
+		return terlarisJumlah[i].JumlahBersih > terlarisJumlah[j].JumlahBersih
+	})
+	terlarisJumlah = terlarisJumlah[:min(top, len(terlarisJumlah))]
+
+	// Rekap per petugas (kasir) dan per cara bayar
+	rekapPetugas := rekapPenjualanPer(result, returNota, func(item models.PenjualanBebasObat) (string, string) {
+		return item.Nip, item.Petugas
+	})
+	rekapCaraBayar := rekapPenjualanPer(result, returNota, func(item models.PenjualanBebasObat) (string, string) {
+		return item.NamaBayar, item.NamaBayar
+	})
+
+	// Hitung total penjualan dan retur
+	var totalPenjualan, totalRetur float64
+	for _, item := range result {
+		totalPenjualan += item.Total
+	}
+	for _, retur := range returNota {
+		totalRetur += retur.Total
+	}
+
+	// Total periode pembanding dihitung dengan query yang sama
+	var nilaiPembanding map[string]float64
+	if pembanding != nil {
+		penjualanPembanding, err := ambilPenjualanBebas(db, *pembanding)
+		if err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query penjualan periode pembanding", err)
+			return
+		}
+		returPembanding, err := hitungReturPenjualanBebas(db, *pembanding)
+		if err != nil {
+			tulisErrorQuery(w, r, "Gagal menjalankan query retur periode pembanding", err)
+			return
+		}
+		var totalPembanding float64
+		for _, item := range penjualanPembanding {
+			totalPembanding += item.Total
+		}
+		nilaiPembanding = map[string]float64{
+			"total_penjualan":        totalPembanding,
+			"total_retur":            returPembanding,
+			"total_penjualan_bersih": totalPembanding - returPembanding,
+		}
+	}
+
+	// Siapkan response
+	filter := map[string]string{
+		"tanggal_awal":  tanggalAwal,
+		"tanggal_akhir": tanggalAkhir,
+		"periode":       rentang.Periode,
+	}
+	filterPembanding(filter, r, pembanding)
+	response := map[string]interface{}{
+		"status":                 "success",
+		"message":                "Data penjualan bebas obat berhasil diambil dari database",
+		"filter":                 filter,
+		"total_data":             len(result),
+		"total_penjualan":        totalPenjualan,
+		"total_retur":            totalRetur,
+		"total_penjualan_bersih": totalPenjualan - totalRetur,
+		"rekap_barang":           rekapBarang,
+		"terlaris_nilai":         terlarisNilai,
+		"terlaris_jumlah":        terlarisJumlah,
+		"rekap_petugas":          rekapPetugas,
+		"rekap_cara_bayar":       rekapCaraBayar,
+		"keterangan":             "Data merupakan pendapatan dari penjualan obat bebas yang sudah dibayar, retur jual pada periode yang sama dikurangkan pada total bersih",
+		"data":                   result,
+	}
+	tambahPembanding(response, nilaiPembanding)
+
+	writeJSON(w, response)
+}
+
+// hitungReturPenjualanBebas menjumlahkan retur jual dengan tanggal retur dalam rentang, sama
+// dengan total retur laporan penjualan bebas
+func hitungReturPenjualanBebas(db *gorm.DB, rentang rentangTanggal) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(detreturjual.subtotal), 0) AS total
+		FROM returjual
+		INNER JOIN detreturjual ON detreturjual.no_retur_jual = returjual.no_retur_jual
+		WHERE returjual.tgl_retur >= ? AND returjual.tgl_retur < ?
+	`
+
+	var hasil struct {
+		Total float64
+	}
+	err := db.Raw(query, rentang.MulaiSQL(), rentang.SelesaiSQL()).Scan(&hasil).Error
+	return hasil.Total, err
+}
 
-	// Query SQL untuk penjualan bebas obat
+// ambilPenjualanBebas menjalankan query laporan penjualan bebas obat: satu baris per nota yang
+// sudah dibayar beserta depo (bangsal) penjualannya. Query yang sama dipakai laporan penjualan
+// dan perhitungan realisasi pendapatan farmasi.
+func ambilPenjualanBebas(db *gorm.DB, rentang rentangTanggal) ([]models.PenjualanBebasObat, error) {
 	query := `
 		SELECT
 			penjualan.tgl_jual AS tanggal_penjualan,
 			penjualan.nota_jual AS no_penjualan,
+			penjualan.nip,
+			COALESCE(petugas.nama, penjualan.nip) AS petugas,
+			penjualan.nama_bayar,
+			penjualan.kd_bangsal,
+			COALESCE(bangsal.nm_bangsal, penjualan.kd_bangsal) AS nm_bangsal,
 			SUM(detailjual.total) AS total
 		FROM
 			penjualan
-		INNER JOIN 
+		INNER JOIN
 			detailjual ON detailjual.nota_jual = penjualan.nota_jual
+		LEFT JOIN
+			petugas ON petugas.nip = penjualan.nip
+		LEFT JOIN
+			bangsal ON bangsal.kd_bangsal = penjualan.kd_bangsal
 		WHERE
 			penjualan.status = 'Sudah Dibayar' AND
-			penjualan.tgl_jual BETWEEN ? AND ?
+			penjualan.tgl_jual >= ? AND penjualan.tgl_jual < ?
 		GROUP BY
 			penjualan.nota_jual
 		ORDER BY
 			penjualan.tgl_jual DESC
 	`
 
-	// Eksekusi query
 	var result []models.PenjualanBebasObat
-	err = db.Raw(query,
-		tanggalAwal,
-		tanggalAkhir,
-	).Scan(&result).Error
-
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Gagal menjalankan query: %v", err), http.StatusInternalServerError)
-		return
+	if err := db.Raw(query, rentang.MulaiSQL(), rentang.SelesaiSQL()).Scan(&result).Error; err != nil {
+		return nil, err
 	}
 
 	// Jika tidak ada hasil, kembalikan array kosong
 	if result == nil {
 		result = []models.PenjualanBebasObat{}
 	}
+	return result, nil
+}
 
-	// Hitung total penjualan
-	var totalPenjualan float64
-	for _, item := range result {
-		totalPenjualan += item.Total
+// rekapPenjualanPer mengelompokkan nota penjualan dan retur berdasarkan kunci yang dipilih
+func rekapPenjualanPer(penjualan, retur []models.PenjualanBebasObat, kunci func(models.PenjualanBebasObat) (string, string)) []models.RekapPenjualan {
+	rekapPerKode := map[string]*models.RekapPenjualan{}
+	ambil := func(item models.PenjualanBebasObat) *models.RekapPenjualan {
+		kode, nama := kunci(item)
+		rekap, ok := rekapPerKode[kode]
+		if !ok {
+			rekap = &models.RekapPenjualan{Kode: kode, Nama: nama}
+			rekapPerKode[kode] = rekap
+		}
+		return rekap
 	}
 
-	// Siapkan response
-	response := map[string]interface{}{
-		"status":  "success",
-		"message": "Data penjualan bebas obat berhasil diambil dari database",
-		"filter": map[string]string{
-			"tanggal_awal":  tanggalAwal,
-			"tanggal_akhir": tanggalAkhir,
-		},
-		"total_data":      len(result),
-		"total_penjualan": totalPenjualan,
-		"keterangan":      "Data merupakan pendapatan dari penjualan obat bebas yang sudah dibayar",
-		"data":            result,
-	}
-
-	// Encode respons ke JSON
-	encoder := json.NewEncoder(w)
-	if err := encoder.Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("Gagal mengenkode response: %v", err), http.StatusInternalServerError)
-		return
+	for _, item := range penjualan {
+		rekap := ambil(item)
+		rekap.JumlahNota++
+		rekap.Total += item.Total
+	}
+	for _, item := range retur {
+		ambil(item).TotalRetur += item.Total
+	}
+
+	result := []models.RekapPenjualan{}
+	for _, rekap := range rekapPerKode {
+		rekap.TotalBersih = rekap.Total - rekap.TotalRetur
+		result = append(result, *rekap)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TotalBersih > result[j].TotalBersih
+	})
+	return result
 }
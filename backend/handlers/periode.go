@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Periode bernama yang dapat dipakai pada parameter periode
+const (
+	periodeTahunBerjalan = "ytd"
+	periodeBulanLalu     = "last_month"
+	periodeHariIni       = "today"
+	periodeKemarin       = "yesterday"
+)
+
+var (
+	polaPeriodeBulan   = regexp.MustCompile(`^\d{4}-\d{2}$`)
+	polaPeriodeKuartal = regexp.MustCompile(`^(\d{4})-[Qq]([1-4])$`)
+	polaPeriodeTahun   = regexp.MustCompile(`^\d{4}$`)
+)
+
+// bulanAwalTahunFiskal adalah bulan pertama tahun fiskal rumah sakit. Diatur lewat
+// TAHUN_FISKAL_BULAN_AWAL (1-12), default 1 (tahun fiskal = tahun kalender).
+func bulanAwalTahunFiskal() time.Month {
+	if n, err := strconv.Atoi(getEnv("TAHUN_FISKAL_BULAN_AWAL", "1")); err == nil && n >= 1 && n <= 12 {
+		return time.Month(n)
+	}
+	return time.January
+}
+
+// awalTahunFiskal mengembalikan tanggal 1 bulan awal tahun fiskal berlabel tahun tersebut.
+// Tahun fiskal diberi label tahun kalender tempat ia dimulai, misalnya dengan bulan awal Juli
+// tahun fiskal 2024 berlangsung 1 Juli 2024 s.d. 30 Juni 2025.
+func awalTahunFiskal(tahun int, bulanAwal time.Month, loc *time.Location) time.Time {
+	return time.Date(tahun, bulanAwal, 1, 0, 0, 0, 0, loc)
+}
+
+// tahunFiskalDari mengembalikan label tahun fiskal yang memuat waktu t
+func tahunFiskalDari(t time.Time, bulanAwal time.Month) int {
+	if t.Month() < bulanAwal {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// resolvePeriode menerjemahkan parameter periode menjadi rentang tanggal. Nilai yang didukung:
+//
+//	2024-05     satu bulan kalender
+//	2024-Q2     kuartal kedua tahun fiskal 2024
+//	2024        tahun fiskal 2024
+//	ytd         awal tahun fiskal berjalan sampai hari ini
+//	last_month  bulan kalender sebelumnya
+//	today       hari ini
+//	yesterday   hari kemarin
+//
+// Waktu sekarang diberikan oleh pemanggil (zona waktu rumah sakit) agar hasilnya deterministik.
+func resolvePeriode(nilai string, sekarang time.Time, bulanAwal time.Month) (rentangTanggal, error) {
+	nilai = strings.TrimSpace(nilai)
+	loc := sekarang.Location()
+	hariIni := time.Date(sekarang.Year(), sekarang.Month(), sekarang.Day(), 0, 0, 0, 0, loc)
+
+	var awal, akhir time.Time
+	label := nilai
+	switch {
+	case strings.EqualFold(nilai, periodeTahunBerjalan):
+		awal = awalTahunFiskal(tahunFiskalDari(hariIni, bulanAwal), bulanAwal, loc)
+		akhir = hariIni
+		label = periodeTahunBerjalan
+
+	case strings.EqualFold(nilai, periodeBulanLalu):
+		awal = time.Date(hariIni.Year(), hariIni.Month()-1, 1, 0, 0, 0, 0, loc)
+		akhir = awal.AddDate(0, 1, -1)
+		label = periodeBulanLalu
+
+	case strings.EqualFold(nilai, periodeHariIni):
+		awal, akhir = hariIni, hariIni
+		label = periodeHariIni
+
+	case strings.EqualFold(nilai, periodeKemarin):
+		awal = hariIni.AddDate(0, 0, -1)
+		akhir = awal
+		label = periodeKemarin
+
+	case polaPeriodeBulan.MatchString(nilai):
+		bulan, err := time.ParseInLocation("2006-01", nilai, loc)
+		if err != nil {
+			return rentangTanggal{}, errValidasi("periode", "periode bulan tidak valid: %s", nilai)
+		}
+		awal = bulan
+		akhir = bulan.AddDate(0, 1, -1)
+
+	case polaPeriodeKuartal.MatchString(nilai):
+		bagian := polaPeriodeKuartal.FindStringSubmatch(nilai)
+		tahun, _ := strconv.Atoi(bagian[1])
+		kuartal, _ := strconv.Atoi(bagian[2])
+		awal = awalTahunFiskal(tahun, bulanAwal, loc).AddDate(0, 3*(kuartal-1), 0)
+		akhir = awal.AddDate(0, 3, -1)
+		label = fmt.Sprintf("%d-Q%d", tahun, kuartal)
+
+	case polaPeriodeTahun.MatchString(nilai):
+		tahun, _ := strconv.Atoi(nilai)
+		awal = awalTahunFiskal(tahun, bulanAwal, loc)
+		akhir = awal.AddDate(1, 0, -1)
+
+	default:
+		return rentangTanggal{}, errValidasi("periode",
+			"periode tidak dikenal: %s (format: YYYY-MM, YYYY-Qn, YYYY, ytd, last_month, today atau yesterday)", nilai)
+	}
+
+	rentang, err := buatRentangTanggal(awal.Format("2006-01-02"), akhir.Format("2006-01-02"))
+	if err != nil {
+		return rentangTanggal{}, errValidasi("periode", "%s", err.Error())
+	}
+	rentang.Periode = label
+	return rentang, nil
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// musimBulanan adalah panjang musim deret pendapatan bulanan
+const musimBulanan = 12
+
+// nilaiZ adalah nilai z distribusi normal untuk setiap tingkat kepercayaan interval prediksi
+var nilaiZ = map[string]float64{
+	"80": 1.2816,
+	"90": 1.6449,
+	"95": 1.9600,
+}
+
+// ProyeksiPendapatanHandler menangani permintaan proyeksi pendapatan bulanan per lini layanan
+// (rawat jalan, rawat inap, farmasi dan total). Deret historis sebanyak riwayat bulan (default
+// 36) sampai bulan lalu disusun per bulan lewat pendapatanBulanan, yang menyimpan total setiap
+// bulan di cache laporan sehingga hanya bulan yang belum tersimpan yang dihitung ulang. Deret
+// tersebut dipasang model Holt-Winters aditif dengan musim 12 bulan untuk meramal horizon bulan
+// (default 12) ke depan. Parameter live=true menghitung ulang seluruh bulan.
+// Akurasi diukur dengan backtest: backtest bulan terakhir (default 6) disembunyikan lalu
+// diramalkan dari data sebelumnya.
+func ProyeksiPendapatanHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	horizon, err := getParamInt(r, "horizon", 12, 1, 24)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	riwayat, err := getParamInt(r, "riwayat", 36, 12, 120)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	jumlahBacktest, err := getParamInt(r, "backtest", 6, 0, 12)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tingkat, err := getParamEnum(r, "tingkat_kepercayaan", "95", "80", "90", "95")
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	jenisUnit, err := getParamEnum(r, "jenis_unit", "", models.UnitRawatJalan, models.UnitRawatInap, models.UnitFarmasi)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	// Bulan berjalan belum lengkap sehingga riwayat berakhir pada bulan lalu
+	bulanIni := rentangBulan(utils.SekarangRS())
+	awal := bulanIni.Mulai().AddDate(0, -riwayat, 0)
+	rentangPerBulan := make([]rentangTanggal, riwayat)
+	daftarBulan := make([]string, riwayat)
+	for i := range daftarBulan {
+		rentangPerBulan[i] = rentangBulan(awal.AddDate(0, i, 0))
+		daftarBulan[i] = rentangPerBulan[i].Periode
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	lini := []string{models.UnitRawatJalan, models.UnitRawatInap, models.UnitFarmasi, "total"}
+	if jenisUnit != "" {
+		lini = []string{jenisUnit}
+	}
+	deret := map[string][]float64{}
+	for _, l := range lini {
+		deret[l] = make([]float64, riwayat)
+	}
+	live := r.URL.Query().Get("live") == "true"
+	for i, rentang := range rentangPerBulan {
+		totalPerUnit, err := pendapatanBulanan(db, rentang, live)
+		if err != nil {
+			tulisErrorQuery(w, r, "Gagal menghitung realisasi pendapatan "+rentang.Periode, err)
+			return
+		}
+		for jenisUnit, jumlah := range totalPerUnit {
+			if d, ada := deret[jenisUnit]; ada {
+				d[i] += jumlah
+			}
+			if d, ada := deret["total"]; ada {
+				d[i] += jumlah
+			}
+		}
+	}
+
+	z := nilaiZ[tingkat]
+	hasil := make([]models.ProyeksiPendapatan, 0, len(lini))
+	for _, l := range lini {
+		hasil = append(hasil, proyeksiDeret(l, daftarBulan, deret[l], horizon, jumlahBacktest, z))
+	}
+
+	// Kirim respons
+	writeJSON(w, map[string]interface{}{
+		"status": "success",
+		"filter": map[string]interface{}{
+			"riwayat_awal":        daftarBulan[0],
+			"riwayat_akhir":       daftarBulan[len(daftarBulan)-1],
+			"horizon":             horizon,
+			"backtest":            jumlahBacktest,
+			"tingkat_kepercayaan": tingkat,
+			"jenis_unit":          jenisUnit,
+		},
+		"data": hasil,
+	})
+}
+
+// namaCacheProyeksi adalah nama laporan untuk entri cache deret bulanan proyeksi pendapatan
+const namaCacheProyeksi = "proyeksi-pendapatan"
+
+// pendapatanBulanan mengembalikan total realisasi pendapatan satu bulan per jenis unit dari
+// hitungRealisasiPendapatan. Hasilnya disimpan di cache laporan dengan masa berlaku periode
+// lampau karena bulan yang sudah lewat jarang berubah; live=true melewati cache dan menyimpan
+// ulang hasil terbaru.
+func pendapatanBulanan(db *gorm.DB, rentang rentangTanggal, live bool) (map[string]float64, error) {
+	cache := getCacheLaporan()
+	kunci := namaCacheProyeksi + "|" + rentang.Periode
+	if cache != nil && !live {
+		if entri, ok := cache.Get(kunci); ok {
+			var total map[string]float64
+			if err := json.Unmarshal(entri.Data, &total); err == nil {
+				return total, nil
+			}
+		}
+	}
+
+	realisasi, err := hitungRealisasiPendapatan(db, rentang)
+	if err != nil {
+		return nil, err
+	}
+	total := map[string]float64{}
+	for _, row := range realisasi {
+		total[row.JenisUnit] += row.Jumlah
+	}
+
+	if cache != nil {
+		if data, err := json.Marshal(total); err == nil {
+			daftarkanCacheLaporan(namaCacheProyeksi)
+			cache.Set(kunci, data, ttlCacheLaporan(rentang, utils.SekarangRS()))
+		}
+	}
+	return total, nil
+}
+
+// proyeksiDeret memasang model pada satu deret bulanan dan menyusun ramalan serta backtest.
+// Bulan-bulan nol di awal deret (sebelum layanan tercatat di Khanza) dibuang agar tidak
+// dianggap sebagai pendapatan nol.
+func proyeksiDeret(jenisUnit string, daftarBulan []string, data []float64, horizon, jumlahBacktest int, z float64) models.ProyeksiPendapatan {
+	hasil := models.ProyeksiPendapatan{
+		JenisUnit: jenisUnit,
+		Historis:  []models.TitikHistoris{},
+		Proyeksi:  []models.TitikProyeksi{},
+	}
+
+	mulai := 0
+	for mulai < len(data) && data[mulai] == 0 {
+		mulai++
+	}
+	daftarBulan, data = daftarBulan[mulai:], data[mulai:]
+	for i, bulan := range daftarBulan {
+		hasil.Historis = append(hasil.Historis, models.TitikHistoris{Bulan: bulan, Jumlah: data[i]})
+	}
+
+	model, err := utils.PasangHoltWinters(data, musimBulanan)
+	if err != nil {
+		hasil.Pesan = err.Error()
+		return hasil
+	}
+
+	hasil.Model = "holt_winters"
+	if model.Musim == 0 {
+		hasil.Model = "holt"
+		hasil.Pesan = "Data historis kurang dari 24 bulan, komponen musiman tidak dipakai"
+	}
+	hasil.Alpha, hasil.Beta, hasil.Gamma = model.Alpha, model.Beta, model.Gamma
+	hasil.Sigma = math.Round(model.Sigma)
+	for i, fitted := range model.Fitted {
+		f := math.Round(fitted)
+		hasil.Historis[model.AwalFitted+i].Fitted = &f
+	}
+
+	bulanTerakhir, _ := time.Parse("2006-01", daftarBulan[len(daftarBulan)-1])
+	titik, bawah, atas := model.Ramal(horizon, z)
+	for i := range titik {
+		hasil.Proyeksi = append(hasil.Proyeksi, models.TitikProyeksi{
+			Bulan:      bulanTerakhir.AddDate(0, i+1, 0).Format("2006-01"),
+			Jumlah:     math.Round(math.Max(0, titik[i])),
+			BatasBawah: math.Round(math.Max(0, bawah[i])),
+			BatasAtas:  math.Round(math.Max(0, atas[i])),
+		})
+	}
+
+	hasil.Backtest = backtestProyeksi(daftarBulan, data, jumlahBacktest, z)
+	return hasil
+}
+
+// backtestProyeksi memasang model pada data tanpa n bulan terakhir, meramal n bulan tersebut,
+// lalu menghitung MAE, RMSE, MAPE dan cakupan interval prediksi. Nil jika n nol atau data
+// pelatihan tidak cukup.
+func backtestProyeksi(daftarBulan []string, data []float64, n int, z float64) *models.HasilBacktest {
+	if n == 0 || len(data)-n < utils.MinimalDataPeramalan {
+		return nil
+	}
+
+	latih := len(data) - n
+	model, err := utils.PasangHoltWinters(data[:latih], musimBulanan)
+	if err != nil {
+		return nil
+	}
+	titik, bawah, atas := model.Ramal(n, z)
+
+	hasil := &models.HasilBacktest{JumlahBulan: n}
+	var totalAbsolut, totalKuadrat, totalPersen float64
+	var jumlahPersen, tercakup int
+	for i := 0; i < n; i++ {
+		aktual := data[latih+i]
+		ramalan := math.Max(0, titik[i])
+		batasBawah, batasAtas := math.Max(0, bawah[i]), math.Max(0, atas[i])
+
+		galat := aktual - ramalan
+		totalAbsolut += math.Abs(galat)
+		totalKuadrat += galat * galat
+		if aktual != 0 {
+			totalPersen += math.Abs(galat / aktual)
+			jumlahPersen++
+		}
+		if aktual >= batasBawah && aktual <= batasAtas {
+			tercakup++
+		}
+
+		hasil.Data = append(hasil.Data, models.TitikBacktest{
+			Bulan:      daftarBulan[latih+i],
+			Aktual:     aktual,
+			Ramalan:    math.Round(ramalan),
+			BatasBawah: math.Round(batasBawah),
+			BatasAtas:  math.Round(batasAtas),
+		})
+	}
+
+	hasil.MAE = math.Round(totalAbsolut / float64(n))
+	hasil.RMSE = math.Round(math.Sqrt(totalKuadrat / float64(n)))
+	if jumlahPersen > 0 {
+		mape := math.Round(totalPersen/float64(jumlahPersen)*1000) / 10
+		hasil.MAPE = &mape
+	}
+	hasil.CakupanInterval = math.Round(float64(tercakup)/float64(n)*1000) / 10
+	return hasil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"siak-rsbw/backend/utils"
+	"time"
+)
+
+// formatWaktuSQL adalah format timestamp yang dikirim ke MySQL sebagai batas rentang
+const formatWaktuSQL = "2006-01-02 15:04:05"
+
+// rentangTanggal adalah rentang tanggal laporan yang dipilih pengguna (inklusif, YYYY-MM-DD)
+// beserta padanannya sebagai rentang timestamp setengah terbuka [mulai, selesai) pada zona
+// waktu rumah sakit.
+//
+// Kolom Khanza seperti nota_jalan.tanggal, nota_inap.tanggal dan piutang_pasien.tgl_piutang
+// bisa bertipe DATETIME, sehingga BETWEEN '2024-05-01' AND '2024-05-31' membuang transaksi
+// tanggal 31 setelah pukul 00:00. Dengan batas atas eksklusif (tanggal akhir + 1 hari) kondisi
+// kolom >= mulai AND kolom < selesai benar untuk kolom DATE maupun DATETIME.
+type rentangTanggal struct {
+	Awal  string
+	Akhir string
+	// Periode adalah label periode bernama yang menghasilkan rentang ini (contoh: 2024-05,
+	// 2024-Q2, ytd), kosong jika rentang diisi langsung lewat tanggal_awal/tanggal_akhir
+	Periode string
+
+	mulai   time.Time
+	selesai time.Time
+}
+
+// buatRentangTanggal memvalidasi tanggal awal dan akhir lalu menyusun rentang setengah terbuka
+func buatRentangTanggal(tanggalAwal, tanggalAkhir string) (rentangTanggal, error) {
+	if err := validasiRentangTanggal(tanggalAwal, tanggalAkhir); err != nil {
+		return rentangTanggal{}, err
+	}
+
+	loc := utils.ZonaWaktuRS()
+	awal, _ := time.ParseInLocation("2006-01-02", tanggalAwal, loc)
+	akhir, _ := time.ParseInLocation("2006-01-02", tanggalAkhir, loc)
+
+	return rentangTanggal{
+		Awal:    tanggalAwal,
+		Akhir:   tanggalAkhir,
+		mulai:   awal,
+		selesai: akhir.AddDate(0, 0, 1),
+	}, nil
+}
+
+// Mulai adalah batas bawah rentang (inklusif), pukul 00:00:00 tanggal awal
+func (rt rentangTanggal) Mulai() time.Time {
+	return rt.mulai
+}
+
+// Selesai adalah batas atas rentang (eksklusif), pukul 00:00:00 sehari setelah tanggal akhir
+func (rt rentangTanggal) Selesai() time.Time {
+	return rt.selesai
+}
+
+// MulaiSQL adalah batas bawah dalam format timestamp MySQL
+func (rt rentangTanggal) MulaiSQL() string {
+	return rt.mulai.Format(formatWaktuSQL)
+}
+
+// SelesaiSQL adalah batas atas eksklusif dalam format timestamp MySQL
+func (rt rentangTanggal) SelesaiSQL() string {
+	return rt.selesai.Format(formatWaktuSQL)
+}
+
+// Parameter mengembalikan parameter bernama @awal (inklusif) dan @akhir (eksklusif) untuk
+// query dengan kondisi kolom >= @awal AND kolom < @akhir
+func (rt rentangTanggal) Parameter() map[string]interface{} {
+	return map[string]interface{}{
+		"awal":  rt.MulaiSQL(),
+		"akhir": rt.SelesaiSQL(),
+	}
+}
+
+// Mencakup memeriksa apakah waktu t berada di dalam rentang
+func (rt rentangTanggal) Mencakup(t time.Time) bool {
+	return !t.Before(rt.mulai) && t.Before(rt.selesai)
+}
+
+// SejakAwalTahunFiskal mengembalikan rentang dari awal tahun fiskal yang memuat tanggal akhir
+// sampai tanggal akhir, dipakai untuk saldo posisi keuangan dan kumulatif tahun berjalan (YTD)
+func (rt rentangTanggal) SejakAwalTahunFiskal(bulanAwal time.Month) rentangTanggal {
+	akhir := rt.selesai.AddDate(0, 0, -1)
+	awal := awalTahunFiskal(tahunFiskalDari(akhir, bulanAwal), bulanAwal, akhir.Location())
+	return rentangTanggal{
+		Awal:    awal.Format("2006-01-02"),
+		Akhir:   rt.Akhir,
+		Periode: rt.Periode,
+		mulai:   awal,
+		selesai: rt.selesai,
+	}
+}
+
+// rentangBulan mengembalikan rentang satu bulan penuh yang memuat waktu t
+func rentangBulan(t time.Time) rentangTanggal {
+	t = t.In(utils.ZonaWaktuRS())
+	awal := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	selesai := awal.AddDate(0, 1, 0)
+	return rentangTanggal{
+		Awal:    awal.Format("2006-01-02"),
+		Akhir:   selesai.AddDate(0, 0, -1).Format("2006-01-02"),
+		Periode: awal.Format("2006-01"),
+		mulai:   awal,
+		selesai: selesai,
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"siak-rsbw/backend/utils"
+)
+
+func TestRentangBulan(t *testing.T) {
+	wib := utils.ZonaWaktuRS()
+	tests := []struct {
+		nama    string
+		waktu   time.Time
+		awal    string
+		akhir   string
+		periode string
+		selesai time.Time
+	}{
+		{"februari kabisat", time.Date(2024, time.February, 15, 10, 0, 0, 0, wib),
+			"2024-02-01", "2024-02-29", "2024-02", time.Date(2024, time.March, 1, 0, 0, 0, 0, wib)},
+		{"februari bukan kabisat", time.Date(2023, time.February, 28, 23, 59, 59, 0, wib),
+			"2023-02-01", "2023-02-28", "2023-02", time.Date(2023, time.March, 1, 0, 0, 0, 0, wib)},
+		{"bulan 30 hari", time.Date(2024, time.April, 1, 0, 0, 0, 0, wib),
+			"2024-04-01", "2024-04-30", "2024-04", time.Date(2024, time.May, 1, 0, 0, 0, 0, wib)},
+		{"bulan 31 hari", time.Date(2024, time.July, 31, 12, 0, 0, 0, wib),
+			"2024-07-01", "2024-07-31", "2024-07", time.Date(2024, time.August, 1, 0, 0, 0, 0, wib)},
+		{"desember ke januari", time.Date(2024, time.December, 31, 23, 0, 0, 0, wib),
+			"2024-12-01", "2024-12-31", "2024-12", time.Date(2025, time.January, 1, 0, 0, 0, 0, wib)},
+		// 31 Januari 18:00 UTC sudah 1 Februari 01:00 WIB
+		{"waktu UTC dinilai menurut WIB", time.Date(2024, time.January, 31, 18, 0, 0, 0, time.UTC),
+			"2024-02-01", "2024-02-29", "2024-02", time.Date(2024, time.March, 1, 0, 0, 0, 0, wib)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rt := rentangBulan(tt.waktu)
+			if rt.Awal != tt.awal || rt.Akhir != tt.akhir || rt.Periode != tt.periode {
+				t.Errorf("rentang = %s s/d %s (%s), ingin %s s/d %s (%s)",
+					rt.Awal, rt.Akhir, rt.Periode, tt.awal, tt.akhir, tt.periode)
+			}
+			if !rt.Selesai().Equal(tt.selesai) {
+				t.Errorf("Selesai() = %s, ingin %s", rt.Selesai(), tt.selesai)
+			}
+		})
+	}
+}
+
+func TestBuatRentangTanggal(t *testing.T) {
+	wib := utils.ZonaWaktuRS()
+	tests := []struct {
+		nama       string
+		awal       string
+		akhir      string
+		mulaiSQL   string
+		selesaiSQL string
+	}{
+		{"satu hari", "2024-05-31", "2024-05-31", "2024-05-31 00:00:00", "2024-06-01 00:00:00"},
+		{"februari kabisat", "2024-02-01", "2024-02-29", "2024-02-01 00:00:00", "2024-03-01 00:00:00"},
+		{"februari bukan kabisat", "2023-02-01", "2023-02-28", "2023-02-01 00:00:00", "2023-03-01 00:00:00"},
+		{"bulan 30 hari", "2024-06-01", "2024-06-30", "2024-06-01 00:00:00", "2024-07-01 00:00:00"},
+		{"bulan 31 hari", "2024-08-01", "2024-08-31", "2024-08-01 00:00:00", "2024-09-01 00:00:00"},
+		{"melewati pergantian tahun", "2024-12-15", "2025-01-15", "2024-12-15 00:00:00", "2025-01-16 00:00:00"},
+		{"akhir desember", "2024-12-31", "2024-12-31", "2024-12-31 00:00:00", "2025-01-01 00:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rt, err := buatRentangTanggal(tt.awal, tt.akhir)
+			if err != nil {
+				t.Fatalf("buatRentangTanggal(%s, %s) error: %v", tt.awal, tt.akhir, err)
+			}
+			if rt.MulaiSQL() != tt.mulaiSQL || rt.SelesaiSQL() != tt.selesaiSQL {
+				t.Errorf("batas SQL = [%s, %s), ingin [%s, %s)", rt.MulaiSQL(), rt.SelesaiSQL(), tt.mulaiSQL, tt.selesaiSQL)
+			}
+
+			// Selesai tepat pukul 00:00 WIB sehari setelah tanggal akhir
+			akhir, _ := time.ParseInLocation("2006-01-02", tt.akhir, wib)
+			if ingin := akhir.AddDate(0, 0, 1); !rt.Selesai().Equal(ingin) || rt.Selesai().Location() != wib {
+				t.Errorf("Selesai() = %s, ingin %s", rt.Selesai(), ingin)
+			}
+
+			// Batas bawah inklusif, batas atas eksklusif, juga untuk waktu yang dinyatakan dalam UTC
+			selesai := rt.Selesai()
+			cek := []struct {
+				waktu time.Time
+				ingin bool
+			}{
+				{rt.Mulai(), true},
+				{rt.Mulai().Add(-time.Nanosecond), false},
+				{selesai.Add(-time.Nanosecond), true},
+				{selesai, false},
+				{selesai.UTC(), false},
+				{selesai.UTC().Add(-time.Second), true},
+			}
+			for _, c := range cek {
+				if got := rt.Mencakup(c.waktu); got != c.ingin {
+					t.Errorf("Mencakup(%s) = %v, ingin %v", c.waktu, got, c.ingin)
+				}
+			}
+		})
+	}
+}
+
+func TestBuatRentangTanggalTidakValid(t *testing.T) {
+	tests := []struct {
+		nama  string
+		awal  string
+		akhir string
+	}{
+		{"akhir sebelum awal", "2024-03-01", "2024-02-29"},
+		{"tanggal 29 februari bukan kabisat", "2023-02-01", "2023-02-29"},
+		{"tanggal 31 pada bulan 30 hari", "2024-04-01", "2024-04-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if _, err := buatRentangTanggal(tt.awal, tt.akhir); err == nil {
+				t.Errorf("buatRentangTanggal(%s, %s) tidak mengembalikan error", tt.awal, tt.akhir)
+			}
+		})
+	}
+}
+
+func TestSejakAwalTahunFiskal(t *testing.T) {
+	tests := []struct {
+		nama      string
+		akhir     string
+		bulanAwal time.Month
+		awal      string
+	}{
+		{"tahun kalender", "2024-05-31", time.January, "2024-01-01"},
+		{"fiskal juli sebelum bulan awal", "2024-05-31", time.July, "2023-07-01"},
+		{"fiskal juli pada bulan awal", "2024-07-15", time.July, "2024-07-01"},
+		{"fiskal april akhir desember", "2024-12-31", time.April, "2024-04-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rt, err := buatRentangTanggal(tt.akhir, tt.akhir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ytd := rt.SejakAwalTahunFiskal(tt.bulanAwal)
+			if ytd.Awal != tt.awal || ytd.Akhir != tt.akhir || !ytd.Selesai().Equal(rt.Selesai()) {
+				t.Errorf("rentang = %s s/d %s, ingin %s s/d %s", ytd.Awal, ytd.Akhir, tt.awal, tt.akhir)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+)
+
+// PendapatanResepHandler menangani permintaan laporan pendapatan obat dan BHP dari resep rawat jalan dan rawat inap.
+// Sumber data adalah detail_pemberian_obat dan resep_pulang, dengan margin dihitung terhadap harga beli (h_beli).
+func PendapatanResepHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	// Barang dianggap BHP jika nama jenisnya mengandung BHP atau "habis pakai"
+	query := `
+		SELECT
+			COALESCE(sumber.kd_bangsal, '-') AS kd_bangsal,
+			COALESCE(bangsal.nm_bangsal, '-') AS nm_bangsal,
+			reg_periksa.kd_pj,
+			penjab.png_jawab,
+			sumber.status_lanjut,
+			CASE
+				WHEN jenis.nama LIKE '%BHP%' OR jenis.nama LIKE '%HABIS PAKAI%' THEN 'BHP'
+				ELSE 'Obat'
+			END AS jenis,
+			COUNT(DISTINCT sumber.no_resep) AS jumlah_resep,
+			SUM(sumber.jml) AS jumlah,
+			SUM(sumber.pendapatan) AS pendapatan,
+			SUM(sumber.modal) AS modal
+		FROM
+		(
+			SELECT
+				detail_pemberian_obat.no_rawat,
+				detail_pemberian_obat.kd_bangsal,
+				detail_pemberian_obat.status AS status_lanjut,
+				detail_pemberian_obat.kode_brng,
+				(
+					-- Subquery skalar, bukan join: beberapa resep_obat bisa berbagi no_rawat, tanggal
+					-- dan jam yang sama sehingga join menggandakan pendapatan dan modal
+					SELECT MIN(resep_obat.no_resep)
+					FROM resep_obat
+					WHERE resep_obat.no_rawat = detail_pemberian_obat.no_rawat
+						AND resep_obat.tgl_perawatan = detail_pemberian_obat.tgl_perawatan
+						AND resep_obat.jam = detail_pemberian_obat.jam
+				) AS no_resep,
+				detail_pemberian_obat.jml,
+				detail_pemberian_obat.total AS pendapatan,
+				detail_pemberian_obat.h_beli * detail_pemberian_obat.jml AS modal
+			FROM
+				detail_pemberian_obat
+			WHERE
+				detail_pemberian_obat.tgl_perawatan >= @awal AND detail_pemberian_obat.tgl_perawatan < @akhir
+
+			UNION ALL
+
+			SELECT
+				resep_pulang.no_rawat,
+				resep_pulang.kd_bangsal,
+				'Ranap' AS status_lanjut,
+				resep_pulang.kode_brng,
+				NULL AS no_resep,
+				resep_pulang.jml_barang AS jml,
+				resep_pulang.total AS pendapatan,
+				databarang.h_beli * resep_pulang.jml_barang AS modal
+			FROM
+				resep_pulang
+			INNER JOIN databarang ON databarang.kode_brng = resep_pulang.kode_brng
+			WHERE
+				resep_pulang.tanggal >= @awal AND resep_pulang.tanggal < @akhir
+		) AS sumber
+		INNER JOIN reg_periksa ON reg_periksa.no_rawat = sumber.no_rawat
+		INNER JOIN penjab ON penjab.kd_pj = reg_periksa.kd_pj
+		INNER JOIN databarang ON databarang.kode_brng = sumber.kode_brng
+		LEFT JOIN jenis ON jenis.kdjns = databarang.kdjns
+		LEFT JOIN bangsal ON bangsal.kd_bangsal = sumber.kd_bangsal
+		GROUP BY
+			sumber.kd_bangsal, reg_periksa.kd_pj, sumber.status_lanjut, jenis
+		ORDER BY
+			pendapatan DESC
+	`
+
+	var result []models.PendapatanResep
+	err = db.Raw(query, rentang.Parameter()).Scan(&result).Error
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query", err)
+		return
+	}
+
+	// Jika tidak ada hasil, kembalikan array kosong
+	if result == nil {
+		result = []models.PendapatanResep{}
+	}
+
+	var totalPendapatan, totalModal float64
+	for i := range result {
+		result[i].Margin = result[i].Pendapatan - result[i].Modal
+		totalPendapatan += result[i].Pendapatan
+		totalModal += result[i].Modal
+	}
+
+	// Pendapatan pembayaran pasien (nota rawat jalan dan rawat inap) sebagai pembanding kontribusi farmasi
+	var totalPendapatanRS struct {
+		Total float64
+	}
+	totalRSQuery := `
+		SELECT
+			COALESCE((
+				SELECT SUM(detail_nota_jalan.besar_bayar)
+				FROM nota_jalan
+				INNER JOIN detail_nota_jalan ON detail_nota_jalan.no_rawat = nota_jalan.no_rawat
+				WHERE nota_jalan.tanggal >= @awal AND nota_jalan.tanggal < @akhir
+			), 0) +
+			COALESCE((
+				SELECT SUM(detail_nota_inap.besar_bayar)
+				FROM nota_inap
+				INNER JOIN detail_nota_inap ON detail_nota_inap.no_rawat = nota_inap.no_rawat
+				WHERE nota_inap.tanggal >= @awal AND nota_inap.tanggal < @akhir
+			), 0) AS total
+	`
+	if err := db.Raw(totalRSQuery, rentang.Parameter()).Scan(&totalPendapatanRS).Error; err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query total pendapatan RS", err)
+		return
+	}
+
+	var persenKontribusi float64
+	if totalPendapatanRS.Total > 0 {
+		persenKontribusi = totalPendapatan / totalPendapatanRS.Total * 100
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data pendapatan resep berhasil diambil dari database",
+		"filter": map[string]string{
+			"tanggal_awal":  tanggalAwal,
+			"tanggal_akhir": tanggalAkhir,
+			"periode":       rentang.Periode,
+		},
+		"total_data":       len(result),
+		"total_pendapatan": totalPendapatan,
+		"total_modal":      totalModal,
+		"total_margin":     totalPendapatan - totalModal,
+		"rekap_depo": rekapPendapatanResepPer(result, func(item models.PendapatanResep) (string, string) {
+			return item.KdBangsal, item.NmBangsal
+		}),
+		"rekap_penjab": rekapPendapatanResepPer(result, func(item models.PendapatanResep) (string, string) {
+			return item.KdPj, item.PngJawab
+		}),
+		"rekap_status_lanjut": rekapPendapatanResepPer(result, func(item models.PendapatanResep) (string, string) {
+			return item.StatusLanjut, item.StatusLanjut
+		}),
+		"rekap_jenis": rekapPendapatanResepPer(result, func(item models.PendapatanResep) (string, string) {
+			return item.Jenis, item.Jenis
+		}),
+		"total_pendapatan_pembayaran_pasien": totalPendapatanRS.Total,
+		"persen_kontribusi_farmasi":          persenKontribusi,
+		"keterangan":                         "Pendapatan resep dari detail_pemberian_obat dan resep_pulang, margin dihitung terhadap harga beli (h_beli)",
+		"data":                               result,
+	}
+
+	writeJSON(w, response)
+}
+
+// rekapPendapatanResepPer mengelompokkan pendapatan resep berdasarkan kunci yang dipilih
+func rekapPendapatanResepPer(data []models.PendapatanResep, kunci func(models.PendapatanResep) (string, string)) []models.RekapPendapatanResep {
+	rekapPerKode := map[string]*models.RekapPendapatanResep{}
+	for _, item := range data {
+		kode, nama := kunci(item)
+		rekap, ok := rekapPerKode[kode]
+		if !ok {
+			rekap = &models.RekapPendapatanResep{Kode: kode, Nama: nama}
+			rekapPerKode[kode] = rekap
+		}
+		rekap.Pendapatan += item.Pendapatan
+		rekap.Modal += item.Modal
+	}
+
+	result := []models.RekapPendapatanResep{}
+	for _, rekap := range rekapPerKode {
+		rekap.Margin = rekap.Pendapatan - rekap.Modal
+		if rekap.Pendapatan != 0 {
+			rekap.PersenMargin = rekap.Margin / rekap.Pendapatan * 100
+		}
+		result = append(result, *rekap)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Pendapatan > result[j].Pendapatan
+	})
+	return result
+}
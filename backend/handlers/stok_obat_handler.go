@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+)
+
+// StokObatHandler menangani permintaan laporan mutasi dan nilai persediaan farmasi per barang dan per depo.
+//
+// Stok akhir periode dihitung mundur dari stok gudangbarang saat ini dikurangi pergerakan
+// riwayat_barang_medis setelah tanggal_akhir, lalu stok awal = stok akhir - pergerakan selama periode.
+// Dengan cara ini riwayat hanya perlu dibaca mulai dari tanggal_awal.
+func StokObatHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Ambil parameter dari query URL
+	rentang, err := getRentangTanggal(r)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	tanggalAwal, tanggalAkhir := rentang.Awal, rentang.Akhir
+	kdBangsal := r.URL.Query().Get("kd_bangsal")
+	tampilkanSemua, err := getParamBool(r, "tampilkan_semua")
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	db, selesai := getKoneksiLaporan(w, r)
+	if db == nil {
+		return
+	}
+	defer selesai()
+
+	// Pergerakan riwayat dikelompokkan berdasarkan posisi transaksi Khanza.
+	// Baris berstatus Hapus ikut dihitung karena berisi pembalik (masuk/keluar) dari transaksi yang dihapus.
+	query := `
+		SELECT
+			gudang.kode_brng,
+			databarang.nama_brng,
+			databarang.kode_sat,
+			gudang.kd_bangsal,
+			bangsal.nm_bangsal,
+			databarang.h_beli,
+			gudang.stok AS stok_sekarang,
+			COALESCE(mutasi.penerimaan, 0) AS penerimaan,
+			COALESCE(mutasi.keluar_resep, 0) AS keluar_resep,
+			COALESCE(mutasi.keluar_penjualan, 0) AS keluar_penjualan,
+			COALESCE(mutasi.mutasi_masuk, 0) AS mutasi_masuk,
+			COALESCE(mutasi.mutasi_keluar, 0) AS mutasi_keluar,
+			COALESCE(mutasi.retur_masuk, 0) AS retur_masuk,
+			COALESCE(mutasi.retur_keluar, 0) AS retur_keluar,
+			COALESCE(mutasi.penyesuaian, 0) AS penyesuaian,
+			COALESCE(mutasi.net_setelah_akhir, 0) AS net_setelah_akhir
+		FROM
+		(
+			SELECT kode_brng, kd_bangsal, SUM(stok) AS stok
+			FROM gudangbarang
+			GROUP BY kode_brng, kd_bangsal
+		) AS gudang
+		INNER JOIN databarang ON databarang.kode_brng = gudang.kode_brng
+		INNER JOIN bangsal ON bangsal.kd_bangsal = gudang.kd_bangsal
+		LEFT JOIN
+		(
+			SELECT
+				kode_brng,
+				kd_bangsal,
+				SUM(CASE WHEN tanggal < @akhir AND posisi IN ('Penerimaan', 'Pengadaan', 'Hibah')
+					THEN masuk - keluar ELSE 0 END) AS penerimaan,
+				SUM(CASE WHEN tanggal < @akhir AND posisi IN ('Pemberian Obat', 'Resep Pulang', 'Resep Luar', 'Stok Pasien Ranap')
+					THEN keluar - masuk ELSE 0 END) AS keluar_resep,
+				SUM(CASE WHEN tanggal < @akhir AND posisi IN ('Penjualan', 'Piutang')
+					THEN keluar - masuk ELSE 0 END) AS keluar_penjualan,
+				SUM(CASE WHEN tanggal < @akhir AND posisi = 'Mutasi' THEN masuk ELSE 0 END) AS mutasi_masuk,
+				SUM(CASE WHEN tanggal < @akhir AND posisi = 'Mutasi' THEN keluar ELSE 0 END) AS mutasi_keluar,
+				SUM(CASE WHEN tanggal < @akhir AND posisi LIKE 'Retur%' THEN masuk ELSE 0 END) AS retur_masuk,
+				SUM(CASE WHEN tanggal < @akhir AND posisi LIKE 'Retur%' THEN keluar ELSE 0 END) AS retur_keluar,
+				SUM(CASE WHEN tanggal < @akhir
+					AND posisi NOT IN ('Penerimaan', 'Pengadaan', 'Hibah', 'Pemberian Obat', 'Resep Pulang',
+						'Resep Luar', 'Stok Pasien Ranap', 'Penjualan', 'Piutang', 'Mutasi')
+					AND posisi NOT LIKE 'Retur%'
+					THEN masuk - keluar ELSE 0 END) AS penyesuaian,
+				SUM(CASE WHEN tanggal >= @akhir THEN masuk - keluar ELSE 0 END) AS net_setelah_akhir
+			FROM riwayat_barang_medis
+			WHERE tanggal >= @awal
+			GROUP BY kode_brng, kd_bangsal
+		) AS mutasi ON mutasi.kode_brng = gudang.kode_brng AND mutasi.kd_bangsal = gudang.kd_bangsal
+		WHERE
+			(@bangsal = '' OR gudang.kd_bangsal = @bangsal)
+		ORDER BY
+			bangsal.nm_bangsal, databarang.nama_brng
+	`
+
+	var rows []models.MutasiStokObat
+	err = db.Raw(query, map[string]interface{}{
+		"awal":    rentang.MulaiSQL(),
+		"akhir":   rentang.SelesaiSQL(),
+		"bangsal": kdBangsal,
+	}).Scan(&rows).Error
+	if err != nil {
+		tulisErrorQuery(w, r, "Gagal menjalankan query", err)
+		return
+	}
+
+	// Hitung stok awal, stok akhir dan nilai persediaan
+	result := []models.MutasiStokObat{}
+	rekapPerDepo := map[string]*models.RekapStokDepo{}
+	var totalNilaiAwal, totalNilaiPenerimaan, totalNilaiKeluar, totalNilaiAkhir float64
+
+	for _, item := range rows {
+		totalKeluar := item.KeluarResep + item.KeluarPenjualan + item.MutasiKeluar + item.ReturKeluar
+		netPeriode := item.Penerimaan + item.MutasiMasuk + item.ReturMasuk + item.Penyesuaian - totalKeluar
+
+		item.StokAkhir = item.StokSekarang - item.NetSetelahAkhir
+		item.StokAwal = item.StokAkhir - netPeriode
+
+		// Lewati barang tanpa stok dan tanpa pergerakan kecuali diminta
+		if !tampilkanSemua && item.StokAwal == 0 && item.StokAkhir == 0 && netPeriode == 0 && totalKeluar == 0 {
+			continue
+		}
+
+		item.NilaiAwal = item.StokAwal * item.HBeli
+		item.NilaiPenerimaan = item.Penerimaan * item.HBeli
+		item.NilaiKeluar = totalKeluar * item.HBeli
+		item.NilaiAkhir = item.StokAkhir * item.HBeli
+		result = append(result, item)
+
+		rekap, ok := rekapPerDepo[item.KdBangsal]
+		if !ok {
+			rekap = &models.RekapStokDepo{KdBangsal: item.KdBangsal, NmBangsal: item.NmBangsal}
+			rekapPerDepo[item.KdBangsal] = rekap
+		}
+		rekap.JumlahItem++
+		rekap.NilaiAwal += item.NilaiAwal
+		rekap.NilaiPenerimaan += item.NilaiPenerimaan
+		rekap.NilaiKeluar += item.NilaiKeluar
+		rekap.NilaiAkhir += item.NilaiAkhir
+
+		totalNilaiAwal += item.NilaiAwal
+		totalNilaiPenerimaan += item.NilaiPenerimaan
+		totalNilaiKeluar += item.NilaiKeluar
+		totalNilaiAkhir += item.NilaiAkhir
+	}
+
+	rekapDepo := []models.RekapStokDepo{}
+	for _, rekap := range rekapPerDepo {
+		rekapDepo = append(rekapDepo, *rekap)
+	}
+	sort.Slice(rekapDepo, func(i, j int) bool {
+		return rekapDepo[i].NmBangsal < rekapDepo[j].NmBangsal
+	})
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Data mutasi stok obat berhasil diambil dari database",
+		"filter": map[string]string{
+			"tanggal_awal":  tanggalAwal,
+			"tanggal_akhir": tanggalAkhir,
+			"periode":       rentang.Periode,
+			"kd_bangsal":    kdBangsal,
+		},
+		"total_data":             len(result),
+		"total_nilai_awal":       totalNilaiAwal,
+		"total_nilai_penerimaan": totalNilaiPenerimaan,
+		"total_nilai_keluar":     totalNilaiKeluar,
+		"total_nilai_akhir":      totalNilaiAkhir,
+		"rekap_depo":             rekapDepo,
+		"keterangan":             "Nilai persediaan dihitung dengan harga beli rata-rata (h_beli) pada databarang",
+		"data":                   result,
+	}
+
+	writeJSON(w, response)
+}
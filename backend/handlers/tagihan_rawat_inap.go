@@ -0,0 +1,73 @@
+package handlers
+
+import "siak-rsbw/backend/models"
+
+// gabungkanKamarRawatInap menggabungkan baris kamar_inap menjadi satu baris per no_rawat.
+// Tagihan setiap no_rawat dihitung sekali walaupun pasien pindah kamar beberapa kali.
+// Tanggal masuk adalah tanggal masuk kamar pertama dan tanggal keluar adalah tanggal keluar
+// kamar terakhir (baris selain "Pindah Kamar"), sedangkan bangsal adalah bangsal kamar terakhir
+// sesuai urutan baris. Setiap kamar tetap disimpan sebagai detail beserta lamanya; urutan
+// kemunculan no_rawat dipertahankan.
+func gabungkanKamarRawatInap(baris []models.KamarRawatInap) []models.LaporanRawatInap {
+	hasil := []models.LaporanRawatInap{}
+	indeks := map[string]int{}
+
+	for _, kamar := range baris {
+		detail := models.DetailKamarInap{
+			KdKamar:    kamar.KdKamar,
+			KdBangsal:  kamar.KdBangsal,
+			NmBangsal:  kamar.NmBangsal,
+			TglMasuk:   kamar.TglMasuk,
+			TglKeluar:  kamar.TglKeluar,
+			Lama:       kamar.Lama,
+			SttsPulang: kamar.SttsPulang,
+		}
+
+		i, ada := indeks[kamar.NoRawat]
+		if !ada {
+			indeks[kamar.NoRawat] = len(hasil)
+			item := models.LaporanRawatInap{
+				NoRawat:    kamar.NoRawat,
+				NoRkmMedis: kamar.NoRkmMedis,
+				NmPasien:   kamar.NmPasien,
+				TglMasuk:   kamar.TglMasuk,
+				KdBangsal:  kamar.KdBangsal,
+				NmBangsal:  kamar.NmBangsal,
+				NoNota:     kamar.NoNota,
+				Tanggal:    kamar.Tanggal,
+				BesarBayar: kamar.BesarBayar,
+				PngJawab:   kamar.PngJawab,
+				KdPj:       kamar.KdPj,
+				LamaInap:   kamar.Lama,
+				Kamar:      []models.DetailKamarInap{detail},
+			}
+			if kamar.SttsPulang != "Pindah Kamar" {
+				item.TglKeluar = kamar.TglKeluar
+			}
+			hasil = append(hasil, item)
+			continue
+		}
+
+		item := &hasil[i]
+		item.KdBangsal, item.NmBangsal = kamar.KdBangsal, kamar.NmBangsal
+		item.LamaInap += kamar.Lama
+		item.Kamar = append(item.Kamar, detail)
+		if kamar.TglMasuk.Before(item.TglMasuk) {
+			item.TglMasuk = kamar.TglMasuk
+		}
+		if kamar.SttsPulang != "Pindah Kamar" && kamar.TglKeluar.After(item.TglKeluar) {
+			item.TglKeluar = kamar.TglKeluar
+		}
+	}
+
+	return hasil
+}
+
+// totalTagihanRawatInap menjumlahkan tagihan rawat inap yang sudah digabung per no_rawat
+func totalTagihanRawatInap(data []models.LaporanRawatInap) float64 {
+	var total float64
+	for _, item := range data {
+		total += item.BesarBayar
+	}
+	return total
+}
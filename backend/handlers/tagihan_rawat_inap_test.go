@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"siak-rsbw/backend/models"
+)
+
+func TestGabungkanKamarRawatInapPindahKamar(t *testing.T) {
+	tanggal := func(hari int) time.Time {
+		return time.Date(2024, time.March, hari, 0, 0, 0, 0, time.Local)
+	}
+	// Satu rawat inap yang pindah kamar dua kali; setiap baris kamar membawa tagihan nota_inap
+	// yang sama karena tagihan dihitung per no_rawat
+	baris := []models.KamarRawatInap{
+		{NoRawat: "2024/03/01/000001", NoRkmMedis: "000123", NmPasien: "PASIEN A", KdKamar: "VIP.01",
+			KdBangsal: "VIP", NmBangsal: "Bangsal VIP", TglMasuk: tanggal(1), TglKeluar: tanggal(3), Lama: 2,
+			SttsPulang: "Pindah Kamar", NoNota: "RI/001", Tanggal: tanggal(9), BesarBayar: 1500000, KdPj: "UMU"},
+		{NoRawat: "2024/03/01/000001", NoRkmMedis: "000123", NmPasien: "PASIEN A", KdKamar: "KLS1.02",
+			KdBangsal: "KLS1", NmBangsal: "Bangsal Kelas 1", TglMasuk: tanggal(3), TglKeluar: tanggal(6), Lama: 3,
+			SttsPulang: "Pindah Kamar", NoNota: "RI/001", Tanggal: tanggal(9), BesarBayar: 1500000, KdPj: "UMU"},
+		{NoRawat: "2024/03/01/000001", NoRkmMedis: "000123", NmPasien: "PASIEN A", KdKamar: "KLS2.05",
+			KdBangsal: "KLS2", NmBangsal: "Bangsal Kelas 2", TglMasuk: tanggal(6), TglKeluar: tanggal(9), Lama: 3,
+			SttsPulang: "Sehat", NoNota: "RI/001", Tanggal: tanggal(9), BesarBayar: 1500000, KdPj: "UMU"},
+	}
+
+	hasil := gabungkanKamarRawatInap(baris)
+	if len(hasil) != 1 {
+		t.Fatalf("jumlah baris = %d, ingin 1", len(hasil))
+	}
+
+	item := hasil[0]
+	if !item.TglMasuk.Equal(tanggal(1)) || !item.TglKeluar.Equal(tanggal(9)) {
+		t.Errorf("tgl_masuk/tgl_keluar = %s/%s, ingin %s/%s",
+			item.TglMasuk.Format("2006-01-02"), item.TglKeluar.Format("2006-01-02"), "2024-03-01", "2024-03-09")
+	}
+	if item.KdBangsal != "KLS2" {
+		t.Errorf("kd_bangsal = %q, ingin bangsal kamar terakhir %q", item.KdBangsal, "KLS2")
+	}
+	if item.LamaInap != 8 {
+		t.Errorf("lama_inap = %v, ingin 8", item.LamaInap)
+	}
+
+	if len(item.Kamar) != 3 {
+		t.Fatalf("jumlah detail kamar = %d, ingin 3", len(item.Kamar))
+	}
+	for i, kamar := range item.Kamar {
+		if kamar.KdKamar != baris[i].KdKamar || kamar.Lama != baris[i].Lama || kamar.SttsPulang != baris[i].SttsPulang {
+			t.Errorf("detail kamar #%d = %+v, ingin kamar %s lama %v (%s)",
+				i+1, kamar, baris[i].KdKamar, baris[i].Lama, baris[i].SttsPulang)
+		}
+	}
+
+	if total := totalTagihanRawatInap(hasil); total != 1500000 {
+		t.Errorf("total tagihan = %.0f, ingin 1500000 (nota_inap dihitung sekali)", total)
+	}
+}
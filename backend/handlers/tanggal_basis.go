@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"siak-rsbw/backend/utils"
+	"strings"
+)
+
+// basisTanggal adalah satu dasar tanggal yang dapat dipakai untuk memfilter laporan.
+// Kondisi berupa ekspresi SQL terhadap reg_periksa dengan parameter @awal (inklusif) dan
+// @akhir (eksklusif) dari rentangTanggal.Parameter, sehingga setiap no_rawat hanya dinilai sekali berapapun jumlah baris kamar/nota-nya.
+type basisTanggal struct {
+	Nama       string
+	Keterangan string
+	Kondisi    string
+}
+
+// aturanBasisTanggal berisi basis tanggal yang didukung satu laporan
+type aturanBasisTanggal struct {
+	Default string
+	Basis   []basisTanggal
+	// Alias memetakan nilai lama parameter filter_by ke nama basis
+	Alias map[string]string
+}
+
+// Kondisi basis tanggal yang dipakai bersama oleh beberapa laporan
+const (
+	kondisiRegistrasi = `reg_periksa.tgl_registrasi >= @awal AND reg_periksa.tgl_registrasi < @akhir`
+
+	// Tanggal masuk adalah tanggal masuk kamar pertama; pindah kamar tidak dihitung sebagai masuk baru
+	kondisiMasukInap = `EXISTS (SELECT 1 FROM kamar_inap
+		WHERE kamar_inap.no_rawat = reg_periksa.no_rawat
+		HAVING MIN(kamar_inap.tgl_masuk) >= @awal AND MIN(kamar_inap.tgl_masuk) < @akhir)`
+
+	// Tanggal pulang adalah tanggal keluar kamar terakhir; baris "Pindah Kamar" bukan kepulangan
+	kondisiPulangInap = `EXISTS (SELECT 1 FROM kamar_inap
+		WHERE kamar_inap.no_rawat = reg_periksa.no_rawat
+			AND kamar_inap.stts_pulang <> 'Pindah Kamar'
+			AND kamar_inap.tgl_keluar >= @awal AND kamar_inap.tgl_keluar < @akhir)`
+
+	kondisiBayarInap = `EXISTS (SELECT 1 FROM nota_inap
+		WHERE nota_inap.no_rawat = reg_periksa.no_rawat
+			AND nota_inap.tanggal >= @awal AND nota_inap.tanggal < @akhir)`
+
+	kondisiBayarJalan = `EXISTS (SELECT 1 FROM nota_jalan
+		WHERE nota_jalan.no_rawat = reg_periksa.no_rawat
+			AND nota_jalan.tanggal >= @awal AND nota_jalan.tanggal < @akhir)`
+
+	kondisiPiutang = `piutang_pasien.tgl_piutang >= @awal AND piutang_pasien.tgl_piutang < @akhir`
+)
+
+// basisTanggalLaporan berisi basis tanggal yang didukung setiap laporan pasien
+var basisTanggalLaporan = map[string]aturanBasisTanggal{
+	"rawat-inap": {
+		Default: "keduanya",
+		Basis: []basisTanggal{
+			{Nama: "registrasi", Keterangan: "Tanggal registrasi (reg_periksa.tgl_registrasi)", Kondisi: kondisiRegistrasi},
+			{Nama: "masuk", Keterangan: "Tanggal masuk kamar pertama", Kondisi: kondisiMasukInap},
+			{Nama: "pulang", Keterangan: "Tanggal pulang (keluar kamar terakhir, bukan pindah kamar)", Kondisi: kondisiPulangInap},
+			{Nama: "bayar", Keterangan: "Tanggal nota pembayaran (nota_inap.tanggal)", Kondisi: kondisiBayarInap},
+			{Nama: "keduanya", Keterangan: "Tanggal masuk atau tanggal pulang berada dalam periode; setiap rawat dihitung sekali",
+				Kondisi: "(" + kondisiMasukInap + " OR " + kondisiPulangInap + ")"},
+		},
+		Alias: map[string]string{"tgl_masuk": "masuk", "tgl_keluar": "pulang", "both": "keduanya"},
+	},
+	"rawat-jalan": {
+		Default: "registrasi",
+		Basis: []basisTanggal{
+			{Nama: "registrasi", Keterangan: "Tanggal registrasi (reg_periksa.tgl_registrasi)", Kondisi: kondisiRegistrasi},
+			{Nama: "bayar", Keterangan: "Tanggal nota pembayaran (nota_jalan.tanggal)", Kondisi: kondisiBayarJalan},
+			{Nama: "keduanya", Keterangan: "Tanggal registrasi atau tanggal bayar berada dalam periode; setiap rawat dihitung sekali",
+				Kondisi: "(" + kondisiRegistrasi + " OR " + kondisiBayarJalan + ")"},
+		},
+		Alias: map[string]string{"tgl_registrasi": "registrasi", "tgl_bayar": "bayar", "both": "keduanya"},
+	},
+	"piutang-pasien": {
+		Default: "piutang",
+		Basis: []basisTanggal{
+			{Nama: "piutang", Keterangan: "Tanggal piutang (piutang_pasien.tgl_piutang)", Kondisi: kondisiPiutang},
+			{Nama: "registrasi", Keterangan: "Tanggal registrasi (reg_periksa.tgl_registrasi)", Kondisi: kondisiRegistrasi},
+		},
+	},
+}
+
+// getBasisTanggal membaca parameter tanggal_basis (atau filter_by untuk kompatibilitas) dan
+// memvalidasinya terhadap basis yang didukung laporan. Nilai yang tidak dikenal menghasilkan error.
+func getBasisTanggal(r *http.Request, laporan string) (basisTanggal, error) {
+	aturan, ada := basisTanggalLaporan[laporan]
+	if !ada {
+		return basisTanggal{}, fmt.Errorf("laporan %s tidak mendukung tanggal_basis", laporan)
+	}
+
+	nama := r.URL.Query().Get("tanggal_basis")
+	if nama == "" {
+		if filterBy := r.URL.Query().Get("filter_by"); filterBy != "" {
+			alias, ada := aturan.Alias[filterBy]
+			if !ada {
+				return basisTanggal{}, errValidasi("filter_by", "filter_by tidak dikenal: %s", filterBy)
+			}
+			nama = alias
+		}
+	}
+	if nama == "" {
+		nama = aturan.Default
+	}
+
+	namaValid := make([]string, 0, len(aturan.Basis))
+	for _, basis := range aturan.Basis {
+		if basis.Nama == nama {
+			return basis, nil
+		}
+		namaValid = append(namaValid, basis.Nama)
+	}
+	return basisTanggal{}, errValidasi("tanggal_basis", "tanggal_basis tidak dikenal: %s (pilihan: %s)", nama, strings.Join(namaValid, ", "))
+}
+
+// BasisTanggalHandler menangani permintaan daftar basis tanggal yang didukung setiap laporan
+// beserta penjelasan semantiknya
+func BasisTanggalHandler(w http.ResponseWriter, r *http.Request) {
+	// Set header Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	data := map[string]interface{}{}
+	for laporan, aturan := range basisTanggalLaporan {
+		basis := make([]map[string]string, 0, len(aturan.Basis))
+		for _, b := range aturan.Basis {
+			basis = append(basis, map[string]string{
+				"nama":       b.Nama,
+				"keterangan": b.Keterangan,
+			})
+		}
+		data[laporan] = map[string]interface{}{
+			"default":         aturan.Default,
+			"basis":           basis,
+			"alias_filter_by": aturan.Alias,
+		}
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Daftar basis tanggal laporan",
+		"catatan": "Data piutang pada laporan rawat inap dan rawat jalan selalu difilter berdasarkan tanggal piutang",
+		"data":    data,
+	}
+
+	writeJSON(w, response)
+}
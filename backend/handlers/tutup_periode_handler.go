@@ -0,0 +1,582 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TutupPeriodeRequest menyimpan data permintaan tutup buku
+type TutupPeriodeRequest struct {
+	Periode      string `json:"periode"` // periode bernama (contoh: 2024-05), alternatif dari tanggal_awal/tanggal_akhir
+	TanggalAwal  string `json:"tanggal_awal"`
+	TanggalAkhir string `json:"tanggal_akhir"`
+	Catatan      string `json:"catatan"`
+	// SaldoAwalKas adalah saldo kas fisik awal periode untuk snapshot laporan kas. Wajib diisi
+	// pada tutup buku pertama agar periode berikutnya dapat menurunkan saldo awal kas.
+	SaldoAwalKas *float64 `json:"saldo_awal_kas"`
+}
+
+// SelisihNilai adalah perbedaan satu nilai total antara snapshot dan data live
+type SelisihNilai struct {
+	Field    string  `json:"field"`
+	Snapshot float64 `json:"snapshot"`
+	Live     float64 `json:"live"`
+	Selisih  float64 `json:"selisih"`
+}
+
+// SelisihBaris adalah baris yang ditambah, dihapus atau berubah pada data live
+type SelisihBaris struct {
+	Array    string      `json:"array"`
+	Kunci    string      `json:"kunci"`
+	Snapshot interface{} `json:"snapshot,omitempty"`
+	Live     interface{} `json:"live,omitempty"`
+}
+
+// SelisihLaporan adalah hasil perbandingan snapshot satu laporan dengan data live Khanza
+type SelisihLaporan struct {
+	Laporan       string         `json:"laporan"`
+	Parameter     string         `json:"parameter"`
+	AdaPerubahan  bool           `json:"ada_perubahan"`
+	TotalBerubah  []SelisihNilai `json:"total_berubah"`
+	BarisDitambah []SelisihBaris `json:"baris_ditambah"`
+	BarisDihapus  []SelisihBaris `json:"baris_dihapus"`
+	BarisBerubah  []SelisihBaris `json:"baris_berubah"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// cariPeriodeTutup mencari periode tertutup dengan rentang tanggal yang persis sama
+func cariPeriodeTutup(db *gorm.DB, tanggalAwal, tanggalAkhir string) (*models.PeriodeTutup, error) {
+	var periode models.PeriodeTutup
+	err := db.Where("tanggal_awal = ? AND tanggal_akhir = ?", tanggalAwal, tanggalAkhir).First(&periode).Error
+	if err != nil {
+		return nil, err
+	}
+	return &periode, nil
+}
+
+// cariPeriodeTutupBeririsan mencari periode tertutup pertama yang beririsan dengan rentang tanggal
+func cariPeriodeTutupBeririsan(db *gorm.DB, tanggalAwal, tanggalAkhir string) (*models.PeriodeTutup, error) {
+	var periode models.PeriodeTutup
+	err := db.Where("tanggal_awal <= ? AND tanggal_akhir >= ?", tanggalAkhir, tanggalAwal).
+		Order("tanggal_awal").First(&periode).Error
+	if err != nil {
+		return nil, err
+	}
+	return &periode, nil
+}
+
+// WithPeriodeTutup membungkus handler laporan agar permintaan untuk periode yang sudah ditutup
+// dilayani dari snapshot. Snapshot hanya berlaku untuk rentang yang persis sama dengan periode
+// tutup (tanggal_awal/tanggal_akhir atau periode yang setara). Rentang yang beririsan dengan
+// periode tutup tetapi tidak sama (misalnya satu minggu di dalam bulan yang ditutup), atau
+// kombinasi parameter yang tidak di-snapshot, tetap diambil live dan ditandai lewat header
+// X-Periode-Tutup: live serta objek periode_tutup pada response, karena angkanya dapat berbeda
+// dengan snapshot. Parameter live=true memaksa data diambil langsung dari Khanza.
+func WithPeriodeTutup(nama string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Query().Get("live") == "true" {
+			handler(w, r)
+			return
+		}
+
+		db := utils.GetDB()
+		if db == nil {
+			handler(w, r)
+			return
+		}
+
+		rentang, err := getRentangTanggal(r)
+		if err != nil {
+			tulisErrorValidasi(w, err)
+			return
+		}
+
+		periode, err := cariPeriodeTutup(db, rentang.Awal, rentang.Akhir)
+		if err == gorm.ErrRecordNotFound {
+			beririsan, errIrisan := cariPeriodeTutupBeririsan(db, rentang.Awal, rentang.Akhir)
+			if errIrisan == nil {
+				tandaiLivePeriodeTutup(w, r, handler, beririsan,
+					"Rentang tidak sama dengan periode tutup sehingga data diambil live dan dapat berbeda dengan snapshot")
+				return
+			}
+			if errIrisan != gorm.ErrRecordNotFound {
+				log.Printf("Gagal memeriksa periode tutup untuk %s: %v", nama, errIrisan)
+			}
+			handler(w, r)
+			return
+		}
+		if err != nil {
+			log.Printf("Gagal memeriksa periode tutup untuk %s: %v", nama, err)
+			handler(w, r)
+			return
+		}
+
+		var snapshot models.SnapshotLaporan
+		err = db.Where("periode_id = ? AND laporan = ? AND parameter = ?",
+			periode.ID, nama, normalisasiParameter(r.URL.Query())).First(&snapshot).Error
+		if err != nil {
+			// Kombinasi parameter ini tidak ikut di-snapshot, tampilkan data live
+			tandaiLivePeriodeTutup(w, r, handler, periode,
+				"Kombinasi parameter ini tidak di-snapshot sehingga data diambil live dan dapat berbeda dengan snapshot")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Periode-Tutup", "snapshot")
+		w.Header().Set("X-Snapshot-Dibuat", snapshot.DibuatPada.Format(time.RFC3339))
+		w.Write([]byte(snapshot.Data))
+	}
+}
+
+// tandaiLivePeriodeTutup menjalankan handler untuk permintaan yang menyentuh periode tutup tetapi
+// tidak dapat dilayani dari snapshot, lalu menambahkan objek periode_tutup pada response JSON
+// yang berhasil agar klien tahu angkanya live
+func tandaiLivePeriodeTutup(w http.ResponseWriter, r *http.Request, handler http.HandlerFunc, periode *models.PeriodeTutup, keterangan string) {
+	rekaman := &rekamanResponse{}
+	handler(rekaman, r)
+	if rekaman.status == 0 {
+		rekaman.status = http.StatusOK
+	}
+	for key, values := range rekaman.header {
+		w.Header()[key] = values
+	}
+	w.Header().Set("X-Periode-Tutup", "live")
+
+	body := rekaman.body.Bytes()
+	var isi map[string]json.RawMessage
+	if rekaman.status == http.StatusOK && json.Unmarshal(body, &isi) == nil {
+		tanda, _ := json.Marshal(map[string]interface{}{
+			"sumber":        "live",
+			"id":            periode.ID,
+			"tanggal_awal":  periode.TanggalAwal,
+			"tanggal_akhir": periode.TanggalAkhir,
+			"keterangan":    keterangan,
+		})
+		isi["periode_tutup"] = tanda
+		if baru, err := json.Marshal(isi); err == nil {
+			body = append(baru, '\n')
+			w.Header().Del("Content-Length")
+		}
+	}
+	w.WriteHeader(rekaman.status)
+	w.Write(body)
+}
+
+// buatSnapshotPeriode menjalankan seluruh laporan terdaftar untuk periode yang ditutup.
+// saldoAwalKas (opsional) diteruskan ke laporan kas sebagai saldo_awal tanpa menjadi bagian dari
+// parameter snapshot, sehingga snapshot tetap dilayani untuk permintaan tanpa saldo_awal.
+func buatSnapshotPeriode(ctx context.Context, tanggalAwal, tanggalAkhir string, saldoAwalKas *float64) ([]models.SnapshotLaporan, error) {
+	var snapshots []models.SnapshotLaporan
+	for _, laporan := range daftarLaporan {
+		for _, varian := range laporan.Varian {
+			params := url.Values{}
+			for key, values := range varian {
+				params[key] = values
+			}
+			params.Set("tanggal_awal", tanggalAwal)
+			params.Set("tanggal_akhir", tanggalAkhir)
+			parameter := normalisasiParameter(params)
+			if laporan.Nama == "kas" && saldoAwalKas != nil {
+				params.Set("saldo_awal", strconv.FormatFloat(*saldoAwalKas, 'f', -1, 64))
+			}
+
+			status, body, err := jalankanLaporan(ctx, laporan, params)
+			if err != nil {
+				return nil, fmt.Errorf("laporan %s gagal dijalankan: %w", laporan.Nama, err)
+			}
+			if status != http.StatusOK {
+				return nil, fmt.Errorf("laporan %s (%s) gagal dengan status %d: %s",
+					laporan.Nama, parameter, status, strings.TrimSpace(string(body)))
+			}
+
+			snapshots = append(snapshots, models.SnapshotLaporan{
+				Laporan:    laporan.Nama,
+				Parameter:  parameter,
+				Data:       string(body),
+				DibuatPada: time.Now(),
+			})
+		}
+	}
+	return snapshots, nil
+}
+
+// GetPeriodeTutupHandler menangani permintaan daftar periode yang sudah ditutup
+func GetPeriodeTutupHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	var periode []models.PeriodeTutup
+	if err := utils.GetDB().Order("tanggal_awal desc").Find(&periode).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil data periode tutup: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(periode)
+}
+
+// errPeriodeTumpangTindih menandai periode yang beririsan dengan periode yang sudah ditutup
+var errPeriodeTumpangTindih = errors.New("periode tumpang tindih dengan periode yang sudah ditutup")
+
+// periodeTumpangTindih memeriksa apakah rentang beririsan dengan periode yang sudah ditutup
+func periodeTumpangTindih(db *gorm.DB, tanggalAwal, tanggalAkhir string) (bool, error) {
+	var periode []models.PeriodeTutup
+	err := db.Where("tanggal_awal <= ? AND tanggal_akhir >= ?", tanggalAkhir, tanggalAwal).
+		Limit(1).Find(&periode).Error
+	return len(periode) > 0, err
+}
+
+// TutupPeriodeHandler menangani permintaan tutup buku: seluruh laporan terdaftar untuk periode
+// tersebut dijalankan dan hasilnya disimpan sebagai snapshot dalam satu transaksi
+func TutupPeriodeHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Decode permintaan JSON
+	var req TutupPeriodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	// Periode bernama (YYYY-MM, YYYY-Qn, YYYY, last_month) diubah menjadi tanggal awal dan akhir
+	if req.Periode != "" {
+		rentang, err := resolvePeriode(req.Periode, utils.SekarangRS(), bulanAwalTahunFiskal())
+		if err != nil {
+			tulisErrorValidasi(w, err)
+			return
+		}
+		req.TanggalAwal = rentang.Awal
+		req.TanggalAkhir = rentang.Akhir
+	}
+
+	if err := validasiRentangTanggal(req.TanggalAwal, req.TanggalAkhir); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	if req.TanggalAkhir >= utils.SekarangRS().Format("2006-01-02") {
+		utils.WriteFieldError(w, "tanggal_akhir", "Periode yang belum berakhir tidak dapat ditutup")
+		return
+	}
+
+	db := utils.GetDB()
+
+	// Pemeriksaan awal agar laporan tidak dijalankan untuk periode yang pasti ditolak; pemeriksaan
+	// yang menentukan diulang di dalam transaksi
+	if tumpang, err := periodeTumpangTindih(db, req.TanggalAwal, req.TanggalAkhir); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memeriksa periode tutup: "+err.Error())
+		return
+	} else if tumpang {
+		utils.WriteError(w, http.StatusConflict, "Periode tumpang tindih dengan periode yang sudah ditutup")
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	periode := models.PeriodeTutup{
+		TanggalAwal:  req.TanggalAwal,
+		TanggalAkhir: req.TanggalAkhir,
+		Catatan:      req.Catatan,
+		DitutupOleh:  username,
+		DitutupPada:  time.Now(),
+	}
+
+	// Laporan dijalankan sebelum transaksi dibuka agar transaksi SIAK tidak tertahan query Khanza
+	snapshots, err := buatSnapshotPeriode(r.Context(), periode.TanggalAwal, periode.TanggalAkhir, req.SaldoAwalKas)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat snapshot laporan: "+err.Error())
+		return
+	}
+
+	// Periode tidak boleh tumpang tindih dengan periode yang sudah ditutup. Pemeriksaan memakai
+	// locking read di dalam transaksi yang sama dengan insert sehingga dua penutupan bersamaan
+	// tidak dapat sama-sama lolos.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		tumpang, err := periodeTumpangTindih(tx.Clauses(clause.Locking{Strength: "UPDATE"}), periode.TanggalAwal, periode.TanggalAkhir)
+		if err != nil {
+			return err
+		}
+		if tumpang {
+			return errPeriodeTumpangTindih
+		}
+		if err := tx.Create(&periode).Error; err != nil {
+			return err
+		}
+		for i := range snapshots {
+			snapshots[i].PeriodeID = periode.ID
+		}
+		return tx.Create(&snapshots).Error
+	})
+	if errors.Is(err, errPeriodeTumpangTindih) {
+		utils.WriteError(w, http.StatusConflict, "Periode tumpang tindih dengan periode yang sudah ditutup")
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menutup periode: "+err.Error())
+		return
+	}
+
+	log.Printf("Periode %s s.d. %s ditutup oleh %s", periode.TanggalAwal, periode.TanggalAkhir, username)
+
+	// Saldo awal kas periode sesudahnya kini diturunkan dari snapshot periode ini
+	hapusCacheLaporan("kas")
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(periode)
+}
+
+// BukaPeriodeHandler menangani permintaan membuka kembali periode yang sudah ditutup.
+// Seluruh snapshot periode tersebut dihapus sehingga laporan kembali memakai data live.
+func BukaPeriodeHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode DELETE
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID periode tidak valid")
+		return
+	}
+
+	db := utils.GetDB()
+	var periode models.PeriodeTutup
+	if err := db.First(&periode, id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Periode tidak ditemukan")
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("periode_id = ?", periode.ID).Delete(&models.SnapshotLaporan{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&periode).Error
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuka periode: "+err.Error())
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	log.Printf("Periode %s s.d. %s dibuka kembali oleh %s", periode.TanggalAwal, periode.TanggalAkhir, username)
+
+	// Periode yang dibuka biasanya akan dikoreksi, jangan layani hasil cache sebelum penutupan
+	hapusCacheLaporan("")
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Periode berhasil dibuka kembali",
+	})
+}
+
+// SelisihPeriodeHandler menangani permintaan perbandingan snapshot periode tertutup dengan
+// data live Khanza saat ini. Parameter laporan opsional membatasi ke satu laporan.
+func SelisihPeriodeHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID periode tidak valid")
+		return
+	}
+
+	db := utils.GetDB()
+	var periode models.PeriodeTutup
+	if err := db.First(&periode, id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Periode tidak ditemukan")
+		return
+	}
+
+	query := db.Where("periode_id = ?", periode.ID)
+	if nama := r.URL.Query().Get("laporan"); nama != "" {
+		if _, ok := cariLaporan(nama); !ok {
+			utils.WriteFieldError(w, "laporan", "Laporan tidak terdaftar: "+nama)
+			return
+		}
+		query = query.Where("laporan = ?", nama)
+	}
+
+	var snapshots []models.SnapshotLaporan
+	if err := query.Order("laporan, parameter").Find(&snapshots).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil snapshot: "+err.Error())
+		return
+	}
+
+	hasil := []SelisihLaporan{}
+	jumlahBerubah := 0
+	for _, snapshot := range snapshots {
+		selisih := SelisihLaporan{Laporan: snapshot.Laporan, Parameter: snapshot.Parameter}
+
+		laporan, ok := cariLaporan(snapshot.Laporan)
+		if !ok {
+			selisih.Error = "Laporan tidak lagi terdaftar"
+			hasil = append(hasil, selisih)
+			continue
+		}
+
+		params, _ := url.ParseQuery(snapshot.Parameter)
+		params.Set("tanggal_awal", periode.TanggalAwal)
+		params.Set("tanggal_akhir", periode.TanggalAkhir)
+
+		status, body, err := jalankanLaporan(r.Context(), laporan, params)
+		if err != nil || status != http.StatusOK {
+			selisih.Error = fmt.Sprintf("Gagal menjalankan laporan live (status %d): %v", status, err)
+			hasil = append(hasil, selisih)
+			continue
+		}
+
+		var dataSnapshot, dataLive map[string]interface{}
+		if err := json.Unmarshal([]byte(snapshot.Data), &dataSnapshot); err != nil {
+			selisih.Error = "Snapshot tidak dapat dibaca: " + err.Error()
+			hasil = append(hasil, selisih)
+			continue
+		}
+		if err := json.Unmarshal(body, &dataLive); err != nil {
+			selisih.Error = "Response live tidak dapat dibaca: " + err.Error()
+			hasil = append(hasil, selisih)
+			continue
+		}
+
+		bandingkanLaporan(&selisih, laporan.Kunci, dataSnapshot, dataLive)
+		if selisih.AdaPerubahan {
+			jumlahBerubah++
+		}
+		hasil = append(hasil, selisih)
+	}
+
+	// Siapkan response
+	response := map[string]interface{}{
+		"status":                "success",
+		"message":               "Perbandingan snapshot dengan data live selesai",
+		"periode":               periode,
+		"total_laporan":         len(hasil),
+		"total_laporan_berubah": jumlahBerubah,
+		"data":                  hasil,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, response)
+}
+
+// bandingkanLaporan membandingkan nilai total (angka di level atas response) dan baris pada
+// setiap array data. Baris dicocokkan memakai kolom kunci laporan; tanpa kunci, seluruh isi
+// baris menjadi kuncinya sehingga perubahan tampil sebagai baris dihapus dan ditambah.
+func bandingkanLaporan(selisih *SelisihLaporan, kunci map[string][]string, snapshot, live map[string]interface{}) {
+	selisih.TotalBerubah = []SelisihNilai{}
+	selisih.BarisDitambah = []SelisihBaris{}
+	selisih.BarisDihapus = []SelisihBaris{}
+	selisih.BarisBerubah = []SelisihBaris{}
+
+	fields := make([]string, 0, len(snapshot))
+	for field := range snapshot {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		nilaiSnapshot := snapshot[field]
+		nilaiLive := live[field]
+
+		switch v := nilaiSnapshot.(type) {
+		case float64:
+			liveAngka, _ := nilaiLive.(float64)
+			if math.Abs(v-liveAngka) >= 0.005 {
+				selisih.TotalBerubah = append(selisih.TotalBerubah, SelisihNilai{
+					Field:    field,
+					Snapshot: v,
+					Live:     liveAngka,
+					Selisih:  liveAngka - v,
+				})
+			}
+		case []interface{}:
+			liveArray, _ := nilaiLive.([]interface{})
+			bandingkanBaris(selisih, field, kunci[field], v, liveArray)
+		}
+	}
+
+	selisih.AdaPerubahan = len(selisih.TotalBerubah) > 0 || len(selisih.BarisDitambah) > 0 ||
+		len(selisih.BarisDihapus) > 0 || len(selisih.BarisBerubah) > 0
+}
+
+// bandingkanBaris membandingkan dua array baris berdasarkan kolom kunci
+func bandingkanBaris(selisih *SelisihLaporan, array string, kolomKunci []string, snapshot, live []interface{}) {
+	petaSnapshot, urutanSnapshot := petakanBaris(kolomKunci, snapshot)
+	petaLive, urutanLive := petakanBaris(kolomKunci, live)
+
+	for _, k := range urutanSnapshot {
+		barisLive, ada := petaLive[k]
+		if !ada {
+			selisih.BarisDihapus = append(selisih.BarisDihapus, SelisihBaris{Array: array, Kunci: k, Snapshot: petaSnapshot[k]})
+			continue
+		}
+		a, _ := json.Marshal(petaSnapshot[k])
+		b, _ := json.Marshal(barisLive)
+		if string(a) != string(b) {
+			selisih.BarisBerubah = append(selisih.BarisBerubah, SelisihBaris{Array: array, Kunci: k, Snapshot: petaSnapshot[k], Live: barisLive})
+		}
+	}
+	for _, k := range urutanLive {
+		if _, ada := petaSnapshot[k]; !ada {
+			selisih.BarisDitambah = append(selisih.BarisDitambah, SelisihBaris{Array: array, Kunci: k, Live: petaLive[k]})
+		}
+	}
+}
+
+// petakanBaris membuat peta kunci -> baris dengan urutan kemunculan. Kunci ganda diberi akhiran #n.
+func petakanBaris(kolomKunci []string, baris []interface{}) (map[string]interface{}, []string) {
+	peta := map[string]interface{}{}
+	urutan := []string{}
+	for _, item := range baris {
+		var k string
+		if obj, ok := item.(map[string]interface{}); ok && len(kolomKunci) > 0 {
+			bagian := make([]string, 0, len(kolomKunci))
+			for _, kolom := range kolomKunci {
+				bagian = append(bagian, fmt.Sprintf("%v", obj[kolom]))
+			}
+			k = strings.Join(bagian, "|")
+		} else {
+			b, _ := json.Marshal(item)
+			k = string(b)
+		}
+
+		dasar := k
+		for n := 2; ; n++ {
+			if _, ada := peta[k]; !ada {
+				break
+			}
+			k = fmt.Sprintf("%s#%d", dasar, n)
+		}
+		peta[k] = item
+		urutan = append(urutan, k)
+	}
+	return peta, urutan
+}
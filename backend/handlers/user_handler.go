@@ -37,21 +37,21 @@ func GetIDFromURL(path string) int {
 func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode GET
 	if r.Method != http.MethodGet {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
 	// Verifikasi autentikasi
 	_, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "Tidak terautentikasi", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Tidak terautentikasi")
 		return
 	}
 
 	// Dapatkan user role dari context
 	userRole, ok := r.Context().Value("userRole").(string)
 	if !ok || userRole != "admin" {
-		http.Error(w, "Tidak memiliki izin", http.StatusForbidden)
+		utils.WriteError(w, http.StatusForbidden, "Tidak memiliki izin")
 		return
 	}
 
@@ -59,7 +59,7 @@ func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 	var users []models.User
 	result := utils.DB.Order("id desc").Find(&users)
 	if result.Error != nil {
-		http.Error(w, "Gagal mengambil data pengguna: "+result.Error.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil data pengguna: "+result.Error.Error())
 		return
 	}
 
@@ -72,48 +72,48 @@ func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 func GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode GET
 	if r.Method != http.MethodGet {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
 	// Dapatkan user ID dari context
 	userID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "Tidak terautentikasi", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Tidak terautentikasi")
 		return
 	}
 
 	// Dapatkan user role dari context
 	userRole, ok := r.Context().Value("userRole").(string)
 	if !ok {
-		http.Error(w, "Tidak terautentikasi", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Tidak terautentikasi")
 		return
 	}
 
 	// Dapatkan ID pengguna dari URL
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 4 {
-		http.Error(w, "ID pengguna diperlukan", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna diperlukan")
 		return
 	}
 
 	targetIDStr := pathParts[3]
 	targetID, err := strconv.ParseUint(targetIDStr, 10, 32)
 	if err != nil {
-		http.Error(w, "ID pengguna tidak valid", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna tidak valid")
 		return
 	}
 
 	// Periksa izin: hanya admin atau pengguna itu sendiri yang bisa melihat detail
 	if userRole != "admin" && userID != uint(targetID) {
-		http.Error(w, "Tidak memiliki izin", http.StatusForbidden)
+		utils.WriteError(w, http.StatusForbidden, "Tidak memiliki izin")
 		return
 	}
 
 	// Dapatkan pengguna dari database
 	user, err := models.FindUserByID(utils.DB, uint(targetID))
 	if err != nil {
-		http.Error(w, "Pengguna tidak ditemukan", http.StatusNotFound)
+		utils.WriteError(w, http.StatusNotFound, "Pengguna tidak ditemukan")
 		return
 	}
 
@@ -126,27 +126,31 @@ func GetUserHandler(w http.ResponseWriter, r *http.Request) {
 func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode POST
 	if r.Method != http.MethodPost {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
 	// Dapatkan user role dari context
 	userRole, ok := r.Context().Value("userRole").(string)
 	if !ok || userRole != "admin" {
-		http.Error(w, "Tidak memiliki izin", http.StatusForbidden)
+		utils.WriteError(w, http.StatusForbidden, "Tidak memiliki izin")
 		return
 	}
 
 	// Decode permintaan JSON
 	var req UserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Format JSON tidak valid", http.StatusBadRequest)
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
 		return
 	}
 
 	// Validasi input
-	if req.Username == "" || req.Password == "" || req.Name == "" {
-		http.Error(w, "Username, password, dan nama wajib diisi", http.StatusBadRequest)
+	if err := validasiWajib("username", req.Username, "password", req.Password, "name", req.Name); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	if err := validasiRole(req.Role); err != nil {
+		tulisErrorValidasi(w, err)
 		return
 	}
 
@@ -154,7 +158,7 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	var existingUserCount int64
 	utils.DB.Model(&models.User{}).Where("username = ?", req.Username).Count(&existingUserCount)
 	if existingUserCount > 0 {
-		http.Error(w, "Username sudah digunakan", http.StatusConflict)
+		utils.WriteError(w, http.StatusConflict, "Username sudah digunakan")
 		return
 	}
 
@@ -173,14 +177,14 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Set dan hash password
 	if err := newUser.SetPassword(req.Password); err != nil {
-		http.Error(w, "Gagal mengatur password: "+err.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengatur password: "+err.Error())
 		return
 	}
 
 	// Simpan ke database
 	result := utils.DB.Create(&newUser)
 	if result.Error != nil {
-		http.Error(w, "Gagal membuat pengguna: "+result.Error.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat pengguna: "+result.Error.Error())
 		return
 	}
 
@@ -194,55 +198,59 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode PUT
 	if r.Method != http.MethodPut {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
 	// Dapatkan user ID dari context
 	userID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "Tidak terautentikasi", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Tidak terautentikasi")
 		return
 	}
 
 	// Dapatkan user role dari context
 	userRole, ok := r.Context().Value("userRole").(string)
 	if !ok {
-		http.Error(w, "Tidak terautentikasi", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Tidak terautentikasi")
 		return
 	}
 
 	// Dapatkan ID pengguna dari URL
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 4 {
-		http.Error(w, "ID pengguna diperlukan", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna diperlukan")
 		return
 	}
 
 	targetIDStr := pathParts[3]
 	targetID, err := strconv.ParseUint(targetIDStr, 10, 32)
 	if err != nil {
-		http.Error(w, "ID pengguna tidak valid", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna tidak valid")
 		return
 	}
 
 	// Periksa izin: hanya admin atau pengguna itu sendiri yang bisa memperbarui
 	if userRole != "admin" && userID != uint(targetID) {
-		http.Error(w, "Tidak memiliki izin", http.StatusForbidden)
+		utils.WriteError(w, http.StatusForbidden, "Tidak memiliki izin")
 		return
 	}
 
 	// Dapatkan pengguna dari database
 	user, err := models.FindUserByID(utils.DB, uint(targetID))
 	if err != nil {
-		http.Error(w, "Pengguna tidak ditemukan", http.StatusNotFound)
+		utils.WriteError(w, http.StatusNotFound, "Pengguna tidak ditemukan")
 		return
 	}
 
 	// Decode permintaan JSON
 	var req UserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Format JSON tidak valid", http.StatusBadRequest)
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+	if err := validasiRole(req.Role); err != nil {
+		tulisErrorValidasi(w, err)
 		return
 	}
 
@@ -259,7 +267,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Jika password diubah, hash dan simpan password baru
 	if req.Password != "" {
 		if err := user.SetPassword(req.Password); err != nil {
-			http.Error(w, "Gagal mengatur password: "+err.Error(), http.StatusInternalServerError)
+			utils.WriteError(w, http.StatusInternalServerError, "Gagal mengatur password: "+err.Error())
 			return
 		}
 	}
@@ -268,7 +276,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	if req.Username != "" && req.Username != user.Username {
 		// Hanya admin yang bisa mengubah username
 		if userRole != "admin" {
-			http.Error(w, "Tidak memiliki izin untuk mengubah username", http.StatusForbidden)
+			utils.WriteError(w, http.StatusForbidden, "Tidak memiliki izin untuk mengubah username")
 			return
 		}
 
@@ -276,7 +284,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 		var existingUserCount int64
 		utils.DB.Model(&models.User{}).Where("username = ?", req.Username).Count(&existingUserCount)
 		if existingUserCount > 0 {
-			http.Error(w, "Username sudah digunakan", http.StatusConflict)
+			utils.WriteError(w, http.StatusConflict, "Username sudah digunakan")
 			return
 		}
 
@@ -286,7 +294,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Simpan perubahan ke database
 	result := utils.DB.Save(user)
 	if result.Error != nil {
-		http.Error(w, "Gagal memperbarui pengguna: "+result.Error.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memperbarui pengguna: "+result.Error.Error())
 		return
 	}
 
@@ -299,55 +307,55 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode DELETE
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		return
 	}
 
 	// Dapatkan user ID dari context
 	userID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w, "Tidak terautentikasi", http.StatusUnauthorized)
+		utils.WriteError(w, http.StatusUnauthorized, "Tidak terautentikasi")
 		return
 	}
 
 	// Dapatkan user role dari context
 	userRole, ok := r.Context().Value("userRole").(string)
 	if !ok || userRole != "admin" {
-		http.Error(w, "Tidak memiliki izin", http.StatusForbidden)
+		utils.WriteError(w, http.StatusForbidden, "Tidak memiliki izin")
 		return
 	}
 
 	// Dapatkan ID pengguna dari URL
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 4 {
-		http.Error(w, "ID pengguna diperlukan", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna diperlukan")
 		return
 	}
 
 	targetIDStr := pathParts[3]
 	targetID, err := strconv.ParseUint(targetIDStr, 10, 32)
 	if err != nil {
-		http.Error(w, "ID pengguna tidak valid", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna tidak valid")
 		return
 	}
 
 	// Mencegah penghapusan diri sendiri
 	if userID == uint(targetID) {
-		http.Error(w, "Tidak dapat menghapus akun Anda sendiri", http.StatusForbidden)
+		utils.WriteError(w, http.StatusForbidden, "Tidak dapat menghapus akun Anda sendiri")
 		return
 	}
 
 	// Dapatkan pengguna dari database
 	user, err := models.FindUserByID(utils.DB, uint(targetID))
 	if err != nil {
-		http.Error(w, "Pengguna tidak ditemukan", http.StatusNotFound)
+		utils.WriteError(w, http.StatusNotFound, "Pengguna tidak ditemukan")
 		return
 	}
 
 	// Hapus pengguna dari database
 	result := utils.DB.Delete(user)
 	if result.Error != nil {
-		http.Error(w, "Gagal menghapus pengguna: "+result.Error.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghapus pengguna: "+result.Error.Error())
 		return
 	}
 
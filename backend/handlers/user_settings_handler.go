@@ -14,20 +14,20 @@ import (
 func GetUserSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode GET
 	if r.Method != http.MethodGet {
-		http.Error(w, "Metode tidak diijinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diijinkan")
 		return
 	}
 
 	// Mendapatkan ID dari query parameter
 	userIDStr := r.URL.Query().Get("id")
 	if userIDStr == "" {
-		http.Error(w, "ID pengguna diperlukan", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna diperlukan")
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		http.Error(w, "ID pengguna tidak valid", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna tidak valid")
 		return
 	}
 
@@ -37,7 +37,7 @@ func GetUserSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	// Mencari pengguna di database
 	user, err := models.FindUserByID(db, uint(userID))
 	if err != nil {
-		http.Error(w, "Pengguna tidak ditemukan", http.StatusNotFound)
+		utils.WriteError(w, http.StatusNotFound, "Pengguna tidak ditemukan")
 		return
 	}
 
@@ -59,20 +59,20 @@ func GetUserSettingsHandler(w http.ResponseWriter, r *http.Request) {
 func UpdateDarkModeHandler(w http.ResponseWriter, r *http.Request) {
 	// Hanya menerima metode POST
 	if r.Method != http.MethodPost {
-		http.Error(w, "Metode tidak diijinkan", http.StatusMethodNotAllowed)
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diijinkan")
 		return
 	}
 
 	// Mendapatkan ID dari query parameter
 	userIDStr := r.URL.Query().Get("id")
 	if userIDStr == "" {
-		http.Error(w, "ID pengguna diperlukan", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna diperlukan")
 		return
 	}
 
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
-		http.Error(w, "ID pengguna tidak valid", http.StatusBadRequest)
+		utils.WriteFieldError(w, "id", "ID pengguna tidak valid")
 		return
 	}
 
@@ -83,7 +83,7 @@ func UpdateDarkModeHandler(w http.ResponseWriter, r *http.Request) {
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		log.Printf("UpdateDarkMode - Error decoding request: %v", err)
-		http.Error(w, "Format permintaan tidak valid", http.StatusBadRequest)
+		utils.WriteError(w, http.StatusBadRequest, "Format permintaan tidak valid")
 		return
 	}
 
@@ -102,7 +102,7 @@ func UpdateDarkModeHandler(w http.ResponseWriter, r *http.Request) {
 		darkModeValue = v == "true" || v == "1"
 	default:
 		log.Printf("UpdateDarkMode - Unknown dark_mode type: %T, value: %v", request.DarkMode, request.DarkMode)
-		http.Error(w, fmt.Sprintf("Tipe data dark_mode tidak didukung: %T", request.DarkMode), http.StatusBadRequest)
+		utils.WriteFieldError(w, "dark_mode", fmt.Sprintf("Tipe data dark_mode tidak didukung: %T", request.DarkMode))
 		return
 	}
 
@@ -114,7 +114,7 @@ func UpdateDarkModeHandler(w http.ResponseWriter, r *http.Request) {
 	// Mencari pengguna di database
 	user, err := models.FindUserByID(db, uint(userID))
 	if err != nil {
-		http.Error(w, "Pengguna tidak ditemukan", http.StatusNotFound)
+		utils.WriteError(w, http.StatusNotFound, "Pengguna tidak ditemukan")
 		return
 	}
 
@@ -125,7 +125,7 @@ func UpdateDarkModeHandler(w http.ResponseWriter, r *http.Request) {
 	result := db.Save(user)
 	if result.Error != nil {
 		log.Printf("UpdateDarkMode - Error saving to database: %v", result.Error)
-		http.Error(w, "Gagal menyimpan pengaturan", http.StatusInternalServerError)
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menyimpan pengaturan")
 		return
 	}
 
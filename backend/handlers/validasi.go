@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"siak-rsbw/backend/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorValidasi adalah kesalahan input pada satu parameter atau field permintaan
+type ErrorValidasi struct {
+	Field   string
+	Message string
+}
+
+func (e *ErrorValidasi) Error() string {
+	return e.Message
+}
+
+// errValidasi membuat ErrorValidasi dengan pesan terformat
+func errValidasi(field, format string, args ...interface{}) *ErrorValidasi {
+	return &ErrorValidasi{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// tulisErrorValidasi menulis response 400 dengan field yang bermasalah. Error selain
+// ErrorValidasi tetap dianggap input tidak valid tanpa field.
+func tulisErrorValidasi(w http.ResponseWriter, err error) {
+	var ev *ErrorValidasi
+	if errors.As(err, &ev) {
+		utils.WriteFieldError(w, ev.Field, ev.Message)
+		return
+	}
+	utils.WriteFieldError(w, "", err.Error())
+}
+
+// maksRentangHari adalah batas panjang rentang tanggal laporan dalam hari.
+// Diatur lewat LAPORAN_MAKS_RENTANG_HARI, default 366 hari.
+func maksRentangHari() int {
+	if n, err := strconv.Atoi(getEnv("LAPORAN_MAKS_RENTANG_HARI", "366")); err == nil && n > 0 {
+		return n
+	}
+	return 366
+}
+
+// parseTanggal mem-parsing tanggal format YYYY-MM-DD secara ketat (tanggal seperti
+// 2024-13-99 atau 2024-02-30 ditolak)
+func parseTanggal(field, nilai string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", nilai)
+	if err != nil {
+		return time.Time{}, errValidasi(field, "%s harus berupa tanggal valid dengan format YYYY-MM-DD", field)
+	}
+	return t, nil
+}
+
+// getParamTanggal mengambil parameter tanggal opsional. Nilai kosong menghasilkan default.
+func getParamTanggal(r *http.Request, nama, nilaiDefault string) (string, error) {
+	nilai := strings.TrimSpace(r.URL.Query().Get(nama))
+	if nilai == "" {
+		return nilaiDefault, nil
+	}
+	if _, err := parseTanggal(nama, nilai); err != nil {
+		return "", err
+	}
+	return nilai, nil
+}
+
+// getParamBool mengambil parameter boolean (true/false/1/0). Nilai kosong berarti false.
+func getParamBool(r *http.Request, nama string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get(nama))) {
+	case "":
+		return false, nil
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	default:
+		return false, errValidasi(nama, "%s harus bernilai true atau false", nama)
+	}
+}
+
+// getParamInt mengambil parameter bilangan bulat dalam batas minimal dan maksimal
+func getParamInt(r *http.Request, nama string, nilaiDefault, minimal, maksimal int) (int, error) {
+	nilai := strings.TrimSpace(r.URL.Query().Get(nama))
+	if nilai == "" {
+		return nilaiDefault, nil
+	}
+	n, err := strconv.Atoi(nilai)
+	if err != nil || n < minimal || n > maksimal {
+		return 0, errValidasi(nama, "%s harus berupa bilangan bulat antara %d dan %d", nama, minimal, maksimal)
+	}
+	return n, nil
+}
+
+// getParamFloat mengambil parameter angka desimal
+func getParamFloat(r *http.Request, nama string, nilaiDefault float64) (float64, error) {
+	nilai := strings.TrimSpace(r.URL.Query().Get(nama))
+	if nilai == "" {
+		return nilaiDefault, nil
+	}
+	f, err := strconv.ParseFloat(nilai, 64)
+	if err != nil {
+		return 0, errValidasi(nama, "%s harus berupa angka", nama)
+	}
+	return f, nil
+}
+
+// getParamEnum mengambil parameter yang nilainya harus salah satu dari pilihan
+func getParamEnum(r *http.Request, nama, nilaiDefault string, pilihan ...string) (string, error) {
+	nilai := strings.TrimSpace(r.URL.Query().Get(nama))
+	if nilai == "" {
+		return nilaiDefault, nil
+	}
+	for _, p := range pilihan {
+		if nilai == p {
+			return nilai, nil
+		}
+	}
+	return "", errValidasi(nama, "%s tidak dikenal: %s (pilihan: %s)", nama, nilai, strings.Join(pilihan, ", "))
+}
+
+// getParamBulan mengambil parameter periode bulanan format YYYY-MM
+func getParamBulan(r *http.Request, nama, nilaiDefault string) (string, error) {
+	nilai := strings.TrimSpace(r.URL.Query().Get(nama))
+	if nilai == "" {
+		return nilaiDefault, nil
+	}
+	if _, err := time.Parse("2006-01", nilai); err != nil {
+		return "", errValidasi(nama, "%s harus berformat YYYY-MM", nama)
+	}
+	return nilai, nil
+}
+
+// validasiRentangTanggal memeriksa format, urutan dan panjang maksimal rentang tanggal
+func validasiRentangTanggal(tanggalAwal, tanggalAkhir string) error {
+	awal, err := parseTanggal("tanggal_awal", tanggalAwal)
+	if err != nil {
+		return err
+	}
+	akhir, err := parseTanggal("tanggal_akhir", tanggalAkhir)
+	if err != nil {
+		return err
+	}
+	if akhir.Before(awal) {
+		return errValidasi("tanggal_akhir", "tanggal_akhir tidak boleh sebelum tanggal_awal")
+	}
+	if maks := maksRentangHari(); int(akhir.Sub(awal).Hours()/24)+1 > maks {
+		return errValidasi("tanggal_akhir", "Rentang tanggal maksimal %d hari", maks)
+	}
+	return nil
+}
+
+// validasiWajib memeriksa field wajib yang diberikan berpasangan (nama, nilai) dan
+// mengembalikan error untuk field pertama yang kosong
+func validasiWajib(pasangan ...string) error {
+	for i := 0; i+1 < len(pasangan); i += 2 {
+		if strings.TrimSpace(pasangan[i+1]) == "" {
+			return errValidasi(pasangan[i], "%s wajib diisi", pasangan[i])
+		}
+	}
+	return nil
+}
+
+// validasiRole memeriksa bahwa role pengguna (jika diisi) adalah admin atau user
+func validasiRole(role string) error {
+	if role != "" && role != "admin" && role != "user" {
+		return errValidasi("role", "role harus admin atau user")
+	}
+	return nil
+}
@@ -0,0 +1,667 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"siak-rsbw/backend/models"
+	"siak-rsbw/backend/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRequest menyimpan data permintaan membuat atau memperbarui langganan webhook
+type WebhookRequest struct {
+	Nama  string   `json:"nama"`
+	URL   string   `json:"url"`
+	Event []string `json:"event"`
+	// Secret untuk tanda tangan HMAC; kosong saat membuat berarti dibuatkan acak, kosong saat
+	// memperbarui berarti secret lama tetap dipakai
+	Secret string `json:"secret"`
+	Aktif  *bool  `json:"aktif"`
+}
+
+// WebhookResponse adalah langganan webhook dengan daftar event dalam bentuk array. Secret hanya
+// dikirim saat langganan dibuat atau secret diganti.
+type WebhookResponse struct {
+	models.WebhookLangganan
+	Event  []string `json:"event"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// payloadWebhook adalah isi JSON yang dikirim ke URL langganan
+type payloadWebhook struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	Waktu string      `json:"waktu"`
+	Data  interface{} `json:"data"`
+}
+
+// eventWebhook adalah event yang dapat dipilih pada langganan. webhook.uji tidak perlu
+// dilanggan karena hanya dikirim lewat endpoint uji.
+var eventWebhook = []string{
+	models.EventPendapatanHarian,
+	models.EventPiutangMelebihiBatas,
+	models.EventJadwalLaporanGagal,
+	models.EventAlertHarian,
+	models.EventSemua,
+}
+
+// pemicuWebhook membangunkan pengirim webhook segera setelah ada event baru
+var pemicuWebhook = make(chan struct{}, 1)
+
+// validasi memeriksa isi permintaan langganan webhook
+func (req *WebhookRequest) validasi() error {
+	req.Nama = strings.TrimSpace(req.Nama)
+	req.URL = strings.TrimSpace(req.URL)
+	if err := validasiWajib("nama", req.Nama, "url", req.URL); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errValidasi("url", "URL webhook harus berupa alamat http atau https: %s", req.URL)
+	}
+
+	if len(req.Event) == 0 {
+		return errValidasi("event", "event wajib diisi (pilihan: %s)", strings.Join(eventWebhook, ", "))
+	}
+	for i, event := range req.Event {
+		req.Event[i] = strings.TrimSpace(event)
+		dikenal := false
+		for _, e := range eventWebhook {
+			dikenal = dikenal || req.Event[i] == e
+		}
+		if !dikenal {
+			return errValidasi("event", "Event tidak dikenal: %s (pilihan: %s)", event, strings.Join(eventWebhook, ", "))
+		}
+	}
+
+	if req.Secret != "" && len(req.Secret) < 16 {
+		return errValidasi("secret", "secret minimal 16 karakter")
+	}
+	return nil
+}
+
+// responseWebhook menyusun response langganan tanpa secret
+func responseWebhook(langganan models.WebhookLangganan) WebhookResponse {
+	return WebhookResponse{WebhookLangganan: langganan, Event: daftarEvent(langganan.Event)}
+}
+
+// daftarEvent memecah daftar event yang dipisah koma
+func daftarEvent(event string) []string {
+	var hasil []string
+	for _, e := range strings.Split(event, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			hasil = append(hasil, e)
+		}
+	}
+	return hasil
+}
+
+// melanggan memeriksa apakah langganan menerima event tertentu
+func melanggan(langganan models.WebhookLangganan, event string) bool {
+	for _, e := range daftarEvent(langganan.Event) {
+		if e == event || e == models.EventSemua {
+			return true
+		}
+	}
+	return false
+}
+
+// pelangganEvent mengambil langganan aktif yang menerima event tertentu
+func pelangganEvent(event string) ([]models.WebhookLangganan, error) {
+	db := utils.GetDB()
+	if db == nil {
+		return nil, nil
+	}
+
+	var semua []models.WebhookLangganan
+	if err := db.Where("aktif = ?", true).Find(&semua).Error; err != nil {
+		return nil, err
+	}
+	var hasil []models.WebhookLangganan
+	for _, langganan := range semua {
+		if melanggan(langganan, event) {
+			hasil = append(hasil, langganan)
+		}
+	}
+	return hasil, nil
+}
+
+// adaPelangganEvent dipakai sumber event untuk melewati query Khanza yang hasilnya tidak akan
+// dikirim ke siapa pun
+func adaPelangganEvent(event string) bool {
+	langganan, err := pelangganEvent(event)
+	return err == nil && len(langganan) > 0
+}
+
+// susunPayloadWebhook membuat payload JSON satu event
+func susunPayloadWebhook(idEvent, event string, data interface{}) (string, error) {
+	payload, err := json.Marshal(payloadWebhook{
+		ID:    idEvent,
+		Event: event,
+		Waktu: utils.SekarangRS().Format(time.RFC3339),
+		Data:  data,
+	})
+	return string(payload), err
+}
+
+// KirimEvent mencatat event untuk setiap langganan aktif yang menerimanya. Pengiriman dilakukan
+// oleh pengirim webhook di latar belakang sehingga pemanggil tidak menunggu penerima.
+func KirimEvent(event string, data interface{}) {
+	langganan, err := pelangganEvent(event)
+	if err != nil {
+		log.Printf("Gagal mengambil langganan webhook untuk %s: %v", event, err)
+		return
+	}
+	if len(langganan) == 0 {
+		return
+	}
+
+	idEvent, err := idAcak()
+	if err != nil {
+		log.Printf("Gagal membuat ID event %s: %v", event, err)
+		return
+	}
+	payload, err := susunPayloadWebhook(idEvent, event, data)
+	if err != nil {
+		log.Printf("Gagal menyusun payload event %s: %v", event, err)
+		return
+	}
+
+	sekarang := time.Now()
+	for _, l := range langganan {
+		pengiriman := models.PengirimanWebhook{
+			LanggananID:    l.ID,
+			Event:          event,
+			IDEvent:        idEvent,
+			Payload:        payload,
+			Status:         models.PengirimanMenunggu,
+			BerikutnyaPada: &sekarang,
+			DibuatPada:     sekarang,
+		}
+		if err := utils.GetDB().Create(&pengiriman).Error; err != nil {
+			log.Printf("Gagal mencatat pengiriman webhook %d untuk %s: %v", l.ID, event, err)
+		}
+	}
+
+	select {
+	case pemicuWebhook <- struct{}{}:
+	default:
+	}
+}
+
+// tandaTanganWebhook menghitung HMAC-SHA256 dari "timestamp.body". Timestamp ikut ditandatangani
+// agar penerima dapat menolak payload lama yang dikirim ulang pihak lain.
+func tandaTanganWebhook(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// kirimWebhook mengirim satu pengiriman ke URL langganan lalu mengisi status HTTP dan potongan
+// response. Status 2xx dianggap berhasil.
+func kirimWebhook(langganan models.WebhookLangganan, pengiriman *models.PengirimanWebhook) error {
+	req, err := http.NewRequest(http.MethodPost, langganan.URL, strings.NewReader(pengiriman.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SIAK-RSBW-Webhook/1.0")
+	req.Header.Set("X-SIAK-Event", pengiriman.Event)
+	req.Header.Set("X-SIAK-Delivery", pengiriman.IDEvent)
+	req.Header.Set("X-SIAK-Timestamp", timestamp)
+	req.Header.Set("X-SIAK-Signature", tandaTanganWebhook(langganan.Secret, timestamp, pengiriman.Payload))
+
+	client := &http.Client{Timeout: durasiEnv("WEBHOOK_TIMEOUT", 10*time.Second)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	isi, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	pengiriman.StatusHTTP = resp.StatusCode
+	pengiriman.Respons = string(isi)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("penerima membalas dengan status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// jedaPercobaan menghitung jeda sebelum percobaan berikutnya: 30 detik, 1 menit, 2 menit, ...
+// berlipat dua setiap kali gagal dan paling lama 1 jam
+func jedaPercobaan(percobaan int) time.Duration {
+	jeda := 30 * time.Second
+	for i := 1; i < percobaan && jeda < time.Hour; i++ {
+		jeda *= 2
+	}
+	if jeda > time.Hour {
+		jeda = time.Hour
+	}
+	return jeda
+}
+
+// JalankanPengirimWebhook memulai pengirim webhook di latar belakang. Antrian diperiksa setiap
+// WEBHOOK_INTERVAL (default 10s) atau segera setelah ada event baru. Pengiriman yang gagal dicoba
+// ulang dengan jeda berlipat sampai WEBHOOK_MAKS_PERCOBAAN (default 6) kali, dan log pengiriman
+// yang lebih lama dari WEBHOOK_RETENSI (default 720h) dihapus.
+func JalankanPengirimWebhook() {
+	db := utils.GetDB()
+	if db == nil {
+		log.Println("Database SIAK tidak tersedia, webhook dinonaktifkan")
+		return
+	}
+
+	// Pengiriman yang terputus karena server berhenti dikirim ulang
+	db.Model(&models.PengirimanWebhook{}).Where("status = ?", models.PengirimanMengirim).
+		UpdateColumn("status", models.PengirimanMenunggu)
+
+	interval := durasiEnv("WEBHOOK_INTERVAL", 10*time.Second)
+	go func() {
+		var terakhirDibersihkan time.Time
+		for {
+			prosesAntrianWebhook()
+
+			if time.Since(terakhirDibersihkan) >= time.Hour {
+				bersihkanPengirimanWebhook()
+				terakhirDibersihkan = time.Now()
+			}
+
+			select {
+			case <-pemicuWebhook:
+			case <-time.After(interval):
+			}
+		}
+	}()
+
+	log.Printf("Pengirim webhook aktif (interval %s)", interval)
+}
+
+// prosesAntrianWebhook mengirim seluruh pengiriman yang sudah jatuh tempo
+func prosesAntrianWebhook() {
+	db := utils.GetDB()
+	for {
+		var antrian []models.PengirimanWebhook
+		err := db.Where("status = ? AND berikutnya_pada <= ?", models.PengirimanMenunggu, time.Now()).
+			Order("berikutnya_pada, id").Limit(50).Find(&antrian).Error
+		if err != nil {
+			log.Printf("Gagal mengambil antrian webhook: %v", err)
+			return
+		}
+
+		for _, pengiriman := range antrian {
+			prosesPengirimanWebhook(pengiriman)
+		}
+		if len(antrian) < 50 {
+			return
+		}
+	}
+}
+
+// prosesPengirimanWebhook menjalankan satu percobaan pengiriman dan menjadwalkan percobaan
+// berikutnya jika gagal
+func prosesPengirimanWebhook(pengiriman models.PengirimanWebhook) {
+	db := utils.GetDB()
+
+	// Klaim pengiriman agar tidak dikirim dua kali
+	klaim := db.Model(&models.PengirimanWebhook{}).
+		Where("id = ? AND status = ?", pengiriman.ID, models.PengirimanMenunggu).
+		UpdateColumn("status", models.PengirimanMengirim)
+	if klaim.Error != nil || klaim.RowsAffected == 0 {
+		return
+	}
+
+	sekarang := time.Now()
+	pengiriman.Percobaan++
+	pengiriman.TerakhirPada = &sekarang
+	pengiriman.StatusHTTP = 0
+	pengiriman.Respons = ""
+	pengiriman.Error = ""
+
+	var langganan models.WebhookLangganan
+	err := db.First(&langganan, pengiriman.LanggananID).Error
+	switch {
+	case err != nil:
+		err = fmt.Errorf("langganan webhook tidak ditemukan")
+	case !langganan.Aktif:
+		err = fmt.Errorf("langganan webhook tidak aktif")
+	default:
+		err = kirimWebhook(langganan, &pengiriman)
+	}
+
+	pengiriman.Status = models.PengirimanBerhasil
+	pengiriman.BerikutnyaPada = nil
+	if err != nil {
+		pengiriman.Error = err.Error()
+		pengiriman.Status = models.PengirimanGagal
+		if langganan.Aktif && pengiriman.Percobaan < envInt("WEBHOOK_MAKS_PERCOBAAN", 6) {
+			berikutnya := sekarang.Add(jedaPercobaan(pengiriman.Percobaan))
+			pengiriman.Status = models.PengirimanMenunggu
+			pengiriman.BerikutnyaPada = &berikutnya
+		}
+		log.Printf("Webhook %s ke langganan %d gagal (percobaan %d): %v",
+			pengiriman.Event, pengiriman.LanggananID, pengiriman.Percobaan, err)
+	}
+
+	db.Model(&models.PengirimanWebhook{}).Where("id = ?", pengiriman.ID).UpdateColumns(map[string]interface{}{
+		"status":          pengiriman.Status,
+		"percobaan":       pengiriman.Percobaan,
+		"status_http":     pengiriman.StatusHTTP,
+		"respons":         pengiriman.Respons,
+		"error":           pengiriman.Error,
+		"berikutnya_pada": pengiriman.BerikutnyaPada,
+		"terakhir_pada":   pengiriman.TerakhirPada,
+	})
+}
+
+// bersihkanPengirimanWebhook menghapus log pengiriman yang sudah selesai dan melewati masa simpan
+func bersihkanPengirimanWebhook() {
+	batas := time.Now().Add(-durasiEnv("WEBHOOK_RETENSI", 720*time.Hour))
+	hasil := utils.GetDB().Where("status IN ? AND dibuat_pada < ?",
+		[]string{models.PengirimanBerhasil, models.PengirimanGagal}, batas).
+		Delete(&models.PengirimanWebhook{})
+	if hasil.Error != nil {
+		log.Printf("Gagal membersihkan log webhook: %v", hasil.Error)
+	} else if hasil.RowsAffected > 0 {
+		log.Printf("%d log pengiriman webhook dihapus", hasil.RowsAffected)
+	}
+}
+
+// getWebhookDariURL mengambil langganan webhook berdasarkan ID pada URL
+func getWebhookDariURL(w http.ResponseWriter, r *http.Request) (*models.WebhookLangganan, bool) {
+	id := GetIDFromURL(r.URL.Path)
+	if id == 0 {
+		utils.WriteFieldError(w, "id", "ID webhook tidak valid")
+		return nil, false
+	}
+
+	var langganan models.WebhookLangganan
+	if err := utils.GetDB().First(&langganan, id).Error; err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Webhook tidak ditemukan")
+		return nil, false
+	}
+	return &langganan, true
+}
+
+// GetWebhookHandler menangani permintaan daftar langganan webhook
+func GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	var daftar []models.WebhookLangganan
+	if err := utils.GetDB().Order("nama").Find(&daftar).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil webhook: "+err.Error())
+		return
+	}
+
+	data := make([]WebhookResponse, len(daftar))
+	for i, langganan := range daftar {
+		data[i] = responseWebhook(langganan)
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"status":     "success",
+		"total_data": len(data),
+		"event":      eventWebhook,
+		"data":       data,
+	})
+}
+
+// CreateWebhookHandler menangani permintaan membuat langganan webhook. Secret dikembalikan
+// sekali pada response ini dan tidak ditampilkan lagi.
+func CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	// Decode permintaan JSON
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	if err := req.validasi(); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	if req.Secret == "" {
+		secret, err := idAcak()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat secret webhook")
+			return
+		}
+		req.Secret = secret
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	langganan := models.WebhookLangganan{
+		Nama:       req.Nama,
+		URL:        req.URL,
+		Event:      strings.Join(req.Event, ","),
+		Secret:     req.Secret,
+		Aktif:      req.Aktif == nil || *req.Aktif,
+		DibuatOleh: username,
+	}
+
+	if err := utils.GetDB().Create(&langganan).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menyimpan webhook: "+err.Error())
+		return
+	}
+
+	log.Printf("Webhook %d (%s, %s) dibuat oleh %s", langganan.ID, langganan.Nama, langganan.Event, username)
+
+	response := responseWebhook(langganan)
+	response.Secret = langganan.Secret
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateWebhookHandler menangani permintaan memperbarui langganan webhook
+func UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode PUT
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	langganan, ok := getWebhookDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode permintaan JSON
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Format JSON tidak valid")
+		return
+	}
+
+	if err := req.validasi(); err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	langganan.Nama = req.Nama
+	langganan.URL = req.URL
+	langganan.Event = strings.Join(req.Event, ",")
+	if req.Secret != "" {
+		langganan.Secret = req.Secret
+	}
+	if req.Aktif != nil {
+		langganan.Aktif = *req.Aktif
+	}
+
+	if err := utils.GetDB().Save(langganan).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal memperbarui webhook: "+err.Error())
+		return
+	}
+
+	response := responseWebhook(*langganan)
+	response.Secret = req.Secret
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteWebhookHandler menangani permintaan menghapus langganan webhook beserta log pengirimannya
+func DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode DELETE
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	langganan, ok := getWebhookDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("langganan_id = ?", langganan.ID).Delete(&models.PengirimanWebhook{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(langganan).Error
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menghapus webhook: "+err.Error())
+		return
+	}
+
+	username, _ := r.Context().Value("username").(string)
+	log.Printf("Webhook %d (%s) dihapus oleh %s", langganan.ID, langganan.Nama, username)
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Webhook berhasil dihapus",
+	})
+}
+
+// GetPengirimanWebhookHandler menangani permintaan log pengiriman satu langganan, terbaru lebih
+// dulu. Parameter status menyaring status pengiriman dan limit membatasi jumlah data (default 50,
+// maksimal 500).
+func GetPengirimanWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode GET
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	limit, err := getParamInt(r, "limit", 50, 1, 500)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+	status, err := getParamEnum(r, "status", "", models.PengirimanMenunggu, models.PengirimanMengirim,
+		models.PengirimanBerhasil, models.PengirimanGagal)
+	if err != nil {
+		tulisErrorValidasi(w, err)
+		return
+	}
+
+	langganan, ok := getWebhookDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	query := utils.GetDB().Where("langganan_id = ?", langganan.ID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var pengiriman []models.PengirimanWebhook
+	if err := query.Order("dibuat_pada desc, id desc").Limit(limit).Find(&pengiriman).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal mengambil log webhook: "+err.Error())
+		return
+	}
+
+	// Kirim respons
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"status":     "success",
+		"webhook":    responseWebhook(*langganan),
+		"total_data": len(pengiriman),
+		"data":       pengiriman,
+	})
+}
+
+// UjiWebhookHandler menangani permintaan mengirim event webhook.uji ke satu langganan sekarang
+// juga, termasuk langganan yang belum aktif. Pengiriman dilakukan sekali tanpa percobaan ulang
+// dan hasilnya dicatat pada log pengiriman.
+func UjiWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// Hanya menerima metode POST
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		return
+	}
+
+	langganan, ok := getWebhookDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	idEvent, err := idAcak()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal membuat ID event")
+		return
+	}
+	username, _ := r.Context().Value("username").(string)
+	payload, err := susunPayloadWebhook(idEvent, models.EventUjiWebhook, map[string]interface{}{
+		"pesan":        "Uji webhook SIAK RSBW",
+		"webhook_id":   langganan.ID,
+		"dikirim_oleh": username,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Gagal menyusun payload: "+err.Error())
+		return
+	}
+
+	sekarang := time.Now()
+	pengiriman := models.PengirimanWebhook{
+		LanggananID:  langganan.ID,
+		Event:        models.EventUjiWebhook,
+		IDEvent:      idEvent,
+		Payload:      payload,
+		Status:       models.PengirimanBerhasil,
+		Percobaan:    1,
+		TerakhirPada: &sekarang,
+		DibuatPada:   sekarang,
+	}
+	if err := kirimWebhook(*langganan, &pengiriman); err != nil {
+		pengiriman.Status = models.PengirimanGagal
+		pengiriman.Error = err.Error()
+	}
+
+	if err := utils.GetDB().Create(&pengiriman).Error; err != nil {
+		log.Printf("Gagal mencatat uji webhook %d: %v", langganan.ID, err)
+	}
+
+	// Kirim respons; hasil gagal tetap 200 karena kegagalannya sudah tercatat di log pengiriman
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pengiriman)
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"siak-rsbw/backend/handlers"
 	"siak-rsbw/backend/middleware"
+	"siak-rsbw/backend/models"
 	"siak-rsbw/backend/utils"
+	"strings"
 )
 
 // Fungsi untuk menambahkan header CORS langsung
@@ -14,6 +16,7 @@ func addCORSHeaders(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Set("Access-Control-Expose-Headers", "X-Cache, X-Cache-Kedaluwarsa, Age, X-Periode-Tutup, X-Snapshot-Dibuat, Content-Disposition")
 }
 
 // Wrapper untuk handler yang menambahkan header CORS
@@ -35,6 +38,27 @@ func main() {
 	// Inisialisasi koneksi database
 	utils.InitDatabase()
 
+	// Migrasi tabel milik SIAK (hanya jika SIAK_AUTO_MIGRATE=true)
+	utils.AutoMigrateSIAK(&models.PeriodeTutup{}, &models.SnapshotLaporan{}, &models.AnggaranPendapatan{},
+		&models.JobLaporan{}, &models.JadwalLaporan{}, &models.RiwayatJadwalLaporan{},
+		&models.WebhookLangganan{}, &models.PengirimanWebhook{}, &models.AturanAlert{}, &models.Alert{})
+
+	// Jalankan pemeriksaan integritas jurnal berkala jika diaktifkan
+	handlers.JalankanPemeriksaanJurnalBerkala()
+
+	// Jalankan worker job laporan di background
+	handlers.JalankanWorkerJobLaporan()
+
+	// Jalankan penjadwal laporan email
+	handlers.JalankanPenjadwalLaporan()
+
+	// Jalankan pengirim webhook dan notifikasi keuangan harian
+	handlers.JalankanPengirimWebhook()
+	handlers.JalankanNotifikasiKeuangan()
+
+	// Jalankan evaluasi aturan alert harian
+	handlers.JalankanEvaluasiAlert()
+
 	// Konfigurasi server
 	port := "8080"
 	host := "0.0.0.0" // Menggunakan 0.0.0.0 agar bisa diakses dari semua interface
@@ -54,19 +78,235 @@ func main() {
 	mux.HandleFunc("/api/mysql-check", withCORS(handlers.MySQLCheckHandler))
 
 	// Route untuk laporan rawat inap
-	mux.HandleFunc("/api/laporan/rawat-inap", withCORS(handlers.LaporanRawatInapHandler))
+	mux.HandleFunc("/api/laporan/rawat-inap", withCORS(handlers.WithBatasWaktu("rawat-inap", handlers.WithPeriodeTutup("rawat-inap", handlers.WithCacheLaporan("rawat-inap", handlers.LaporanRawatInapHandler)))))
 
 	// Route untuk laporan rawat jalan
-	mux.HandleFunc("/api/laporan/rawat-jalan", withCORS(handlers.RawatJalanHandler))
+	mux.HandleFunc("/api/laporan/rawat-jalan", withCORS(handlers.WithBatasWaktu("rawat-jalan", handlers.WithPeriodeTutup("rawat-jalan", handlers.WithCacheLaporan("rawat-jalan", handlers.RawatJalanHandler)))))
+
+	// Route untuk daftar basis tanggal (tanggal_basis) setiap laporan
+	mux.HandleFunc("/api/laporan/basis-tanggal", withCORS(handlers.BasisTanggalHandler))
 
 	// Route untuk laporan piutang pasien
-	mux.HandleFunc("/api/laporan/piutang-pasien", withCORS(handlers.LaporanPiutangPasienHandler))
+	mux.HandleFunc("/api/laporan/piutang-pasien", withCORS(handlers.WithBatasWaktu("piutang-pasien", handlers.WithPeriodeTutup("piutang-pasien", handlers.WithCacheLaporan("piutang-pasien", handlers.LaporanPiutangPasienHandler)))))
 
 	// Route untuk penjualan bebas obat
-	mux.HandleFunc("/api/laporan/penjualan-obat", withCORS(handlers.PenjualanBebasObatHandler))
+	mux.HandleFunc("/api/laporan/penjualan-obat", withCORS(handlers.WithBatasWaktu("penjualan-obat", handlers.WithPeriodeTutup("penjualan-obat", handlers.WithCacheLaporan("penjualan-obat", handlers.PenjualanBebasObatHandler)))))
 
 	// Route untuk penerimaan obat
-	mux.HandleFunc("/api/laporan/penerimaan-obat", withCORS(handlers.PenerimaanObatHandler))
+	mux.HandleFunc("/api/laporan/penerimaan-obat", withCORS(handlers.WithBatasWaktu("penerimaan-obat", handlers.WithPeriodeTutup("penerimaan-obat", handlers.WithCacheLaporan("penerimaan-obat", handlers.PenerimaanObatHandler)))))
+
+	// Route untuk mutasi dan nilai persediaan farmasi
+	mux.HandleFunc("/api/laporan/stok-obat", withCORS(handlers.WithBatasWaktu("stok-obat", handlers.WithPeriodeTutup("stok-obat", handlers.WithCacheLaporan("stok-obat", handlers.StokObatHandler)))))
+
+	// Route untuk hutang obat ke supplier
+	mux.HandleFunc("/api/laporan/hutang-obat", withCORS(handlers.WithBatasWaktu("hutang-obat", handlers.HutangObatHandler)))
+
+	// Route untuk pendapatan resep rawat jalan dan rawat inap
+	mux.HandleFunc("/api/laporan/pendapatan-resep", withCORS(handlers.WithBatasWaktu("pendapatan-resep", handlers.WithPeriodeTutup("pendapatan-resep", handlers.WithCacheLaporan("pendapatan-resep", handlers.PendapatanResepHandler)))))
+
+	// Route untuk laporan kunjungan pasien (reg_periksa)
+	mux.HandleFunc("/api/laporan/kunjungan", withCORS(handlers.WithBatasWaktu("kunjungan", handlers.WithPeriodeTutup("kunjungan", handlers.WithCacheLaporan("kunjungan", handlers.LaporanKunjunganHandler)))))
+
+	// Route untuk arus kas harian
+	mux.HandleFunc("/api/laporan/kas", withCORS(handlers.WithBatasWaktu("kas", handlers.WithPeriodeTutup("kas", handlers.WithCacheLaporan("kas", handlers.ArusKasHandler)))))
+
+	// Route untuk akuntansi dari jurnal Khanza. Buku besar per rekening tidak ikut di-snapshot saat
+	// tutup buku; permintaannya pada periode tutup ditandai live oleh WithPeriodeTutup.
+	mux.HandleFunc("/api/akuntansi/buku-besar", withCORS(handlers.WithBatasWaktu("buku-besar", handlers.WithPeriodeTutup("buku-besar", handlers.WithCacheLaporan("buku-besar", handlers.BukuBesarHandler)))))
+	mux.HandleFunc("/api/akuntansi/neraca-saldo", withCORS(handlers.WithBatasWaktu("neraca-saldo", handlers.WithPeriodeTutup("neraca-saldo", handlers.WithCacheLaporan("neraca-saldo", handlers.NeracaSaldoHandler)))))
+	mux.HandleFunc("/api/akuntansi/laba-rugi", withCORS(handlers.WithBatasWaktu("laba-rugi", handlers.WithPeriodeTutup("laba-rugi", handlers.WithCacheLaporan("laba-rugi", handlers.LabaRugiHandler)))))
+	mux.HandleFunc("/api/akuntansi/neraca", withCORS(handlers.WithBatasWaktu("neraca", handlers.WithPeriodeTutup("neraca", handlers.WithCacheLaporan("neraca", handlers.NeracaHandler)))))
+
+	// Route untuk statistik (GET) dan pengosongan (DELETE) cache laporan, hanya admin
+	mux.HandleFunc("/api/cache-laporan", withCORS(middleware.AdminMiddleware(handlers.CacheLaporanHandler)))
+
+	// Route untuk pemeriksaan integritas jurnal
+	mux.HandleFunc("/api/akuntansi/integritas-jurnal", withCORS(handlers.WithBatasWaktu("integritas-jurnal", handlers.IntegritasJurnalHandler)))
+
+	// Route untuk tutup buku: GET (daftar periode) dan POST (tutup periode, hanya admin)
+	mux.HandleFunc("/api/tutup-buku", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetPeriodeTutupHandler(w, r)
+		case http.MethodPost:
+			middleware.AdminMiddleware(handlers.TutupPeriodeHandler)(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk tutup buku per periode: DELETE (buka kembali, hanya admin)
+	// dan GET /api/tutup-buku/{id}/selisih (perbandingan snapshot dengan data live)
+	mux.HandleFunc("/api/tutup-buku/", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/selisih"):
+			handlers.SelisihPeriodeHandler(w, r)
+		case r.Method == http.MethodDelete:
+			middleware.AdminMiddleware(handlers.BukaPeriodeHandler)(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk anggaran pendapatan: GET (daftar) dan POST (buat, hanya admin)
+	mux.HandleFunc("/api/anggaran", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetAnggaranHandler(w, r)
+		case http.MethodPost:
+			middleware.AdminMiddleware(handlers.CreateAnggaranHandler)(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk anggaran pendapatan per ID: PUT (ubah) dan DELETE (hapus), hanya admin
+	mux.HandleFunc("/api/anggaran/", withCORS(middleware.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			handlers.UpdateAnggaranHandler(w, r)
+		case http.MethodDelete:
+			handlers.DeleteAnggaranHandler(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk laporan anggaran vs realisasi pendapatan
+	mux.HandleFunc("/api/laporan/realisasi-anggaran", withCORS(middleware.AuthMiddleware(handlers.WithBatasWaktu("realisasi-anggaran", handlers.RealisasiAnggaranHandler))))
+
+	// Route untuk proyeksi pendapatan bulanan per lini layanan (Holt-Winters)
+	mux.HandleFunc("/api/laporan/proyeksi-pendapatan", withCORS(middleware.AuthMiddleware(handlers.WithBatasWaktu("proyeksi-pendapatan", handlers.ProyeksiPendapatanHandler))))
+
+	// Route untuk job laporan di background: GET (daftar job pengguna) dan POST (buat job)
+	mux.HandleFunc("/api/job-laporan", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetJobLaporanHandler(w, r)
+		case http.MethodPost:
+			handlers.CreateJobLaporanHandler(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk job laporan per ID: GET (status dan progres), GET /api/job-laporan/{id}/unduh
+	// (unduh berkas hasil) dan DELETE (batalkan atau hapus job)
+	mux.HandleFunc("/api/job-laporan/", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/unduh"):
+			handlers.UnduhJobLaporanHandler(w, r)
+		case r.Method == http.MethodGet:
+			handlers.GetJobLaporanDetailHandler(w, r)
+		case r.Method == http.MethodDelete:
+			handlers.HapusJobLaporanHandler(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk jadwal laporan email: GET (daftar) dan POST (buat, hanya admin)
+	mux.HandleFunc("/api/jadwal-laporan", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetJadwalLaporanHandler(w, r)
+		case http.MethodPost:
+			middleware.AdminMiddleware(handlers.CreateJadwalLaporanHandler)(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk jadwal laporan per ID: PUT (ubah) dan DELETE (hapus), hanya admin,
+	// GET /api/jadwal-laporan/{id}/riwayat (riwayat eksekusi) dan
+	// POST /api/jadwal-laporan/{id}/jalankan (jalankan sekarang, hanya admin)
+	mux.HandleFunc("/api/jadwal-laporan/", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/riwayat"):
+			handlers.GetRiwayatJadwalLaporanHandler(w, r)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/jalankan"):
+			middleware.AdminMiddleware(handlers.JalankanJadwalLaporanHandler)(w, r)
+		case r.Method == http.MethodPut:
+			middleware.AdminMiddleware(handlers.UpdateJadwalLaporanHandler)(w, r)
+		case r.Method == http.MethodDelete:
+			middleware.AdminMiddleware(handlers.DeleteJadwalLaporanHandler)(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk langganan webhook: GET (daftar) dan POST (buat), hanya admin
+	mux.HandleFunc("/api/webhook", withCORS(middleware.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetWebhookHandler(w, r)
+		case http.MethodPost:
+			handlers.CreateWebhookHandler(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk webhook per ID, hanya admin: PUT (ubah), DELETE (hapus),
+	// GET /api/webhook/{id}/pengiriman (log pengiriman) dan POST /api/webhook/{id}/uji (kirim uji)
+	mux.HandleFunc("/api/webhook/", withCORS(middleware.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/pengiriman"):
+			handlers.GetPengirimanWebhookHandler(w, r)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/uji"):
+			handlers.UjiWebhookHandler(w, r)
+		case r.Method == http.MethodPut:
+			handlers.UpdateWebhookHandler(w, r)
+		case r.Method == http.MethodDelete:
+			handlers.DeleteWebhookHandler(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk aturan alert: GET (daftar) dan POST (buat, hanya admin)
+	mux.HandleFunc("/api/aturan-alert", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetAturanAlertHandler(w, r)
+		case http.MethodPost:
+			middleware.AdminMiddleware(handlers.CreateAturanAlertHandler)(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk aturan alert per ID: PUT (ubah) dan DELETE (hapus), hanya admin
+	mux.HandleFunc("/api/aturan-alert/", withCORS(middleware.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			handlers.UpdateAturanAlertHandler(w, r)
+		case http.MethodDelete:
+			handlers.DeleteAturanAlertHandler(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk daftar alert harian
+	mux.HandleFunc("/api/alert", withCORS(middleware.AuthMiddleware(handlers.GetAlertHandler)))
+
+	// Route untuk POST /api/alert/evaluasi (evaluasi ulang satu tanggal, hanya admin) dan
+	// POST /api/alert/{id}/tangani (tandai alert sudah ditangani)
+	mux.HandleFunc("/api/alert/", withCORS(middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/alert/evaluasi":
+			middleware.AdminMiddleware(handlers.EvaluasiAlertHandler)(w, r)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/tangani"):
+			handlers.TanganiAlertHandler(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	})))
+
+	// Route untuk server-sent events dashboard kasir; token dapat dikirim lewat parameter
+	// access_token karena EventSource tidak dapat mengirim header Authorization
+	mux.HandleFunc("/api/live/kasir", withCORS(middleware.TokenQueryMiddleware(middleware.AuthMiddleware(handlers.LiveKasirHandler))))
 
 	// Route untuk login
 	mux.HandleFunc("/api/auth/login", withCORS(handlers.LoginHandler))
@@ -90,7 +330,7 @@ func main() {
 		case http.MethodPost:
 			handlers.CreateUserHandler(w, r)
 		default:
-			http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		}
 	})))
 
@@ -123,7 +363,7 @@ func main() {
 
 		// Untuk DELETE dan metode lain, hanya admin yang diizinkan
 		if !isAdmin {
-			http.Error(w, "Akses ditolak: Memerlukan hak admin", http.StatusForbidden)
+			utils.WriteError(w, http.StatusForbidden, "Akses ditolak: Memerlukan hak admin")
 			return
 		}
 
@@ -136,7 +376,7 @@ func main() {
 		case http.MethodDelete:
 			handlers.DeleteUserHandler(w, r)
 		default:
-			http.Error(w, "Metode tidak diizinkan", http.StatusMethodNotAllowed)
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
 		}
 	})))
 
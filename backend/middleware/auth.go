@@ -13,21 +13,21 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Ambil header Authorization
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header tidak ditemukan", http.StatusUnauthorized)
+			utils.WriteError(w, http.StatusUnauthorized, "Authorization header tidak ditemukan")
 			return
 		}
 
 		// Periksa format Bearer token
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Format Authorization header tidak valid", http.StatusUnauthorized)
+			utils.WriteError(w, http.StatusUnauthorized, "Format Authorization header tidak valid")
 			return
 		}
 
 		// Validasi token
 		tokenData, err := utils.ValidateJWTWithData(parts[1])
 		if err != nil {
-			http.Error(w, "Token tidak valid: "+err.Error(), http.StatusUnauthorized)
+			utils.WriteError(w, http.StatusUnauthorized, "Token tidak valid: "+err.Error())
 			return
 		}
 
@@ -41,6 +41,18 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// TokenQueryMiddleware memindahkan token dari parameter access_token ke header Authorization
+// jika header tersebut kosong. Dipakai untuk endpoint server-sent events karena EventSource di
+// browser tidak dapat mengirim header sendiri.
+func TokenQueryMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next(w, r)
+	}
+}
+
 // AdminMiddleware adalah middleware untuk memeriksa apakah pengguna adalah admin
 func AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	// Pertama gunakan AuthMiddleware untuk otentikasi
@@ -48,13 +60,13 @@ func AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Ambil role dari context
 		role, ok := r.Context().Value("userRole").(string)
 		if !ok {
-			http.Error(w, "Gagal mendapatkan role pengguna", http.StatusInternalServerError)
+			utils.WriteError(w, http.StatusInternalServerError, "Gagal mendapatkan role pengguna")
 			return
 		}
 
 		// Periksa apakah role adalah admin (case insensitive)
 		if strings.ToLower(role) != "admin" {
-			http.Error(w, "Akses ditolak: Memerlukan hak admin", http.StatusForbidden)
+			utils.WriteError(w, http.StatusForbidden, "Akses ditolak: Memerlukan hak admin")
 			return
 		}
 
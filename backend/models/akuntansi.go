@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// SaldoRekening adalah saldo dan mutasi satu rekening (akun) pada suatu periode.
+// Saldo disajikan sesuai saldo normal rekening (balance D atau K).
+type SaldoRekening struct {
+	KdRek      string  `json:"kd_rek"`
+	NmRek      string  `json:"nm_rek"`
+	Tipe       string  `json:"tipe"`
+	Balance    string  `json:"balance"`
+	Level      string  `json:"level"`
+	Induk      string  `json:"induk"`
+	Kedalaman  int     `json:"kedalaman"`
+	AdaSub     bool    `json:"ada_sub"`
+	SaldoAwal  float64 `json:"saldo_awal"`
+	Debet      float64 `json:"debet"`
+	Kredit     float64 `json:"kredit"`
+	SaldoAkhir float64 `json:"saldo_akhir"`
+	// Kolom bantu dari query, tidak dikirim ke client
+	SaldoTahun          float64 `json:"-"`
+	DebetSebelum        float64 `json:"-"`
+	KreditSebelum       float64 `json:"-"`
+	DebetSebelumFiskal  float64 `json:"-"`
+	KreditSebelumFiskal float64 `json:"-"`
+	// SaldoTahunLalu adalah saldo rekening R dari tahun fiskal sebelumnya yang tidak termasuk
+	// saldo awal, sesuai saldo normal rekening
+	SaldoTahunLalu float64 `json:"-"`
+}
+
+// BarisBukuBesar adalah satu baris detailjurnal pada buku besar sebuah rekening
+type BarisBukuBesar struct {
+	NoJurnal   string    `json:"no_jurnal"`
+	NoBukti    string    `json:"no_bukti"`
+	TglJurnal  time.Time `json:"tgl_jurnal"`
+	JamJurnal  string    `json:"jam_jurnal"`
+	Jenis      string    `json:"jenis"`
+	Keterangan string    `json:"keterangan"`
+	Debet      float64   `json:"debet"`
+	Kredit     float64   `json:"kredit"`
+	Saldo      float64   `json:"saldo"`
+}
+
+// TemuanJurnal adalah satu temuan dari pemeriksaan integritas jurnal
+type TemuanJurnal struct {
+	Jenis      string  `json:"jenis"`
+	NoJurnal   string  `json:"no_jurnal"`
+	NoBukti    string  `json:"no_bukti"`
+	NoRawat    string  `json:"no_rawat"`
+	KdRek      string  `json:"kd_rek"`
+	Tanggal    string  `json:"tanggal"`
+	Debet      float64 `json:"debet"`
+	Kredit     float64 `json:"kredit"`
+	Keterangan string  `json:"keterangan"`
+}
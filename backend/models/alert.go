@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Metrik harian yang dapat dipantau aturan alert
+const (
+	MetrikPendapatan        = "pendapatan"          // total pendapatan
+	MetrikPendapatanUnit    = "pendapatan_unit"     // per unit, dimensi jenis_unit:kd_unit
+	MetrikPendapatanPenjab  = "pendapatan_penjab"   // per penjab, dimensi kd_pj
+	MetrikKunjungan         = "kunjungan"           // total kunjungan (reg_periksa, tanpa batal)
+	MetrikKunjunganPoli     = "kunjungan_poli"      // per poli, dimensi kd_poli
+	MetrikPiutangBaru       = "piutang_baru"        // total piutang pasien baru
+	MetrikPiutangBaruPenjab = "piutang_baru_penjab" // per penjab, dimensi kd_pj
+)
+
+// Jenis pembanding aturan alert
+const (
+	AturanBatasBawah = "batas_bawah"         // nilai < Nilai
+	AturanBatasAtas  = "batas_atas"          // nilai > Nilai
+	AturanTurunBasis = "turun_dari_baseline" // turun >= Nilai persen dari baseline
+	AturanNaikBasis  = "naik_dari_baseline"  // naik >= Nilai persen dari baseline
+)
+
+// Tingkat keparahan alert
+const (
+	KeparahanInfo       = "info"
+	KeparahanPeringatan = "peringatan"
+	KeparahanKritis     = "kritis"
+)
+
+// Status alert
+const (
+	AlertBaru      = "baru"
+	AlertDitangani = "ditangani"
+)
+
+// AturanAlert membandingkan satu metrik harian dengan batas tetap atau dengan baseline, yaitu
+// rata-rata nilai pada hari yang sama di 4 minggu sebelumnya. Dimensi kosong berarti aturan
+// berlaku untuk setiap unit/poli/penjab pada metrik tersebut.
+type AturanAlert struct {
+	ID      uint    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nama    string  `json:"nama" gorm:"type:varchar(191);not null"`
+	Metrik  string  `json:"metrik" gorm:"type:varchar(32);not null"`
+	Dimensi string  `json:"dimensi" gorm:"type:varchar(64)"`
+	Jenis   string  `json:"jenis" gorm:"type:varchar(32);not null"`
+	Nilai   float64 `json:"nilai"`
+	// MinBaseline mencegah alert pada unit yang memang kecil; aturan baseline dilewati jika
+	// rata-rata baseline di bawah nilai ini
+	MinBaseline float64   `json:"min_baseline"`
+	Keparahan   string    `json:"keparahan" gorm:"type:varchar(16);not null"`
+	Kirim       bool      `json:"kirim"` // teruskan alert sebagai event webhook alert.harian
+	Aktif       bool      `json:"aktif" gorm:"not null"`
+	DibuatOleh  string    `json:"dibuat_oleh" gorm:"type:varchar(191)"`
+	CreatedAt   time.Time `json:"created_at" gorm:"type:datetime(3)"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (AturanAlert) TableName() string {
+	return "siak_aturan_alert"
+}
+
+// Alert adalah hasil satu aturan yang terpicu untuk satu tanggal dan dimensi
+type Alert struct {
+	ID          uint    `json:"id" gorm:"primaryKey;autoIncrement"`
+	AturanID    uint    `json:"aturan_id" gorm:"not null;uniqueIndex:idx_alert_aturan_tanggal"`
+	Tanggal     string  `json:"tanggal" gorm:"type:varchar(10);not null;index;uniqueIndex:idx_alert_aturan_tanggal"`
+	Metrik      string  `json:"metrik" gorm:"type:varchar(32);not null"`
+	Dimensi     string  `json:"dimensi" gorm:"type:varchar(64);uniqueIndex:idx_alert_aturan_tanggal"`
+	NamaDimensi string  `json:"nama_dimensi" gorm:"type:varchar(191)"`
+	Nilai       float64 `json:"nilai"`
+	// Pembanding adalah batas tetap atau rata-rata baseline, sesuai jenis aturan
+	Pembanding    float64    `json:"pembanding"`
+	Deviasi       *float64   `json:"deviasi_persen"`
+	Keparahan     string     `json:"keparahan" gorm:"type:varchar(16);not null"`
+	Pesan         string     `json:"pesan" gorm:"type:varchar(500)"`
+	Status        string     `json:"status" gorm:"type:varchar(16);not null;index"`
+	DitanganiOleh string     `json:"ditangani_oleh,omitempty" gorm:"type:varchar(191)"`
+	DitanganiPada *time.Time `json:"ditangani_pada" gorm:"type:datetime(3)"`
+	Catatan       string     `json:"catatan,omitempty" gorm:"type:text"`
+	DibuatPada    time.Time  `json:"dibuat_pada" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (Alert) TableName() string {
+	return "siak_alert"
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Jenis unit pendapatan yang dapat dianggarkan
+const (
+	UnitRawatJalan = "ralan"   // kd_unit berisi kd_poli
+	UnitRawatInap  = "ranap"   // kd_unit berisi kd_bangsal (bangsal terakhir pasien)
+	UnitFarmasi    = "farmasi" // kd_unit berisi kd_bangsal depo penjualan bebas
+)
+
+// AnggaranPendapatan adalah target pendapatan bulanan per unit dan penanggung jawab (penjab).
+// KdUnit atau KdPj kosong berarti target berlaku untuk seluruh unit atau seluruh penjab.
+type AnggaranPendapatan struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Periode    string    `json:"periode" gorm:"type:varchar(7);not null;uniqueIndex:idx_anggaran_unik"` // format YYYY-MM
+	JenisUnit  string    `json:"jenis_unit" gorm:"type:varchar(10);not null;uniqueIndex:idx_anggaran_unik"`
+	KdUnit     string    `json:"kd_unit" gorm:"type:varchar(10);not null;default:'';uniqueIndex:idx_anggaran_unik"`
+	KdPj       string    `json:"kd_pj" gorm:"type:varchar(3);not null;default:'';uniqueIndex:idx_anggaran_unik"`
+	Jumlah     float64   `json:"jumlah" gorm:"type:decimal(15,2);not null"`
+	Keterangan string    `json:"keterangan" gorm:"type:text"`
+	DibuatOleh string    `json:"dibuat_oleh" gorm:"type:varchar(191)"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:datetime(3)"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (AnggaranPendapatan) TableName() string {
+	return "siak_anggaran_pendapatan"
+}
+
+// RealisasiPendapatan adalah pendapatan aktual satu bulan per jenis unit, unit dan penjab
+type RealisasiPendapatan struct {
+	Bulan     string  `json:"bulan"`
+	JenisUnit string  `json:"jenis_unit"`
+	KdUnit    string  `json:"kd_unit"`
+	NmUnit    string  `json:"nm_unit"`
+	KdPj      string  `json:"kd_pj"`
+	PngJawab  string  `json:"png_jawab"`
+	Jumlah    float64 `json:"jumlah"`
+}
+
+// RealisasiAnggaran membandingkan anggaran dengan realisasi untuk satu kombinasi unit dan penjab,
+// baik untuk bulan yang diminta maupun kumulatif sejak awal tahun (YTD)
+type RealisasiAnggaran struct {
+	AnggaranID       uint    `json:"anggaran_id"`
+	JenisUnit        string  `json:"jenis_unit"`
+	KdUnit           string  `json:"kd_unit"`
+	NmUnit           string  `json:"nm_unit"`
+	KdPj             string  `json:"kd_pj"`
+	PngJawab         string  `json:"png_jawab"`
+	Anggaran         float64 `json:"anggaran"`
+	Realisasi        float64 `json:"realisasi"`
+	Selisih          float64 `json:"selisih"`
+	PersenCapaian    float64 `json:"persen_capaian"`
+	AnggaranYTD      float64 `json:"anggaran_ytd"`
+	RealisasiYTD     float64 `json:"realisasi_ytd"`
+	SelisihYTD       float64 `json:"selisih_ytd"`
+	PersenCapaianYTD float64 `json:"persen_capaian_ytd"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// HutangObat adalah model untuk hasil query hutang obat per faktur pemesanan
+type HutangObat struct {
+	NoFaktur      string             `json:"no_faktur"`
+	TglPesan      time.Time          `json:"tgl_pesan"`
+	TglFaktur     time.Time          `json:"tgl_faktur"`
+	TglTempo      time.Time          `json:"tgl_tempo"`
+	KodeSupplier  string             `json:"kode_supplier"`
+	NamaSupplier  string             `json:"nama_supplier"`
+	Tagihan       float64            `json:"tagihan"`
+	TotalBayar    float64            `json:"total_bayar"`
+	Sisa          float64            `json:"sisa"`
+	HariTerlambat int                `json:"hari_terlambat"`
+	Status        string             `json:"status"`
+	Pembayaran    []PembayaranHutang `json:"pembayaran" gorm:"-"`
+}
+
+// PembayaranHutang adalah riwayat pembayaran dari bayar_pemesanan
+type PembayaranHutang struct {
+	NoFaktur   string    `json:"no_faktur"`
+	TglBayar   time.Time `json:"tgl_bayar"`
+	BesarBayar float64   `json:"besar_bayar"`
+	NamaBayar  string    `json:"nama_bayar"`
+	NoBukti    string    `json:"no_bukti"`
+	Keterangan string    `json:"keterangan"`
+}
+
+// UmurHutangSupplier adalah ringkasan umur hutang per supplier
+type UmurHutangSupplier struct {
+	KodeSupplier     string  `json:"kode_supplier"`
+	NamaSupplier     string  `json:"nama_supplier"`
+	JumlahFaktur     int     `json:"jumlah_faktur"`
+	BelumJatuhTempo  float64 `json:"belum_jatuh_tempo"`
+	Terlambat1s30    float64 `json:"terlambat_1_30"`
+	Terlambat31s60   float64 `json:"terlambat_31_60"`
+	Terlambat61s90   float64 `json:"terlambat_61_90"`
+	TerlambatLebih90 float64 `json:"terlambat_lebih_90"`
+	TotalSisa        float64 `json:"total_sisa"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Status hasil satu kali eksekusi jadwal laporan
+const (
+	JadwalBerhasil = "berhasil"
+	JadwalGagal    = "gagal"
+)
+
+// JadwalLaporan adalah laporan yang dikirim otomatis lewat email sesuai ekspresi cron (zona waktu
+// rumah sakit). Parameter disimpan apa adanya sehingga periode relatif seperti yesterday atau
+// last_month dihitung ulang setiap kali jadwal berjalan.
+type JadwalLaporan struct {
+	ID             uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nama           string     `json:"nama" gorm:"type:varchar(191);not null"`
+	Cron           string     `json:"cron" gorm:"type:varchar(100);not null"`
+	Laporan        string     `json:"laporan" gorm:"type:varchar(64);not null"`
+	Parameter      string     `json:"parameter" gorm:"type:varchar(1000)"`
+	Format         string     `json:"format" gorm:"type:varchar(8);not null"`
+	Bagian         string     `json:"bagian" gorm:"type:varchar(64)"`
+	Penerima       string     `json:"penerima" gorm:"type:text;not null"` // alamat email dipisah koma
+	Aktif          bool       `json:"aktif" gorm:"not null"`
+	BerikutnyaPada *time.Time `json:"berikutnya_pada" gorm:"type:datetime(3);index"`
+	TerakhirPada   *time.Time `json:"terakhir_pada" gorm:"type:datetime(3)"`
+	TerakhirStatus string     `json:"terakhir_status" gorm:"type:varchar(16)"`
+	DibuatOleh     string     `json:"dibuat_oleh" gorm:"type:varchar(191)"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"type:datetime(3)"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (JadwalLaporan) TableName() string {
+	return "siak_jadwal_laporan"
+}
+
+// RiwayatJadwalLaporan mencatat hasil setiap eksekusi jadwal laporan
+type RiwayatJadwalLaporan struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	JadwalID     uint      `json:"jadwal_id" gorm:"not null;index"`
+	TanggalAwal  string    `json:"tanggal_awal" gorm:"type:varchar(10)"`
+	TanggalAkhir string    `json:"tanggal_akhir" gorm:"type:varchar(10)"`
+	Penerima     string    `json:"penerima" gorm:"type:text"`
+	Status       string    `json:"status" gorm:"type:varchar(16);not null"`
+	Pesan        string    `json:"pesan,omitempty" gorm:"type:text"`
+	UkuranBerkas int64     `json:"ukuran_berkas,omitempty"`
+	Manual       bool      `json:"manual"` // dijalankan lewat endpoint, bukan oleh penjadwal
+	DimulaiPada  time.Time `json:"dimulai_pada" gorm:"type:datetime(3)"`
+	SelesaiPada  time.Time `json:"selesai_pada" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (RiwayatJadwalLaporan) TableName() string {
+	return "siak_riwayat_jadwal_laporan"
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Status job laporan
+const (
+	JobAntri      = "antri"
+	JobBerjalan   = "berjalan"
+	JobSelesai    = "selesai"
+	JobGagal      = "gagal"
+	JobDibatalkan = "dibatalkan"
+)
+
+// Format berkas hasil job laporan
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// JobLaporan adalah permintaan laporan yang dijalankan di background. Hasilnya disimpan sebagai
+// berkas di disk dan dihapus bersama job setelah KedaluwarsaPada.
+type JobLaporan struct {
+	ID              string     `json:"id" gorm:"type:varchar(32);primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index:idx_job_user"`
+	Username        string     `json:"username" gorm:"type:varchar(191)"`
+	Laporan         string     `json:"laporan" gorm:"type:varchar(64);not null"`
+	Parameter       string     `json:"parameter" gorm:"type:varchar(1000)"`
+	Format          string     `json:"format" gorm:"type:varchar(8);not null"`
+	Bagian          string     `json:"bagian" gorm:"type:varchar(64)"` // array data yang diekspor ke CSV
+	Status          string     `json:"status" gorm:"type:varchar(16);not null;index:idx_job_status"`
+	Progres         int        `json:"progres"` // persen, 0-100
+	Tahap           string     `json:"tahap" gorm:"type:varchar(191)"`
+	Pesan           string     `json:"pesan,omitempty" gorm:"type:text"`
+	NamaBerkas      string     `json:"nama_berkas,omitempty" gorm:"type:varchar(255)"`
+	UkuranBerkas    int64      `json:"ukuran_berkas,omitempty"`
+	DibuatPada      time.Time  `json:"dibuat_pada" gorm:"type:datetime(3);index:idx_job_user"`
+	DimulaiPada     *time.Time `json:"dimulai_pada" gorm:"type:datetime(3)"`
+	SelesaiPada     *time.Time `json:"selesai_pada" gorm:"type:datetime(3)"`
+	KedaluwarsaPada *time.Time `json:"kedaluwarsa_pada" gorm:"type:datetime(3);index"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (JobLaporan) TableName() string {
+	return "siak_job_laporan"
+}
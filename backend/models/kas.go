@@ -0,0 +1,29 @@
+package models
+
+// ArusKas adalah model untuk hasil query arus kas harian per sumber, akun bayar dan petugas
+type ArusKas struct {
+	Tanggal         string  `json:"tanggal"`
+	Arus            string  `json:"arus"`
+	Sumber          string  `json:"sumber"`
+	Akun            string  `json:"akun"`
+	Petugas         string  `json:"petugas"`
+	JumlahTransaksi int     `json:"jumlah_transaksi"`
+	Nominal         float64 `json:"nominal"`
+}
+
+// KasHarian adalah saldo kas per hari. Saldo null jika saldo awal periode tidak diketahui.
+type KasHarian struct {
+	Tanggal    string   `json:"tanggal"`
+	SaldoAwal  *float64 `json:"saldo_awal"`
+	Masuk      float64  `json:"masuk"`
+	Keluar     float64  `json:"keluar"`
+	SaldoAkhir *float64 `json:"saldo_akhir"`
+}
+
+// RekapKas adalah ringkasan kas masuk dan keluar per kelompok (akun bayar atau petugas)
+type RekapKas struct {
+	Nama   string  `json:"nama"`
+	Masuk  float64 `json:"masuk"`
+	Keluar float64 `json:"keluar"`
+	Netto  float64 `json:"netto"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// KunjunganPasien adalah model untuk hasil query jumlah kunjungan reg_periksa per kombinasi status
+// daftar, poli, dokter, penjab, wilayah, kelompok umur dan jenis kelamin
+type KunjunganPasien struct {
+	StatusDaftar string `json:"status_daftar"`
+	KdPoli       string `json:"kd_poli"`
+	NmPoli       string `json:"nm_poli"`
+	KdDokter     string `json:"kd_dokter"`
+	NmDokter     string `json:"nm_dokter"`
+	KdPj         string `json:"kd_pj"`
+	PngJawab     string `json:"png_jawab"`
+	KdKab        string `json:"kd_kab"`
+	NmKab        string `json:"nm_kab"`
+	KdKec        string `json:"kd_kec"`
+	NmKec        string `json:"nm_kec"`
+	KelompokUmur string `json:"kelompok_umur"`
+	JenisKelamin string `json:"jenis_kelamin"`
+	Jumlah       int    `json:"jumlah"`
+}
+
+// RekapKunjungan adalah ringkasan kunjungan per kelompok beserta pembandingnya. Kolom pembanding
+// hanya diisi jika laporan diminta dengan parameter bandingkan.
+type RekapKunjungan struct {
+	Kode       string  `json:"kode"`
+	Nama       string  `json:"nama"`
+	Jumlah     int     `json:"jumlah"`
+	PasienBaru int     `json:"pasien_baru"`
+	PasienLama int     `json:"pasien_lama"`
+	Persen     float64 `json:"persen"` // persen terhadap total kunjungan periode
+
+	Pembanding      *int     `json:"pembanding,omitempty"`
+	Selisih         *int     `json:"selisih,omitempty"`
+	PersenPerubahan *float64 `json:"persen_perubahan,omitempty"` // kosong jika pembanding nol
+}
@@ -4,16 +4,31 @@ import "time"
 
 // LaporanRawatInap adalah model untuk hasil query laporan rawat inap
 type LaporanRawatInap struct {
-	NoRawat    string    `json:"no_rawat"`
-	NoRkmMedis string    `json:"no_rkm_medis"`
-	NmPasien   string    `json:"nm_pasien"`
+	NoRawat    string            `json:"no_rawat"`
+	NoRkmMedis string            `json:"no_rkm_medis"`
+	NmPasien   string            `json:"nm_pasien"`
+	TglMasuk   time.Time         `json:"tgl_masuk"`
+	TglKeluar  time.Time         `json:"tgl_keluar"`
+	KdBangsal  string            `json:"kd_bangsal"` // bangsal kamar terakhir
+	NmBangsal  string            `json:"nm_bangsal"`
+	NoNota     string            `json:"no_nota"`
+	Tanggal    time.Time         `json:"tanggal"`
+	BesarBayar float64           `json:"besar_bayar"`
+	PngJawab   string            `json:"png_jawab"`
+	KdPj       string            `json:"kd_pj"`
+	LamaInap   float64           `json:"lama_inap"` // jumlah lama seluruh kamar
+	Kamar      []DetailKamarInap `json:"kamar" gorm:"-"`
+}
+
+// DetailKamarInap adalah satu kamar yang ditempati pasien selama satu rawat inap
+type DetailKamarInap struct {
+	KdKamar    string    `json:"kd_kamar"`
+	KdBangsal  string    `json:"kd_bangsal"`
+	NmBangsal  string    `json:"nm_bangsal"`
 	TglMasuk   time.Time `json:"tgl_masuk"`
 	TglKeluar  time.Time `json:"tgl_keluar"`
-	NoNota     string    `json:"no_nota"`
-	Tanggal    time.Time `json:"tanggal"`
-	BesarBayar float64   `json:"besar_bayar"`
-	PngJawab   string    `json:"png_jawab"`
-	KdPj       string    `json:"kd_pj"`
+	Lama       float64   `json:"lama"`
+	SttsPulang string    `json:"stts_pulang"`
 }
 
 // LaporanRawatJalan adalah model untuk hasil query laporan rawat jalan
@@ -22,6 +37,7 @@ type LaporanRawatJalan struct {
 	NoRkmMedis    string    `json:"no_rkm_medis"`
 	NmPasien      string    `json:"nm_pasien"`
 	TglRegistrasi time.Time `json:"tgl_registrasi"`
+	KdPoli        string    `json:"kd_poli"`
 	NmPoli        string    `json:"nm_poli"`
 	NoNota        string    `json:"no_nota"`
 	TglBayar      time.Time `json:"tgl_bayar"`
@@ -33,6 +49,11 @@ type LaporanRawatJalan struct {
 // LaporanPiutangPasien adalah model untuk hasil query laporan piutang pasien
 type LaporanPiutangPasien struct {
 	NoRawat      string  `json:"no_rawat"`
+	TglPiutang   string  `json:"tgl_piutang"`
+	StatusLanjut string  `json:"status_lanjut"`
+	KdPoli       string  `json:"kd_poli"`
+	NmPoli       string  `json:"nm_poli"`
+	KdPj         string  `json:"kd_pj"`
 	PngJawab     string  `json:"png_jawab"`
 	NamaBayar    string  `json:"nama_bayar"`
 	TotalPiutang float64 `json:"totalpiutang"`
@@ -43,3 +64,23 @@ type TanggalFilter struct {
 	TanggalAwal  string `json:"tanggal_awal"`
 	TanggalAkhir string `json:"tanggal_akhir"`
 }
+
+// KamarRawatInap adalah satu baris kamar_inap sebuah rawat inap beserta tagihannya.
+// Pasien yang pindah kamar memiliki beberapa baris dengan tagihan (BesarBayar) yang sama.
+type KamarRawatInap struct {
+	NoRawat    string
+	NoRkmMedis string
+	NmPasien   string
+	TglMasuk   time.Time
+	TglKeluar  time.Time
+	SttsPulang string
+	KdKamar    string
+	KdBangsal  string
+	NmBangsal  string
+	Lama       float64
+	NoNota     string
+	Tanggal    time.Time
+	BesarBayar float64
+	PngJawab   string
+	KdPj       string
+}
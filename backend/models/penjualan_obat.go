@@ -4,9 +4,54 @@ import "time"
 
 // PenjualanBebasObat adalah model untuk hasil query penjualan bebas obat
 type PenjualanBebasObat struct {
-	NoPenjualan      string    `json:"no_penjualan"`
-	TanggalPenjualan time.Time `json:"tanggal_penjualan"`
-	Total            float64   `json:"total"`
+	NoPenjualan      string                `json:"no_penjualan"`
+	TanggalPenjualan time.Time             `json:"tanggal_penjualan"`
+	Nip              string                `json:"nip"`
+	Petugas          string                `json:"petugas"`
+	NamaBayar        string                `json:"nama_bayar"`
+	KdBangsal        string                `json:"kd_bangsal"` // depo
+	NmBangsal        string                `json:"nm_bangsal"`
+	Total            float64               `json:"total"`
+	Detail           []DetailPenjualanObat `json:"detail,omitempty" gorm:"-"`
+}
+
+// DetailPenjualanObat adalah baris detailjual dari satu nota penjualan bebas
+type DetailPenjualanObat struct {
+	NoPenjualan string  `json:"no_penjualan"`
+	KodeBrng    string  `json:"kode_brng"`
+	NamaObat    string  `json:"nama_obat"`
+	Satuan      string  `json:"satuan"`
+	Jumlah      float64 `json:"jumlah"`
+	HargaJual   float64 `json:"harga_jual"`
+	Subtotal    float64 `json:"subtotal"`
+	Diskon      float64 `json:"diskon"`
+	Tambahan    float64 `json:"tambahan"`
+	Embalase    float64 `json:"embalase"`
+	Tuslah      float64 `json:"tuslah"`
+	Total       float64 `json:"total"`
+}
+
+// RekapPenjualanBarang adalah rekap penjualan bebas per barang setelah dikurangi retur
+type RekapPenjualanBarang struct {
+	KodeBrng     string  `json:"kode_brng"`
+	NamaObat     string  `json:"nama_obat"`
+	Satuan       string  `json:"satuan"`
+	Jumlah       float64 `json:"jumlah"`
+	Total        float64 `json:"total"`
+	JumlahRetur  float64 `json:"jumlah_retur"`
+	TotalRetur   float64 `json:"total_retur"`
+	JumlahBersih float64 `json:"jumlah_bersih"`
+	TotalBersih  float64 `json:"total_bersih"`
+}
+
+// RekapPenjualan adalah rekap penjualan bebas per kelompok (petugas atau cara bayar)
+type RekapPenjualan struct {
+	Kode        string  `json:"kode"`
+	Nama        string  `json:"nama"`
+	JumlahNota  int     `json:"jumlah_nota"`
+	Total       float64 `json:"total"`
+	TotalRetur  float64 `json:"total_retur"`
+	TotalBersih float64 `json:"total_bersih"`
 }
 
 // PenerimaanObat adalah model untuk hasil query penerimaan obat
@@ -14,11 +59,26 @@ type PenerimaanObat struct {
 	NoPenerimaan      string    `json:"no_penerimaan"`
 	TanggalPenerimaan time.Time `json:"tanggal_penerimaan"`
 	KodeSupplier      string    `json:"kode_supplier"`
-	NamaObat          string    `json:"nama_obat"`
 	NamaSupplier      string    `json:"nama_supplier"`
-	Jumlah            int       `json:"jumlah"`
-	Satuan            string    `json:"satuan"`
-	HargaSatuan       float64   `json:"harga_satuan"`
 	Total             float64   `json:"total"`
-	Petugas           string    `json:"petugas"`
+	// Kolom berikut hanya diisi pada mode detail
+	Petugas  string                 `json:"petugas,omitempty"`
+	Potongan float64                `json:"potongan,omitempty"`
+	PPN      float64                `json:"ppn,omitempty"`
+	Meterai  float64                `json:"meterai,omitempty"`
+	Tagihan  float64                `json:"tagihan,omitempty"`
+	Detail   []DetailPenerimaanObat `json:"detail,omitempty" gorm:"-"`
+}
+
+// DetailPenerimaanObat adalah baris detailpesan dari satu faktur penerimaan obat
+type DetailPenerimaanObat struct {
+	NoPenerimaan string  `json:"no_penerimaan"`
+	KodeBrng     string  `json:"kode_brng"`
+	NamaObat     string  `json:"nama_obat"`
+	Jumlah       float64 `json:"jumlah"`
+	Satuan       string  `json:"satuan"`
+	HargaSatuan  float64 `json:"harga_satuan"`
+	Subtotal     float64 `json:"subtotal"`
+	Diskon       float64 `json:"diskon"`
+	Total        float64 `json:"total"`
 }
@@ -0,0 +1,52 @@
+package models
+
+// TitikHistoris adalah pendapatan aktual satu bulan beserta ramalan satu langkah model
+// (kosong untuk bulan yang dipakai inisialisasi model)
+type TitikHistoris struct {
+	Bulan  string   `json:"bulan"`
+	Jumlah float64  `json:"jumlah"`
+	Fitted *float64 `json:"fitted"`
+}
+
+// TitikProyeksi adalah ramalan pendapatan satu bulan beserta interval prediksinya
+type TitikProyeksi struct {
+	Bulan      string  `json:"bulan"`
+	Jumlah     float64 `json:"jumlah"`
+	BatasBawah float64 `json:"batas_bawah"`
+	BatasAtas  float64 `json:"batas_atas"`
+}
+
+// HasilBacktest adalah akurasi model ketika beberapa bulan terakhir disembunyikan lalu
+// diramalkan dari bulan-bulan sebelumnya
+type HasilBacktest struct {
+	JumlahBulan int      `json:"jumlah_bulan"`
+	MAE         float64  `json:"mae"`
+	RMSE        float64  `json:"rmse"`
+	MAPE        *float64 `json:"mape"` // persen; kosong jika seluruh nilai aktual nol
+	// CakupanInterval adalah persentase bulan aktual yang berada di dalam interval prediksi
+	CakupanInterval float64         `json:"cakupan_interval"`
+	Data            []TitikBacktest `json:"data"`
+}
+
+// TitikBacktest membandingkan pendapatan aktual dengan ramalan backtest satu bulan
+type TitikBacktest struct {
+	Bulan      string  `json:"bulan"`
+	Aktual     float64 `json:"aktual"`
+	Ramalan    float64 `json:"ramalan"`
+	BatasBawah float64 `json:"batas_bawah"`
+	BatasAtas  float64 `json:"batas_atas"`
+}
+
+// ProyeksiPendapatan adalah hasil peramalan pendapatan bulanan satu lini layanan
+type ProyeksiPendapatan struct {
+	JenisUnit string          `json:"jenis_unit"` // ralan, ranap, farmasi atau total
+	Model     string          `json:"model"`      // holt_winters atau holt (tanpa musiman)
+	Alpha     float64         `json:"alpha"`
+	Beta      float64         `json:"beta"`
+	Gamma     float64         `json:"gamma"`
+	Sigma     float64         `json:"sigma"`
+	Historis  []TitikHistoris `json:"historis"`
+	Proyeksi  []TitikProyeksi `json:"proyeksi"`
+	Backtest  *HasilBacktest  `json:"backtest"`
+	Pesan     string          `json:"pesan,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// PendapatanResep adalah model untuk hasil query pendapatan resep per depo, penjab, status lanjut dan jenis barang
+type PendapatanResep struct {
+	KdBangsal    string  `json:"kd_bangsal"`
+	NmBangsal    string  `json:"nm_bangsal"`
+	KdPj         string  `json:"kd_pj"`
+	PngJawab     string  `json:"png_jawab"`
+	StatusLanjut string  `json:"status_lanjut"`
+	Jenis        string  `json:"jenis"`
+	JumlahResep  int     `json:"jumlah_resep"`
+	Jumlah       float64 `json:"jumlah"`
+	Pendapatan   float64 `json:"pendapatan"`
+	Modal        float64 `json:"modal"`
+	Margin       float64 `json:"margin"`
+}
+
+// RekapPendapatanResep adalah ringkasan pendapatan resep per kelompok
+type RekapPendapatanResep struct {
+	Kode         string  `json:"kode"`
+	Nama         string  `json:"nama"`
+	Pendapatan   float64 `json:"pendapatan"`
+	Modal        float64 `json:"modal"`
+	Margin       float64 `json:"margin"`
+	PersenMargin float64 `json:"persen_margin"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// MutasiStokObat adalah model untuk hasil query mutasi stok obat per barang dan per depo
+type MutasiStokObat struct {
+	KodeBrng   string  `json:"kode_brng"`
+	NamaBrng   string  `json:"nama_brng"`
+	KodeSat    string  `json:"kode_sat"`
+	KdBangsal  string  `json:"kd_bangsal"`
+	NmBangsal  string  `json:"nm_bangsal"`
+	HBeli      float64 `json:"h_beli"`
+	StokAwal   float64 `json:"stok_awal"`
+	Penerimaan float64 `json:"penerimaan"`
+	// Pengeluaran dipecah berdasarkan sumbernya
+	KeluarResep     float64 `json:"keluar_resep"`
+	KeluarPenjualan float64 `json:"keluar_penjualan"`
+	MutasiMasuk     float64 `json:"mutasi_masuk"`
+	MutasiKeluar    float64 `json:"mutasi_keluar"`
+	ReturMasuk      float64 `json:"retur_masuk"`
+	ReturKeluar     float64 `json:"retur_keluar"`
+	Penyesuaian     float64 `json:"penyesuaian"`
+	StokAkhir       float64 `json:"stok_akhir"`
+	// Nilai persediaan dihitung dengan harga beli rata-rata (databarang.h_beli)
+	NilaiAwal       float64 `json:"nilai_awal"`
+	NilaiPenerimaan float64 `json:"nilai_penerimaan"`
+	NilaiKeluar     float64 `json:"nilai_keluar"`
+	NilaiAkhir      float64 `json:"nilai_akhir"`
+	// Kolom bantu dari query, tidak dikirim ke client
+	StokSekarang    float64 `json:"-"`
+	NetSetelahAkhir float64 `json:"-"`
+}
+
+// RekapStokDepo adalah ringkasan nilai persediaan per depo
+type RekapStokDepo struct {
+	KdBangsal       string  `json:"kd_bangsal"`
+	NmBangsal       string  `json:"nm_bangsal"`
+	JumlahItem      int     `json:"jumlah_item"`
+	NilaiAwal       float64 `json:"nilai_awal"`
+	NilaiPenerimaan float64 `json:"nilai_penerimaan"`
+	NilaiKeluar     float64 `json:"nilai_keluar"`
+	NilaiAkhir      float64 `json:"nilai_akhir"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// PeriodeTutup adalah periode laporan yang sudah ditutup (tutup buku).
+// Laporan untuk periode yang ditutup dilayani dari SnapshotLaporan.
+type PeriodeTutup struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TanggalAwal  string    `json:"tanggal_awal" gorm:"type:varchar(10);not null;index:idx_periode_tanggal"`
+	TanggalAkhir string    `json:"tanggal_akhir" gorm:"type:varchar(10);not null;index:idx_periode_tanggal"`
+	Catatan      string    `json:"catatan" gorm:"type:text"`
+	DitutupOleh  string    `json:"ditutup_oleh" gorm:"type:varchar(191)"`
+	DitutupPada  time.Time `json:"ditutup_pada" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (PeriodeTutup) TableName() string {
+	return "siak_periode_tutup"
+}
+
+// SnapshotLaporan menyimpan response lengkap (baris dan total) sebuah laporan saat periode ditutup
+type SnapshotLaporan struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PeriodeID  uint      `json:"periode_id" gorm:"not null;index:idx_snapshot_laporan"`
+	Laporan    string    `json:"laporan" gorm:"type:varchar(64);not null;index:idx_snapshot_laporan"`
+	Parameter  string    `json:"parameter" gorm:"type:varchar(255);not null;index:idx_snapshot_laporan"`
+	Data       string    `json:"-" gorm:"type:longtext"`
+	DibuatPada time.Time `json:"dibuat_pada" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (SnapshotLaporan) TableName() string {
+	return "siak_snapshot_laporan"
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Jenis event yang dapat dilanggan webhook
+const (
+	EventPendapatanHarian     = "pendapatan.harian"      // pendapatan satu hari sudah final
+	EventPiutangMelebihiBatas = "piutang.melebihi_batas" // piutang pasien melebihi batas
+	EventJadwalLaporanGagal   = "jadwal_laporan.gagal"   // jadwal laporan email gagal
+	EventAlertHarian          = "alert.harian"           // aturan alert harian terpicu
+	EventUjiWebhook           = "webhook.uji"            // dikirim lewat endpoint uji
+	EventSemua                = "*"                      // seluruh event
+)
+
+// Status pengiriman webhook
+const (
+	PengirimanMenunggu = "menunggu"
+	PengirimanMengirim = "mengirim"
+	PengirimanBerhasil = "berhasil"
+	PengirimanGagal    = "gagal"
+)
+
+// WebhookLangganan adalah URL yang menerima event SIAK. Setiap payload ditandatangani dengan
+// HMAC-SHA256 memakai Secret sehingga penerima dapat memastikan asal pesan.
+type WebhookLangganan struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nama       string    `json:"nama" gorm:"type:varchar(191);not null"`
+	URL        string    `json:"url" gorm:"type:varchar(500);not null"`
+	Event      string    `json:"event" gorm:"type:varchar(500);not null"` // jenis event dipisah koma
+	Secret     string    `json:"-" gorm:"type:varchar(128);not null"`
+	Aktif      bool      `json:"aktif" gorm:"not null"`
+	DibuatOleh string    `json:"dibuat_oleh" gorm:"type:varchar(191)"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:datetime(3)"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (WebhookLangganan) TableName() string {
+	return "siak_webhook_langganan"
+}
+
+// PengirimanWebhook adalah satu event yang dikirim ke satu langganan beserta hasil percobaan
+// terakhirnya. Pengiriman yang gagal dicoba ulang pada BerikutnyaPada.
+type PengirimanWebhook struct {
+	ID             uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	LanggananID    uint       `json:"langganan_id" gorm:"not null;index"`
+	Event          string     `json:"event" gorm:"type:varchar(64);not null"`
+	IDEvent        string     `json:"id_event" gorm:"type:varchar(32);not null"`
+	Payload        string     `json:"payload" gorm:"type:mediumtext"`
+	Status         string     `json:"status" gorm:"type:varchar(16);not null;index:idx_pengiriman_antrian"`
+	Percobaan      int        `json:"percobaan"`
+	StatusHTTP     int        `json:"status_http,omitempty"`
+	Respons        string     `json:"respons,omitempty" gorm:"type:text"`
+	Error          string     `json:"error,omitempty" gorm:"type:text"`
+	BerikutnyaPada *time.Time `json:"berikutnya_pada" gorm:"type:datetime(3);index:idx_pengiriman_antrian"`
+	TerakhirPada   *time.Time `json:"terakhir_pada" gorm:"type:datetime(3)"`
+	DibuatPada     time.Time  `json:"dibuat_pada" gorm:"type:datetime(3)"`
+}
+
+// TableName menentukan nama tabel milik SIAK agar tidak bercampur dengan tabel Khanza
+func (PengirimanWebhook) TableName() string {
+	return "siak_pengiriman_webhook"
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EntriCache adalah satu nilai yang tersimpan di cache beserta waktu pembuatan dan kedaluwarsanya
+type EntriCache struct {
+	Data            []byte
+	DibuatPada      time.Time
+	KedaluwarsaPada time.Time
+}
+
+// StatistikCache berisi ringkasan pemakaian cache
+type StatistikCache struct {
+	Jumlah    int   `json:"jumlah_entri"`
+	Kapasitas int   `json:"kapasitas"`
+	Hit       int64 `json:"hit"`
+	Miss      int64 `json:"miss"`
+}
+
+// Cache adalah penyimpanan nilai berumur terbatas. Implementasi bawaan adalah CacheLRU di memori;
+// implementasi lain (misalnya Redis) cukup memenuhi interface ini.
+type Cache interface {
+	// Get mengembalikan entri yang belum kedaluwarsa untuk kunci yang diberikan
+	Get(kunci string) (EntriCache, bool)
+	// Set menyimpan data dengan masa berlaku ttl
+	Set(kunci string, data []byte, ttl time.Duration)
+	// HapusAwalan menghapus seluruh entri dengan kunci berawalan tertentu (kosong = semua)
+	// dan mengembalikan jumlah entri yang dihapus
+	HapusAwalan(awalan string) int
+	// Statistik mengembalikan ringkasan pemakaian cache
+	Statistik() StatistikCache
+}
+
+// CacheLRU adalah Cache di memori dengan batas jumlah entri. Jika penuh, entri yang paling lama
+// tidak diakses dibuang lebih dulu.
+type CacheLRU struct {
+	mu        sync.Mutex
+	kapasitas int
+	urutan    *list.List // depan = paling baru diakses
+	entri     map[string]*list.Element
+	hit       int64
+	miss      int64
+}
+
+type elemenCache struct {
+	kunci string
+	EntriCache
+}
+
+// NewCacheLRU membuat CacheLRU dengan kapasitas jumlah entri tertentu
+func NewCacheLRU(kapasitas int) *CacheLRU {
+	if kapasitas < 1 {
+		kapasitas = 1
+	}
+	return &CacheLRU{
+		kapasitas: kapasitas,
+		urutan:    list.New(),
+		entri:     map[string]*list.Element{},
+	}
+}
+
+// Get mengembalikan entri yang belum kedaluwarsa. Entri kedaluwarsa langsung dibuang.
+func (c *CacheLRU) Get(kunci string) (EntriCache, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elemen, ok := c.entri[kunci]
+	if !ok {
+		c.miss++
+		return EntriCache{}, false
+	}
+
+	item := elemen.Value.(*elemenCache)
+	if time.Now().After(item.KedaluwarsaPada) {
+		c.hapusElemen(elemen)
+		c.miss++
+		return EntriCache{}, false
+	}
+
+	c.urutan.MoveToFront(elemen)
+	c.hit++
+	return item.EntriCache, true
+}
+
+// Set menyimpan data dengan masa berlaku ttl
+func (c *CacheLRU) Set(kunci string, data []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sekarang := time.Now()
+	entri := EntriCache{Data: data, DibuatPada: sekarang, KedaluwarsaPada: sekarang.Add(ttl)}
+
+	if elemen, ok := c.entri[kunci]; ok {
+		elemen.Value.(*elemenCache).EntriCache = entri
+		c.urutan.MoveToFront(elemen)
+		return
+	}
+
+	c.entri[kunci] = c.urutan.PushFront(&elemenCache{kunci: kunci, EntriCache: entri})
+	for c.urutan.Len() > c.kapasitas {
+		c.hapusElemen(c.urutan.Back())
+	}
+}
+
+// HapusAwalan menghapus entri dengan kunci berawalan tertentu (kosong = semua)
+func (c *CacheLRU) HapusAwalan(awalan string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	jumlah := 0
+	for kunci, elemen := range c.entri {
+		if strings.HasPrefix(kunci, awalan) {
+			c.hapusElemen(elemen)
+			jumlah++
+		}
+	}
+	return jumlah
+}
+
+// Statistik mengembalikan ringkasan pemakaian cache
+func (c *CacheLRU) Statistik() StatistikCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return StatistikCache{
+		Jumlah:    c.urutan.Len(),
+		Kapasitas: c.kapasitas,
+		Hit:       c.hit,
+		Miss:      c.miss,
+	}
+}
+
+// hapusElemen membuang satu elemen dari daftar dan map; pemanggil harus memegang mu
+func (c *CacheLRU) hapusElemen(elemen *list.Element) {
+	c.urutan.Remove(elemen)
+	delete(c.entri, elemen.Value.(*elemenCache).kunci)
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JadwalCron adalah ekspresi cron lima kolom (menit jam tanggal bulan hari) yang sudah di-parse.
+// Setiap kolom disimpan sebagai bitmask nilai yang cocok.
+type JadwalCron struct {
+	Ekspresi string
+
+	menit, jam, tanggal, bulan, hari uint64
+	// tanggalBebas dan hariBebas menandai kolom berisi *, untuk aturan tanggal ATAU hari
+	tanggalBebas, hariBebas bool
+}
+
+// makroCron adalah singkatan ekspresi cron yang didukung
+var makroCron = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// ParseCron mem-parsing ekspresi cron lima kolom: menit (0-59), jam (0-23), tanggal (1-31),
+// bulan (1-12) dan hari dalam minggu (0-6, 0 atau 7 = Minggu). Setiap kolom mendukung *,
+// daftar (1,15), rentang (1-5) dan langkah (*/15, 8-17/2), serta makro @hourly, @daily,
+// @weekly, @monthly dan @yearly. Contoh "0 7 * * *" berarti setiap hari pukul 07:00.
+func ParseCron(ekspresi string) (*JadwalCron, error) {
+	ekspresi = strings.TrimSpace(ekspresi)
+	teks := ekspresi
+	if makro, ok := makroCron[strings.ToLower(teks)]; ok {
+		teks = makro
+	}
+
+	kolom := strings.Fields(teks)
+	if len(kolom) != 5 {
+		return nil, fmt.Errorf("ekspresi cron harus terdiri dari 5 kolom (menit jam tanggal bulan hari): %q", ekspresi)
+	}
+
+	jadwal := &JadwalCron{Ekspresi: ekspresi}
+	batas := []struct {
+		nama     string
+		min, max int
+		hasil    *uint64
+	}{
+		{"menit", 0, 59, &jadwal.menit},
+		{"jam", 0, 23, &jadwal.jam},
+		{"tanggal", 1, 31, &jadwal.tanggal},
+		{"bulan", 1, 12, &jadwal.bulan},
+		{"hari", 0, 7, &jadwal.hari},
+	}
+	for i, b := range batas {
+		mask, err := parseKolomCron(kolom[i], b.min, b.max)
+		if err != nil {
+			return nil, fmt.Errorf("kolom %s tidak valid: %w", b.nama, err)
+		}
+		*b.hasil = mask
+	}
+
+	// Hari 7 sama dengan 0 (Minggu)
+	if jadwal.hari&(1<<7) != 0 {
+		jadwal.hari |= 1
+		jadwal.hari &^= 1 << 7
+	}
+	jadwal.tanggalBebas = strings.HasPrefix(kolom[2], "*")
+	jadwal.hariBebas = strings.HasPrefix(kolom[4], "*")
+	return jadwal, nil
+}
+
+// parseKolomCron mem-parsing satu kolom cron menjadi bitmask
+func parseKolomCron(kolom string, min, max int) (uint64, error) {
+	var mask uint64
+	for _, bagian := range strings.Split(kolom, ",") {
+		rentang, langkahStr, adaLangkah := strings.Cut(bagian, "/")
+		langkah := 1
+		if adaLangkah {
+			n, err := strconv.Atoi(langkahStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("langkah tidak valid: %q", bagian)
+			}
+			langkah = n
+		}
+
+		awal, akhir := min, max
+		if rentang != "*" {
+			awalStr, akhirStr, adaRentang := strings.Cut(rentang, "-")
+			var err error
+			if awal, err = strconv.Atoi(awalStr); err != nil {
+				return 0, fmt.Errorf("nilai tidak valid: %q", bagian)
+			}
+			akhir = awal
+			if adaRentang {
+				if akhir, err = strconv.Atoi(akhirStr); err != nil {
+					return 0, fmt.Errorf("nilai tidak valid: %q", bagian)
+				}
+			} else if adaLangkah {
+				akhir = max
+			}
+		}
+		if awal < min || akhir > max || awal > akhir {
+			return 0, fmt.Errorf("nilai %q di luar rentang %d-%d", bagian, min, max)
+		}
+
+		for n := awal; n <= akhir; n += langkah {
+			mask |= 1 << uint(n)
+		}
+	}
+	return mask, nil
+}
+
+// cocokTanggal memeriksa tanggal dan hari. Seperti cron pada umumnya, jika kolom tanggal dan
+// hari sama-sama dibatasi, jadwal berjalan bila salah satunya cocok.
+func (j *JadwalCron) cocokTanggal(t time.Time) bool {
+	cocokTgl := j.tanggal&(1<<uint(t.Day())) != 0
+	cocokHari := j.hari&(1<<uint(t.Weekday())) != 0
+	if j.tanggalBebas || j.hariBebas {
+		return cocokTgl && cocokHari
+	}
+	return cocokTgl || cocokHari
+}
+
+// Berikutnya mengembalikan waktu jadwal pertama setelah t, dihitung dalam zona waktu t.
+// Mengembalikan waktu nol jika tidak ada jadwal dalam lima tahun ke depan (misalnya 30 Februari).
+func (j *JadwalCron) Berikutnya(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	batas := t.AddDate(5, 0, 0)
+
+	for t.Before(batas) {
+		if j.bulan&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !j.cocokTanggal(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if j.jam&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if j.menit&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
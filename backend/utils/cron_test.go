@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJadwalCronBerikutnya(t *testing.T) {
+	wib, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waktu := func(tahun int, bulan time.Month, hari, jam, menit int) time.Time {
+		return time.Date(tahun, bulan, hari, jam, menit, 0, 0, wib)
+	}
+
+	tests := []struct {
+		nama     string
+		ekspresi string
+		dari     time.Time
+		ingin    time.Time
+	}{
+		{"harian sebelum jadwal", "0 7 * * *", waktu(2024, time.May, 10, 6, 59), waktu(2024, time.May, 10, 7, 0)},
+		{"harian tepat pada jadwal", "0 7 * * *", waktu(2024, time.May, 10, 7, 0), waktu(2024, time.May, 11, 7, 0)},
+		{"harian melewati akhir tahun", "0 7 * * *", waktu(2024, time.December, 31, 8, 0), waktu(2025, time.January, 1, 7, 0)},
+		{"awal bulan", "0 0 1 * *", waktu(2024, time.January, 31, 12, 0), waktu(2024, time.February, 1, 0, 0)},
+		{"awal bulan melewati akhir tahun", "0 0 1 * *", waktu(2024, time.December, 15, 0, 0), waktu(2025, time.January, 1, 0, 0)},
+		{"makro monthly", "@monthly", waktu(2024, time.February, 29, 23, 59), waktu(2024, time.March, 1, 0, 0)},
+		// Tanggal dan hari sama-sama dibatasi: jalan pada tanggal 13 ATAU hari Jumat
+		{"tanggal atau hari, hari cocok dulu", "0 9 13 * 5", waktu(2024, time.September, 1, 0, 0), waktu(2024, time.September, 6, 9, 0)},
+		{"tanggal atau hari, tanggal cocok dulu", "0 9 13 * 5", waktu(2024, time.May, 11, 0, 0), waktu(2024, time.May, 13, 9, 0)},
+		// Jika salah satu kolom *, kolom lainnya harus cocok
+		{"hanya tanggal", "0 9 13 * *", waktu(2024, time.September, 1, 0, 0), waktu(2024, time.September, 13, 9, 0)},
+		{"hanya hari", "0 9 * * 5", waktu(2024, time.May, 11, 0, 0), waktu(2024, time.May, 17, 9, 0)},
+		{"hari 7 adalah minggu", "0 6 * * 7", waktu(2024, time.May, 13, 0, 0), waktu(2024, time.May, 19, 6, 0)},
+		{"langkah menit", "*/15 8-9 * * 1-5", waktu(2024, time.May, 10, 9, 50), waktu(2024, time.May, 13, 8, 0)},
+		{"30 februari tidak pernah ada", "30 2 30 2 *", waktu(2024, time.January, 1, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			jadwal, err := ParseCron(tt.ekspresi)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error: %v", tt.ekspresi, err)
+			}
+			if got := jadwal.Berikutnya(tt.dari); !got.Equal(tt.ingin) {
+				t.Errorf("Berikutnya(%s) = %s, ingin %s", tt.dari, got, tt.ingin)
+			}
+		})
+	}
+}
+
+func TestParseCronTidakValid(t *testing.T) {
+	for _, ekspresi := range []string{
+		"",
+		"0 7 * *",
+		"0 7 * * * *",
+		"60 * * * *",
+		"0 24 * * *",
+		"0 0 0 * *",
+		"0 0 1 13 *",
+		"0 0 * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@setiapmenit",
+	} {
+		if _, err := ParseCron(ekspresi); err == nil {
+			t.Errorf("ParseCron(%q) tidak mengembalikan error", ekspresi)
+		}
+	}
+}
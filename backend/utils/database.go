@@ -1,24 +1,100 @@
 package utils
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-// DB adalah instance database PostgreSQL yang akan digunakan di seluruh aplikasi
+// DB adalah koneksi baca-tulis ke database SIAK (user, pengaturan, tutup buku, anggaran, audit)
 var DB *gorm.DB
 
-// MySQLDB adalah instance database MySQL yang akan digunakan di seluruh aplikasi
+// MySQLDB adalah koneksi baca-saja ke database Khanza yang dipakai laporan. Koneksi ini dapat
+// diarahkan ke replica agar query laporan tidak membebani database produksi.
 var MySQLDB *gorm.DB
 
+// kontrolKhanza adalah pool kecil terpisah ke database Khanza untuk perintah kontrol seperti
+// KILL QUERY. Pool laporan bisa penuh oleh query yang justru ingin dihentikan, sehingga perintah
+// kontrol tidak boleh meminjam koneksi dari pool tersebut.
+var (
+	kontrolKhanza     *sql.DB
+	kontrolKhanzaErr  error
+	kontrolKhanzaOnce sync.Once
+)
+
 // Menyimpan tipe database yang sedang digunakan sebagai database utama
 var dbTypeUsed string
 
+// KonfigurasiKoneksi berisi parameter satu koneksi MySQL beserta ukuran pool-nya
+type KonfigurasiKoneksi struct {
+	Host            string
+	Port            string
+	User            string
+	Password        string
+	DBName          string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// DSN menyusun data source name untuk driver MySQL
+func (k KonfigurasiKoneksi) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		k.User, k.Password, k.Host, k.Port, k.DBName)
+}
+
+// bacaKonfigurasiKoneksi membaca konfigurasi koneksi dengan awalan variabel lingkungan tertentu
+// (contoh: KHANZA_HOST). Parameter yang tidak diisi memakai MYSQL_* agar konfigurasi lama tetap
+// berjalan dengan satu database.
+func bacaKonfigurasiKoneksi(awalan string, maxOpen, maxIdle int) KonfigurasiKoneksi {
+	env := func(nama string) string {
+		return getEnv(awalan+"_"+nama, os.Getenv("MYSQL_"+nama))
+	}
+	envInt := func(nama string, nilaiDefault int) int {
+		if n, err := strconv.Atoi(getEnv(awalan+"_"+nama, "")); err == nil && n >= 0 {
+			return n
+		}
+		return nilaiDefault
+	}
+
+	lifetime := 30 * time.Minute
+	if d, err := time.ParseDuration(getEnv(awalan+"_CONN_MAX_LIFETIME", "")); err == nil && d > 0 {
+		lifetime = d
+	}
+
+	return KonfigurasiKoneksi{
+		Host:            env("HOST"),
+		Port:            env("PORT"),
+		User:            env("USER"),
+		Password:        env("PASSWORD"),
+		DBName:          env("DBNAME"),
+		MaxOpenConns:    envInt("MAX_OPEN_CONNS", maxOpen),
+		MaxIdleConns:    envInt("MAX_IDLE_CONNS", maxIdle),
+		ConnMaxLifetime: lifetime,
+	}
+}
+
+// KonfigurasiKhanza mengembalikan konfigurasi koneksi Khanza (KHANZA_*). KHANZA_HOST dapat
+// diarahkan ke replica; pool default 10 koneksi terbuka dan 5 idle.
+func KonfigurasiKhanza() KonfigurasiKoneksi {
+	return bacaKonfigurasiKoneksi("KHANZA", 10, 5)
+}
+
+// KonfigurasiSIAK mengembalikan konfigurasi koneksi SIAK (SIAK_*); pool default 5 koneksi
+// terbuka dan 2 idle
+func KonfigurasiSIAK() KonfigurasiKoneksi {
+	return bacaKonfigurasiKoneksi("SIAK", 5, 2)
+}
+
 // InitDatabase menginisialisasi koneksi database
 func InitDatabase() {
 	// Load .env
@@ -27,28 +103,68 @@ func InitDatabase() {
 		log.Println("Menggunakan variabel lingkungan sistem")
 	}
 
-	// Hanya inisialisasi MySQL
-	initMySQLDB()
+	MySQLDB = bukaKoneksi("Khanza", KonfigurasiKhanza())
+	tolakPenulisan(MySQLDB)
+
+	DB = bukaKoneksi("SIAK", KonfigurasiSIAK())
+	dbTypeUsed = "mysql"
 }
 
-// initMySQLDB menginisialisasi koneksi ke database MySQL
-func initMySQLDB() {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		os.Getenv("MYSQL_USER"),
-		os.Getenv("MYSQL_PASSWORD"),
-		os.Getenv("MYSQL_HOST"),
-		os.Getenv("MYSQL_PORT"),
-		os.Getenv("MYSQL_DBNAME"))
+// bukaKoneksi membuka koneksi MySQL dan mengatur ukuran pool-nya
+func bukaKoneksi(nama string, konfigurasi KonfigurasiKoneksi) *gorm.DB {
+	db, err := gorm.Open(mysql.Open(konfigurasi.DSN()), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Gagal terhubung ke MySQL %s: %v", nama, err) // Hentikan aplikasi jika gagal
+	}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Gagal terhubung ke MySQL: %v", err) // Hentikan aplikasi jika gagal
+		log.Fatalf("Gagal mendapatkan instance SQL DB %s: %v", nama, err)
 	}
+	sqlDB.SetMaxOpenConns(konfigurasi.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(konfigurasi.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(konfigurasi.ConnMaxLifetime)
+
+	log.Printf("MySQL %s siap digunakan: %s@%s:%s/%s (max open %d, max idle %d, lifetime %s)",
+		nama, konfigurasi.User, konfigurasi.Host, konfigurasi.Port, konfigurasi.DBName,
+		konfigurasi.MaxOpenConns, konfigurasi.MaxIdleConns, konfigurasi.ConnMaxLifetime)
+	return db
+}
+
+// ErrKoneksiBacaSaja dikembalikan jika ada upaya menulis lewat koneksi Khanza
+var ErrKoneksiBacaSaja = errors.New("koneksi Khanza hanya untuk membaca")
 
-	// Set kedua variabel DB dan MySQLDB ke koneksi yang sama
-	DB = db
-	MySQLDB = db
-	log.Println("MySQL siap digunakan")
+// tolakPenulisan memasang callback yang menolak create, update, delete dan Exec pada koneksi,
+// sehingga kesalahan kode tidak pernah mengubah data Khanza
+func tolakPenulisan(db *gorm.DB) {
+	tolak := func(tx *gorm.DB) {
+		tx.AddError(ErrKoneksiBacaSaja)
+	}
+	db.Callback().Create().Before("gorm:create").Register("siak:baca_saja", tolak)
+	db.Callback().Update().Before("gorm:update").Register("siak:baca_saja", tolak)
+	db.Callback().Delete().Before("gorm:delete").Register("siak:baca_saja", tolak)
+	db.Callback().Raw().Before("gorm:raw").Register("siak:baca_saja", tolak)
+}
+
+// AutoMigrateSIAK membuat atau memperbarui tabel milik SIAK (bukan tabel Khanza).
+// Secara default migrasi tidak dijalankan karena user database produksi biasanya tidak memiliki
+// hak DDL; aktifkan dengan SIAK_AUTO_MIGRATE=true atau buat tabel secara manual.
+func AutoMigrateSIAK(models ...interface{}) {
+	if getEnv("SIAK_AUTO_MIGRATE", "false") != "true" {
+		log.Println("Auto migrate tabel SIAK dinonaktifkan (SIAK_AUTO_MIGRATE != true)")
+		return
+	}
+
+	if DB == nil {
+		log.Println("Auto migrate tabel SIAK dilewati: database belum diinisialisasi")
+		return
+	}
+
+	if err := DB.AutoMigrate(models...); err != nil {
+		log.Printf("Gagal auto migrate tabel SIAK: %v", err)
+		return
+	}
+	log.Println("Auto migrate tabel SIAK selesai")
 }
 
 // getEnv mengambil nilai variabel lingkungan atau nilai default jika tidak ada
@@ -64,32 +180,52 @@ func GetDatabaseType() string {
 	return dbTypeUsed
 }
 
-// GetDB mengembalikan instance database yang telah diinisialisasi
+// GetDB mengembalikan koneksi baca-tulis ke database SIAK
 func GetDB() *gorm.DB {
 	return DB
 }
 
-// GetMySQLDB mengembalikan instance database MySQL yang telah diinisialisasi
+// GetMySQLDB mengembalikan koneksi baca-saja ke database Khanza
 func GetMySQLDB() *gorm.DB {
-	return DB
+	return MySQLDB
 }
 
-// CloseDatabase menutup koneksi database
+// GetKontrolKhanza mengembalikan pool kontrol Khanza (maksimal 2 koneksi) yang dibuka saat
+// pertama kali dibutuhkan. Koneksi idle ditutup setelah satu menit.
+func GetKontrolKhanza() (*sql.DB, error) {
+	kontrolKhanzaOnce.Do(func() {
+		kontrolKhanza, kontrolKhanzaErr = sql.Open("mysql", KonfigurasiKhanza().DSN())
+		if kontrolKhanzaErr != nil {
+			return
+		}
+		kontrolKhanza.SetMaxOpenConns(2)
+		kontrolKhanza.SetMaxIdleConns(1)
+		kontrolKhanza.SetConnMaxIdleTime(time.Minute)
+	})
+	return kontrolKhanza, kontrolKhanzaErr
+}
+
+// CloseDatabase menutup koneksi database Khanza dan SIAK
 func CloseDatabase() {
-	// Tutup koneksi MySQL jika ada
-	if MySQLDB != nil && MySQLDB != DB { // Hindari menutup dua kali jika MySQLDB sama dengan DB
-		sqlDB, err := MySQLDB.DB()
+	for nama, db := range map[string]*gorm.DB{"Khanza": MySQLDB, "SIAK": DB} {
+		if db == nil {
+			continue
+		}
+		sqlDB, err := db.DB()
 		if err != nil {
-			log.Printf("Error mendapatkan database MySQL: %v", err)
-		} else {
-			sqlDB.Close()
-			log.Println("Koneksi MySQL ditutup")
+			log.Printf("Error mendapatkan database %s: %v", nama, err)
+			continue
 		}
+		sqlDB.Close()
+		log.Printf("Koneksi MySQL %s ditutup", nama)
+	}
+	if kontrolKhanza != nil {
+		kontrolKhanza.Close()
 	}
 }
 
 // GetUserDB mengembalikan instance database yang benar untuk operasi user
 func GetUserDB() *gorm.DB {
-	// Gunakan database utama (bisa MySQL)
+	// Tabel user milik SIAK
 	return DB
 }
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TabelEkspor adalah satu tabel hasil laporan yang akan ditulis ke berkas ekspor. Sel bernilai
+// angka (json.Number, float64, int) ditulis sebagai angka pada format yang mendukungnya.
+type TabelEkspor struct {
+	Nama  string
+	Kolom []string
+	Baris [][]interface{}
+}
+
+// DokumenEkspor adalah isi berkas ekspor: judul dan satu atau lebih tabel
+type DokumenEkspor struct {
+	Judul string
+	Tabel []TabelEkspor
+}
+
+// teksSel mengubah nilai sel menjadi teks
+func teksSel(nilai interface{}) string {
+	switch v := nilai.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// angkaSel mengembalikan teks angka jika sel bernilai angka
+func angkaSel(nilai interface{}) (string, bool) {
+	switch v := nilai.(type) {
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int, int64, uint, uint64:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// TulisCSV menulis satu tabel sebagai CSV dengan baris judul kolom
+func TulisCSV(w io.Writer, tabel TabelEkspor) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(tabel.Kolom); err != nil {
+		return err
+	}
+
+	baris := make([]string, len(tabel.Kolom))
+	for _, sel := range tabel.Baris {
+		for i := range baris {
+			baris[i] = ""
+			if i < len(sel) {
+				baris[i] = teksSel(sel[i])
+			}
+		}
+		if err := writer.Write(baris); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
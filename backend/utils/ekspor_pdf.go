@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Berkas PDF ditulis langsung (PDF 1.4, halaman A4 landscape) memakai font standar Courier.
+// Font monospace membuat kolom tabel cukup disusun dengan spasi tanpa perlu tabel lebar glyph.
+
+const (
+	lebarHalamanPDF  = 842.0
+	tinggiHalamanPDF = 595.0
+	marginPDF        = 28.0
+	// lebarKarakterPDF adalah lebar satu karakter Courier relatif terhadap ukuran font
+	lebarKarakterPDF = 0.6
+	maksLebarKolom   = 40
+)
+
+// penulisPDF menyusun isi (content stream) setiap halaman
+type penulisPDF struct {
+	halaman []*bytes.Buffer
+	y       float64
+}
+
+// TulisPDF menulis dokumen sebagai PDF: judul di halaman pertama lalu setiap tabel berurutan.
+// Kolom yang terlalu lebar dipotong agar baris muat dalam satu halaman.
+func TulisPDF(w io.Writer, dokumen DokumenEkspor) error {
+	p := &penulisPDF{}
+	p.halamanBaru()
+	if dokumen.Judul != "" {
+		p.teks(marginPDF, "F2", 12, dokumen.Judul)
+		p.y -= 20
+	}
+	for _, tabel := range dokumen.Tabel {
+		p.tulisTabel(tabel)
+	}
+	return p.simpan(w)
+}
+
+// halamanBaru menambah halaman beserta nomor halaman di bagian bawah
+func (p *penulisPDF) halamanBaru() {
+	p.halaman = append(p.halaman, &bytes.Buffer{})
+	p.y = tinggiHalamanPDF - marginPDF - 10
+	p.teksPada(marginPDF, marginPDF/2, "F1", 7, fmt.Sprintf("Halaman %d", len(p.halaman)))
+}
+
+// teks menulis satu baris teks pada posisi y saat ini
+func (p *penulisPDF) teks(x float64, font string, ukuran float64, isi string) {
+	p.teksPada(x, p.y, font, ukuran, isi)
+}
+
+// teksPada menulis satu baris teks pada posisi tertentu
+func (p *penulisPDF) teksPada(x, y float64, font string, ukuran float64, isi string) {
+	fmt.Fprintf(p.halaman[len(p.halaman)-1], "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, ukuran, x, y, escapePDF(isi))
+}
+
+// garis menggambar garis horizontal selebar area cetak pada posisi y
+func (p *penulisPDF) garis(y float64) {
+	fmt.Fprintf(p.halaman[len(p.halaman)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", marginPDF, y, lebarHalamanPDF-marginPDF, y)
+}
+
+// tulisTabel menulis nama tabel, judul kolom (diulang di setiap halaman) dan seluruh baris
+func (p *penulisPDF) tulisTabel(tabel TabelEkspor) {
+	ukuran, lebar := tataLetakKolomPDF(tabel)
+	tinggiBaris := ukuran * 1.35
+
+	if p.y-14-3*tinggiBaris < marginPDF {
+		p.halamanBaru()
+	}
+	p.teks(marginPDF, "F2", 10, tabel.Nama)
+	p.y -= 14
+
+	judulKolom := func() {
+		sel := make([]interface{}, len(tabel.Kolom))
+		for i, kolom := range tabel.Kolom {
+			sel[i] = kolom
+		}
+		p.teks(marginPDF, "F2", ukuran, susunBarisPDF(sel, lebar))
+		p.garis(p.y - ukuran*0.35)
+		p.y -= tinggiBaris
+	}
+	judulKolom()
+
+	if len(tabel.Baris) == 0 {
+		p.teks(marginPDF, "F1", ukuran, "(tidak ada data)")
+		p.y -= tinggiBaris
+	}
+	for _, baris := range tabel.Baris {
+		if p.y-tinggiBaris < marginPDF {
+			p.halamanBaru()
+			judulKolom()
+		}
+		p.teks(marginPDF, "F1", ukuran, susunBarisPDF(baris, lebar))
+		p.y -= tinggiBaris
+	}
+	p.y -= tinggiBaris
+}
+
+// tataLetakKolomPDF menentukan ukuran font (8 sampai 5 pt) dan lebar setiap kolom dalam karakter.
+// Jika pada ukuran terkecil baris masih terlalu lebar, kolom terlebar dipersempit bertahap.
+func tataLetakKolomPDF(tabel TabelEkspor) (float64, []int) {
+	lebar := make([]int, len(tabel.Kolom))
+	for i, kolom := range tabel.Kolom {
+		lebar[i] = len([]rune(kolom))
+	}
+	for _, baris := range tabel.Baris {
+		for i := 0; i < len(baris) && i < len(lebar); i++ {
+			if n := len([]rune(teksSel(baris[i]))); n > lebar[i] {
+				lebar[i] = n
+			}
+		}
+	}
+
+	total := 0
+	for i := range lebar {
+		if lebar[i] > maksLebarKolom {
+			lebar[i] = maksLebarKolom
+		}
+		if lebar[i] < 3 {
+			lebar[i] = 3
+		}
+		total += lebar[i] + 2
+	}
+
+	areaCetak := lebarHalamanPDF - 2*marginPDF
+	ukuran := 8.0
+	for ukuran > 5 && float64(total)*lebarKarakterPDF*ukuran > areaCetak {
+		ukuran -= 0.5
+	}
+
+	maksKarakter := int(areaCetak / (lebarKarakterPDF * ukuran))
+	for total > maksKarakter {
+		terlebar := 0
+		for i := range lebar {
+			if lebar[i] > lebar[terlebar] {
+				terlebar = i
+			}
+		}
+		if lebar[terlebar] <= 4 {
+			break
+		}
+		lebar[terlebar]--
+		total--
+	}
+	return ukuran, lebar
+}
+
+// susunBarisPDF menyusun sel menjadi satu baris teks; angka rata kanan, teks rata kiri
+func susunBarisPDF(baris []interface{}, lebar []int) string {
+	var sb strings.Builder
+	for i, n := range lebar {
+		var sel interface{}
+		if i < len(baris) {
+			sel = baris[i]
+		}
+		teks := []rune(teksSel(sel))
+		if len(teks) > n {
+			teks = append(teks[:n-1], '~')
+		}
+		isian := strings.Repeat(" ", n-len(teks))
+		if _, angka := angkaSel(sel); angka {
+			sb.WriteString(isian + string(teks))
+		} else {
+			sb.WriteString(string(teks) + isian)
+		}
+		sb.WriteString("  ")
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// escapePDF mengubah teks ke WinAnsi (karakter di luar Latin-1 menjadi ?) dan meng-escape
+// karakter khusus string PDF
+func escapePDF(teks string) string {
+	var sb strings.Builder
+	for _, r := range teks {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(byte(r))
+		case r == '\n' || r == '\r' || r == '\t':
+			sb.WriteByte(' ')
+		case r < 32 || r > 255:
+			sb.WriteByte('?')
+		default:
+			sb.WriteByte(byte(r))
+		}
+	}
+	return sb.String()
+}
+
+// simpan menulis seluruh objek PDF beserta tabel xref
+func (p *penulisPDF) simpan(w io.Writer) error {
+	var buf bytes.Buffer
+	jumlahObjek := 4 + 2*len(p.halaman)
+	offset := make([]int, jumlahObjek+1)
+
+	objek := func(nomor int, isi string) {
+		offset[nomor] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", nomor, isi)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	objek(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	objek(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	objek(4, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	kids := make([]string, len(p.halaman))
+	for i, isi := range p.halaman {
+		nomorIsi, nomorHalaman := 5+2*i, 6+2*i
+		objek(nomorIsi, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", isi.Len(), isi.String()))
+		objek(nomorHalaman, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			lebarHalamanPDF, tinggiHalamanPDF, nomorIsi))
+		kids[i] = fmt.Sprintf("%d 0 R", nomorHalaman)
+	}
+	objek(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.halaman)))
+
+	awalXref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", jumlahObjek+1)
+	for nomor := 1; nomor <= jumlahObjek; nomor++ {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset[nomor])
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", jumlahObjek+1, awalXref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Berkas XLSX ditulis langsung sebagai arsip zip SpreadsheetML minimal (satu sheet per tabel,
+// teks inline tanpa sharedStrings) agar tidak memerlukan pustaka spreadsheet tambahan.
+
+const xlsxContentTypesAwal = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles berisi dua format sel: 0 normal dan 1 tebal untuk judul kolom
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// TulisXLSX menulis dokumen sebagai workbook XLSX dengan satu sheet untuk setiap tabel
+func TulisXLSX(w io.Writer, dokumen DokumenEkspor) error {
+	arsip := zip.NewWriter(w)
+	namaSheet := namaSheetXLSX(dokumen.Tabel)
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xlsxContentTypesAwal)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, nama := range namaSheet {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(nama), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(namaSheet)+1)
+	workbookRels.WriteString(`</Relationships>`)
+
+	bagian := []struct{ nama, isi string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, b := range bagian {
+		f, err := arsip.Create(b.nama)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, b.isi); err != nil {
+			return err
+		}
+	}
+
+	for i, tabel := range dokumen.Tabel {
+		f, err := arsip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := tulisSheetXLSX(f, tabel); err != nil {
+			return err
+		}
+	}
+
+	return arsip.Close()
+}
+
+// tulisSheetXLSX menulis satu worksheet; baris judul kolom dibekukan di atas
+func tulisSheetXLSX(w io.Writer, tabel TabelEkspor) error {
+	buf := bufio.NewWriter(w)
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	buf.WriteString(`<sheetData><row r="1">`)
+	for i, kolom := range tabel.Kolom {
+		fmt.Fprintf(buf, `<c r="%s1" t="inlineStr" s="1"><is><t xml:space="preserve">%s</t></is></c>`, kolomXLSX(i), escapeXML(kolom))
+	}
+	buf.WriteString(`</row>`)
+
+	for r, baris := range tabel.Baris {
+		nomor := strconv.Itoa(r + 2)
+		fmt.Fprintf(buf, `<row r="%s">`, nomor)
+		for i, sel := range baris {
+			ref := kolomXLSX(i) + nomor
+			if angka, ok := angkaSel(sel); ok {
+				fmt.Fprintf(buf, `<c r="%s"><v>%s</v></c>`, ref, angka)
+				continue
+			}
+			if teks := teksSel(sel); teks != "" {
+				fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(teks))
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.Flush()
+}
+
+// kolomXLSX mengubah indeks kolom (mulai 0) menjadi huruf kolom: A, B, ..., Z, AA, AB, ...
+func kolomXLSX(indeks int) string {
+	nama := ""
+	for indeks >= 0 {
+		nama = string(rune('A'+indeks%26)) + nama
+		indeks = indeks/26 - 1
+	}
+	return nama
+}
+
+// namaSheetXLSX menyusun nama sheet yang valid dan unik: maksimal 31 karakter tanpa : \ / ? * [ ]
+func namaSheetXLSX(daftar []TabelEkspor) []string {
+	hasil := make([]string, len(daftar))
+	dipakai := map[string]bool{}
+	for i, tabel := range daftar {
+		nama := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`:\/?*[]`, r) {
+				return '_'
+			}
+			return r
+		}, tabel.Nama)
+		if nama == "" {
+			nama = fmt.Sprintf("Sheet%d", i+1)
+		}
+		if len([]rune(nama)) > 31 {
+			nama = string([]rune(nama)[:31])
+		}
+
+		dasar := nama
+		for n := 2; dipakai[strings.ToLower(nama)]; n++ {
+			akhiran := fmt.Sprintf(" (%d)", n)
+			runes := []rune(dasar)
+			if len(runes)+len(akhiran) > 31 {
+				runes = runes[:31-len(akhiran)]
+			}
+			nama = string(runes) + akhiran
+		}
+		dipakai[strings.ToLower(nama)] = true
+		hasil[i] = nama
+	}
+	return hasil
+}
+
+// escapeXML meng-escape teks untuk isi atau atribut XML; karakter yang tidak valid di XML diganti
+func escapeXML(teks string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(teks))
+	return sb.String()
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// KonfigurasiSMTP adalah pengaturan server SMTP untuk pengiriman email
+type KonfigurasiSMTP struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Pengirim string
+	// Mode koneksi: starttls (default, dipakai jika server mendukung), tls (TLS langsung,
+	// biasanya port 465) atau none (tanpa enkripsi, misalnya server SMTP lokal untuk pengujian)
+	Mode string
+}
+
+// LampiranEmail adalah berkas yang dilampirkan pada email
+type LampiranEmail struct {
+	NamaBerkas  string
+	ContentType string
+	Data        []byte
+}
+
+// PesanEmail adalah email teks biasa dengan lampiran opsional
+type PesanEmail struct {
+	Penerima []string
+	Subjek   string
+	Isi      string
+	Lampiran []LampiranEmail
+}
+
+// KonfigurasiSMTPDariEnv membaca pengaturan SMTP dari SMTP_HOST, SMTP_PORT (default 587),
+// SMTP_USER, SMTP_PASSWORD, SMTP_FROM (default SMTP_USER) dan SMTP_MODE
+func KonfigurasiSMTPDariEnv() KonfigurasiSMTP {
+	cfg := KonfigurasiSMTP{
+		Host:     getEnv("SMTP_HOST", ""),
+		Port:     getEnv("SMTP_PORT", "587"),
+		User:     getEnv("SMTP_USER", ""),
+		Password: getEnv("SMTP_PASSWORD", ""),
+		Mode:     strings.ToLower(getEnv("SMTP_MODE", "starttls")),
+	}
+	cfg.Pengirim = getEnv("SMTP_FROM", cfg.User)
+	return cfg
+}
+
+// KirimEmail mengirim pesan melalui server SMTP
+func KirimEmail(cfg KonfigurasiSMTP, pesan PesanEmail) error {
+	if cfg.Host == "" {
+		return errors.New("SMTP belum dikonfigurasi (SMTP_HOST kosong)")
+	}
+	if cfg.Pengirim == "" {
+		return errors.New("alamat pengirim email belum dikonfigurasi (SMTP_FROM)")
+	}
+	if len(pesan.Penerima) == 0 {
+		return errors.New("penerima email kosong")
+	}
+
+	isi, err := susunEmail(cfg.Pengirim, pesan)
+	if err != nil {
+		return err
+	}
+
+	alamat := net.JoinHostPort(cfg.Host, cfg.Port)
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	var conn net.Conn
+	if cfg.Mode == "tls" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", alamat, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", alamat, 30*time.Second)
+	}
+	if err != nil {
+		return fmt.Errorf("gagal terhubung ke server SMTP %s: %w", alamat, err)
+	}
+	conn.SetDeadline(time.Now().Add(2 * time.Minute))
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("gagal memulai sesi SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if cfg.Mode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("STARTTLS gagal: %w", err)
+			}
+		}
+	}
+	if cfg.User != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.User, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("autentikasi SMTP gagal: %w", err)
+		}
+	}
+
+	if err := client.Mail(cfg.Pengirim); err != nil {
+		return fmt.Errorf("pengirim ditolak server SMTP: %w", err)
+	}
+	for _, penerima := range pesan.Penerima {
+		if err := client.Rcpt(penerima); err != nil {
+			return fmt.Errorf("penerima %s ditolak server SMTP: %w", penerima, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("gagal mengirim isi email: %w", err)
+	}
+	if _, err := w.Write(isi); err != nil {
+		w.Close()
+		return fmt.Errorf("gagal mengirim isi email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email ditolak server SMTP: %w", err)
+	}
+	return client.Quit()
+}
+
+// susunEmail menyusun email MIME multipart/mixed berisi teks dan lampiran base64
+func susunEmail(pengirim string, pesan PesanEmail) ([]byte, error) {
+	acak := make([]byte, 12)
+	if _, err := rand.Read(acak); err != nil {
+		return nil, err
+	}
+	batas := "siak-" + hex.EncodeToString(acak)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", pengirim)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(pesan.Penerima, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", pesan.Subjek))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", batas)
+
+	fmt.Fprintf(&buf, "--%s\r\n", batas)
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	tulisBase64(&buf, []byte(pesan.Isi))
+
+	for _, lampiran := range pesan.Lampiran {
+		fmt.Fprintf(&buf, "--%s\r\n", batas)
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n", lampiran.ContentType)
+		fmt.Fprintf(&buf, "Content-Disposition: %s\r\n", mime.FormatMediaType("attachment", map[string]string{"filename": lampiran.NamaBerkas}))
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		tulisBase64(&buf, lampiran.Data)
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", batas)
+	return buf.Bytes(), nil
+}
+
+// tulisBase64 menulis data base64 dengan baris maksimal 76 karakter
+func tulisBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// MinimalDataPeramalan adalah jumlah titik data paling sedikit untuk membuat ramalan
+const MinimalDataPeramalan = 6
+
+// ModelHoltWinters adalah model pemulusan eksponensial aditif (level, tren dan musiman) yang
+// sudah dipasang pada satu deret waktu. Jika data kurang dari dua musim penuh, komponen musiman
+// tidak dipakai (metode Holt) dan Musim bernilai 0.
+type ModelHoltWinters struct {
+	Alpha float64 // pemulusan level
+	Beta  float64 // pemulusan tren
+	Gamma float64 // pemulusan musiman
+	Musim int     // panjang musim, 0 berarti tanpa musiman
+
+	// Fitted adalah ramalan satu langkah ke depan untuk setiap titik data mulai AwalFitted;
+	// titik sebelumnya dipakai untuk inisialisasi
+	Fitted     []float64
+	AwalFitted int
+	// Sigma adalah simpangan baku galat ramalan satu langkah
+	Sigma float64
+
+	n      int
+	level  float64
+	tren   float64
+	indeks []float64
+}
+
+// hasilPemulusan adalah keadaan akhir satu kali pemulusan dengan parameter tertentu
+type hasilPemulusan struct {
+	fitted []float64
+	awal   int
+	sse    float64
+	level  float64
+	tren   float64
+	indeks []float64
+}
+
+// PasangHoltWinters memasang model Holt-Winters aditif pada data. Parameter alpha, beta dan
+// gamma dipilih dengan pencarian grid (kasar lalu halus) yang meminimalkan jumlah kuadrat galat
+// ramalan satu langkah.
+func PasangHoltWinters(data []float64, musim int) (*ModelHoltWinters, error) {
+	if len(data) < MinimalDataPeramalan {
+		return nil, fmt.Errorf("data historis minimal %d periode, tersedia %d", MinimalDataPeramalan, len(data))
+	}
+	if musim < 2 || len(data) < 2*musim {
+		musim = 0
+	}
+
+	cari := func(pusat [3]float64, langkah float64, jumlah int) ([3]float64, *hasilPemulusan) {
+		var terbaik *hasilPemulusan
+		var param [3]float64
+		nilai := func(p float64, i int) float64 {
+			return math.Min(0.99, math.Max(0.01, p+langkah*float64(i-jumlah/2)))
+		}
+		jumlahGamma := jumlah
+		if musim == 0 {
+			jumlahGamma = 1
+		}
+		for i := 0; i < jumlah; i++ {
+			for j := 0; j < jumlah; j++ {
+				for k := 0; k < jumlahGamma; k++ {
+					p := [3]float64{nilai(pusat[0], i), nilai(pusat[1], j), nilai(pusat[2], k)}
+					if musim == 0 {
+						p[2] = 0
+					}
+					h := pemulusanHoltWinters(data, musim, p[0], p[1], p[2])
+					if terbaik == nil || h.sse < terbaik.sse {
+						terbaik, param = h, p
+					}
+				}
+			}
+		}
+		return param, terbaik
+	}
+
+	param, _ := cari([3]float64{0.5, 0.5, 0.5}, 0.1, 10)
+	param, hasil := cari(param, 0.01, 11)
+
+	jumlahFitted := len(hasil.fitted)
+	model := &ModelHoltWinters{
+		Alpha:      param[0],
+		Beta:       param[1],
+		Gamma:      param[2],
+		Musim:      musim,
+		Fitted:     hasil.fitted,
+		AwalFitted: hasil.awal,
+		n:          len(data),
+		level:      hasil.level,
+		tren:       hasil.tren,
+		indeks:     hasil.indeks,
+	}
+	if jumlahFitted > 0 {
+		model.Sigma = math.Sqrt(hasil.sse / float64(jumlahFitted))
+	}
+	return model, nil
+}
+
+// pemulusanHoltWinters menjalankan pemulusan satu kali. Dengan musiman, keadaan awal diambil
+// dari dua musim pertama (level = rata-rata musim pertama, tren = selisih rata-rata dua musim
+// dibagi panjang musim); tanpa musiman, dari dua titik pertama.
+func pemulusanHoltWinters(data []float64, musim int, alpha, beta, gamma float64) *hasilPemulusan {
+	h := &hasilPemulusan{}
+
+	if musim == 0 {
+		h.level = data[1]
+		h.tren = data[1] - data[0]
+		h.awal = 2
+	} else {
+		rata1, rata2 := rataRata(data[:musim]), rataRata(data[musim:2*musim])
+		h.tren = (rata2 - rata1) / float64(musim)
+		h.level = rata1 + h.tren*float64(musim-1)/2
+		h.indeks = make([]float64, musim)
+		for i := 0; i < musim; i++ {
+			h.indeks[i] = ((data[i] - rata1) + (data[musim+i] - rata2)) / 2
+		}
+		h.awal = musim
+	}
+
+	for t := h.awal; t < len(data); t++ {
+		musiman := 0.0
+		if musim > 0 {
+			musiman = h.indeks[t%musim]
+		}
+		ramalan := h.level + h.tren + musiman
+		galat := data[t] - ramalan
+		h.fitted = append(h.fitted, ramalan)
+		h.sse += galat * galat
+
+		levelLama := h.level
+		h.level = alpha*(data[t]-musiman) + (1-alpha)*(h.level+h.tren)
+		h.tren = beta*(h.level-levelLama) + (1-beta)*h.tren
+		if musim > 0 {
+			h.indeks[t%musim] = gamma*(data[t]-h.level) + (1-gamma)*musiman
+		}
+	}
+	return h
+}
+
+// Ramal menghitung ramalan titik untuk h periode setelah data terakhir beserta interval
+// prediksi dengan nilai z (misalnya 1.96 untuk 95%). Varians galat h langkah memakai
+// pendekatan model ETS(A,A,A): sigma² (1 + jumlah c_j² untuk j = 1..h-1) dengan
+// c_j = alpha (1 + j beta) + gamma (1 - alpha) jika j kelipatan musim.
+func (m *ModelHoltWinters) Ramal(h int, z float64) (titik, bawah, atas []float64) {
+	titik = make([]float64, h)
+	bawah = make([]float64, h)
+	atas = make([]float64, h)
+
+	jumlahC := 0.0
+	for i := 1; i <= h; i++ {
+		nilai := m.level + float64(i)*m.tren
+		if m.Musim > 0 {
+			nilai += m.indeks[(m.n-1+i)%m.Musim]
+		}
+
+		if j := i - 1; j >= 1 {
+			c := m.Alpha * (1 + float64(j)*m.Beta)
+			if m.Musim > 0 && j%m.Musim == 0 {
+				c += m.Gamma * (1 - m.Alpha)
+			}
+			jumlahC += c * c
+		}
+		lebar := z * m.Sigma * math.Sqrt(1+jumlahC)
+
+		titik[i-1] = nilai
+		bawah[i-1] = nilai - lebar
+		atas[i-1] = nilai + lebar
+	}
+	return titik, bawah, atas
+}
+
+// rataRata menghitung rata-rata aritmetika
+func rataRata(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range data {
+		total += v
+	}
+	return total / float64(len(data))
+}
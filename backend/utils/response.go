@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Kode error yang dikirim pada body response error
+const (
+	KodeInputTidakValid     = "invalid_parameter"
+	KodePermintaanTidakSah  = "bad_request"
+	KodeTidakTerautentikasi = "unauthorized"
+	KodeAksesDitolak        = "forbidden"
+	KodeTidakDitemukan      = "not_found"
+	KodeMetodeTidakDiizin   = "method_not_allowed"
+	KodeKonflik             = "conflict"
+	KodeTerlaluBanyak       = "too_many_requests"
+	KodeKesalahanServer     = "internal_error"
+	KodeLayananTidakSiap    = "service_unavailable"
+	KodeWaktuHabis          = "timeout"
+)
+
+// ErrorResponse adalah format body error yang seragam untuk seluruh API
+type ErrorResponse struct {
+	Status  string `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// kodeDariStatus menentukan kode error default berdasarkan status HTTP
+func kodeDariStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return KodePermintaanTidakSah
+	case http.StatusUnauthorized:
+		return KodeTidakTerautentikasi
+	case http.StatusForbidden:
+		return KodeAksesDitolak
+	case http.StatusNotFound:
+		return KodeTidakDitemukan
+	case http.StatusMethodNotAllowed:
+		return KodeMetodeTidakDiizin
+	case http.StatusConflict:
+		return KodeKonflik
+	case http.StatusTooManyRequests:
+		return KodeTerlaluBanyak
+	case http.StatusServiceUnavailable:
+		return KodeLayananTidakSiap
+	case http.StatusGatewayTimeout:
+		return KodeWaktuHabis
+	default:
+		return KodeKesalahanServer
+	}
+}
+
+// WriteError menulis response error JSON dengan kode yang ditentukan dari status HTTP
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteErrorResponse(w, status, ErrorResponse{
+		Code:    kodeDariStatus(status),
+		Message: message,
+	})
+}
+
+// WriteFieldError menulis response error 400 untuk parameter atau field input yang tidak valid
+func WriteFieldError(w http.ResponseWriter, field, message string) {
+	WriteErrorResponse(w, http.StatusBadRequest, ErrorResponse{
+		Code:    KodeInputTidakValid,
+		Message: message,
+		Field:   field,
+	})
+}
+
+// WriteErrorResponse menulis body error JSON apa adanya dengan status HTTP yang diberikan
+func WriteErrorResponse(w http.ResponseWriter, status int, body ErrorResponse) {
+	body.Status = "error"
+	if body.Code == "" {
+		body.Code = kodeDariStatus(status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	// Data zona waktu disertakan di binary agar Asia/Jakarta tetap tersedia di container
+	// yang tidak memiliki tzdata
+	_ "time/tzdata"
+)
+
+var (
+	zonaWaktuRS     *time.Location
+	zonaWaktuRSOnce sync.Once
+)
+
+// ZonaWaktuRS mengembalikan zona waktu rumah sakit yang dipakai untuk menerjemahkan tanggal
+// laporan. Diatur lewat RS_TIMEZONE, default Asia/Jakarta.
+func ZonaWaktuRS() *time.Location {
+	zonaWaktuRSOnce.Do(func() {
+		nama := getEnv("RS_TIMEZONE", "Asia/Jakarta")
+		loc, err := time.LoadLocation(nama)
+		if err != nil {
+			log.Printf("Zona waktu RS_TIMEZONE=%s tidak dikenal, menggunakan Asia/Jakarta: %v", nama, err)
+			loc, _ = time.LoadLocation("Asia/Jakarta")
+		}
+		zonaWaktuRS = loc
+	})
+	return zonaWaktuRS
+}
+
+// SekarangRS mengembalikan waktu saat ini pada zona waktu rumah sakit
+func SekarangRS() time.Time {
+	return time.Now().In(ZonaWaktuRS())
+}